@@ -1,6 +1,8 @@
 package conjurapi
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -32,28 +34,93 @@ type AuthenticatorStatusResponse struct {
 }
 
 func (c *Client) RefreshToken() (err error) {
+	return c.RefreshTokenCtx(context.Background())
+}
+
+// RefreshTokenCtx is like RefreshToken but uses ctx for cancellation and deadlines.
+func (c *Client) RefreshTokenCtx(ctx context.Context) (err error) {
 	// Fetch cached conjur access token if using OIDC
 	if c.GetConfig().AuthnType == "oidc" {
 		token := c.readCachedAccessToken()
 		if token != nil {
-			c.authToken = token
+			c.setToken(token)
 		}
 	}
 
-	if c.NeedsTokenRefresh() {
-		return c.refreshToken()
+	token := c.currentToken()
+	needsRefresh := c.needsTokenRefresh(token)
+	c.observeCacheLookup("token", !needsRefresh)
+	if needsRefresh {
+		return c.refreshToken(ctx, token)
 	}
 
 	return nil
 }
 
 func (c *Client) ForceRefreshToken() error {
-	return c.refreshToken()
+	return c.ForceRefreshTokenCtx(context.Background())
+}
+
+// ForceRefreshTokenCtx is like ForceRefreshToken but uses ctx for cancellation and deadlines.
+func (c *Client) ForceRefreshTokenCtx(ctx context.Context) error {
+	return c.refreshToken(ctx, c.currentToken())
 }
 
-func (c *Client) refreshToken() error {
+// tokenRefresh is an authentication call in progress. Goroutines which need a
+// new token while it runs wait for its result instead of authenticating too.
+type tokenRefresh struct {
+	done chan struct{}
+	err  error
+	// waiters is the number of goroutines waiting for the call's result.
+	waiters int
+}
+
+// refreshToken replaces the stale token with a new one from the authenticator.
+// Concurrent calls share a single authentication call, and if the stale token
+// has already been replaced by the time a call starts, it returns immediately.
+func (c *Client) refreshToken(ctx context.Context, stale *authn.AuthnToken) error {
+	for {
+		c.tokenMutex.Lock()
+		if c.authToken != stale && c.tokenRefresh == nil {
+			c.tokenMutex.Unlock()
+			return nil
+		}
+		flight := c.tokenRefresh
+		if flight == nil {
+			flight = &tokenRefresh{done: make(chan struct{})}
+			c.tokenRefresh = flight
+			c.tokenMutex.Unlock()
+
+			flight.err = c.fetchToken(ctx)
+			c.observeTokenRefresh(stale, flight.err)
+
+			c.tokenMutex.Lock()
+			c.tokenRefresh = nil
+			c.tokenMutex.Unlock()
+			close(flight.done)
+			return flight.err
+		}
+		flight.waiters++
+		c.tokenMutex.Unlock()
+
+		select {
+		case <-flight.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		// If the call we waited for was abandoned by its own caller, our
+		// context may still allow us to try again.
+		if isContextError(flight.err) && ctx.Err() == nil {
+			continue
+		}
+		return flight.err
+	}
+}
+
+func (c *Client) fetchToken(ctx context.Context) error {
 	var tokenBytes []byte
-	tokenBytes, err := c.authenticator.RefreshToken()
+	tokenBytes, err := c.refreshAuthenticatorToken(ctx)
 	if err != nil {
 		return err
 	}
@@ -64,13 +131,33 @@ func (c *Client) refreshToken() error {
 	}
 
 	token.FromJSON(tokenBytes)
-	c.authToken = token
+	c.setToken(token)
 	return nil
 }
 
+func (c *Client) currentToken() *authn.AuthnToken {
+	c.tokenMutex.RLock()
+	defer c.tokenMutex.RUnlock()
+	return c.authToken
+}
+
+func (c *Client) setToken(token *authn.AuthnToken) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+	c.authToken = token
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
 func (c *Client) NeedsTokenRefresh() bool {
-	return c.authToken == nil ||
-		c.authToken.ShouldRefresh() ||
+	return c.needsTokenRefresh(c.currentToken())
+}
+
+func (c *Client) needsTokenRefresh(token *authn.AuthnToken) bool {
+	return token == nil ||
+		token.ShouldRefresh() ||
 		c.authenticator.NeedsTokenRefresh()
 }
 
@@ -89,30 +176,87 @@ func (c *Client) readCachedAccessToken() *authn.AuthnToken {
 	return token
 }
 
-func (c *Client) createAuthRequest(req *http.Request) error {
-	if err := c.RefreshToken(); err != nil {
-		return err
+// refreshAuthenticatorToken obtains a new token from the authenticator, passing
+// ctx along with the client's logger when the authenticator supports it.
+func (c *Client) refreshAuthenticatorToken(ctx context.Context) ([]byte, error) {
+	if ctxAuthenticator, ok := c.authenticator.(ContextAuthenticator); ok {
+		return ctxAuthenticator.RefreshTokenCtx(logging.NewContext(ctx, c.log()))
 	}
+	return c.authenticator.RefreshToken()
+}
 
+// createAuthRequest makes sure the client holds a valid access token and sets
+// it on req. It returns the token which was used.
+func (c *Client) createAuthRequest(req *http.Request) (*authn.AuthnToken, error) {
+	if err := c.RefreshTokenCtx(req.Context()); err != nil {
+		return nil, err
+	}
+
+	token := c.currentToken()
+	setAuthorizationHeader(req, token)
+	return token, nil
+}
+
+func setAuthorizationHeader(req *http.Request, token *authn.AuthnToken) {
 	req.Header.Set(
 		"Authorization",
-		fmt.Sprintf("Token token=\"%s\"", base64.StdEncoding.EncodeToString(c.authToken.Raw())),
+		fmt.Sprintf("Token token=\"%s\"", base64.StdEncoding.EncodeToString(token.Raw())),
 	)
+}
 
-	return nil
+// SetReauthenticateOnUnauthorized controls whether requests rejected with a
+// 401 are replayed once with a freshly obtained access token. This is enabled
+// by default, so that a token revoked or rejected before it is due to expire
+// doesn't fail every request until it ages out.
+func (c *Client) SetReauthenticateOnUnauthorized(enabled bool) {
+	c.disableReauthentication = !enabled
+}
+
+// replayWithNewToken is called when the server rejects the access token of
+// req. It obtains a new token and resends req once. If no new token can be
+// obtained, the original response is returned unchanged.
+func (c *Client) replayWithNewToken(req *http.Request, resp *http.Response, rejected *authn.AuthnToken) (*http.Response, error) {
+	if err := c.refreshToken(req.Context(), rejected); err != nil {
+		c.log().Debug("Unable to obtain a new access token after HTTP status 401", "error", err)
+		return resp, nil
+	}
+
+	// Replaying with the token which was just rejected would be pointless, which
+	// is the case for authenticators holding a fixed token.
+	token := c.currentToken()
+	if bytes.Equal(rejected.Raw(), token.Raw()) {
+		return resp, nil
+	}
+
+	if err := rewindBody(req); err != nil {
+		return resp, nil
+	}
+
+	c.log().Debug("Replaying request with a new access token", "method", req.Method, "url", req.URL.String())
+	discardResponse(resp)
+	setAuthorizationHeader(req, token)
+	return c.submitRequestWithCustomAuth(req)
 }
 
 func (c *Client) ChangeUserPassword(username string, password string, newPassword string) ([]byte, error) {
+	return c.ChangeUserPasswordCtx(context.Background(), username, password, newPassword)
+}
+
+// ChangeUserPasswordCtx is like ChangeUserPassword but uses ctx for cancellation and deadlines.
+func (c *Client) ChangeUserPasswordCtx(ctx context.Context, username string, password string, newPassword string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "ChangeUserPassword", "user", username)
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return nil, errors.New("Change User Password is not supported in Conjur Cloud")
 	}
 
-	req, err := c.ChangeUserPasswordRequest(username, password, newPassword)
+	req, err := c.ChangeUserPasswordRequestCtx(ctx, username, password, newPassword)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -121,26 +265,39 @@ func (c *Client) ChangeUserPassword(username string, password string, newPasswor
 }
 
 func (c *Client) ChangeCurrentUserPassword(newPassword string) ([]byte, error) {
+	return c.ChangeCurrentUserPasswordCtx(context.Background(), newPassword)
+}
+
+// ChangeCurrentUserPasswordCtx is like ChangeCurrentUserPassword but uses ctx for cancellation and deadlines.
+func (c *Client) ChangeCurrentUserPasswordCtx(ctx context.Context, newPassword string) ([]byte, error) {
 	username, password, err := c.storage.ReadCredentials()
 	if err != nil {
 		return nil, err
 	}
 
-	return c.ChangeUserPassword(username, password, newPassword)
+	return c.ChangeUserPasswordCtx(ctx, username, password, newPassword)
 }
 
 // Login exchanges a user's password for an API key.
 func (c *Client) Login(login string, password string) ([]byte, error) {
+	return c.LoginCtx(context.Background(), login, password)
+}
+
+// LoginCtx is like Login but uses ctx for cancellation and deadlines.
+func (c *Client) LoginCtx(ctx context.Context, login string, password string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "Login", "", loginResourceID(login))
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) && !strings.HasPrefix(login, "host/") {
 		return nil, errors.New("Login for users is not supported in Conjur Cloud")
 	}
 
-	req, err := c.LoginRequest(login, password)
+	req, err := c.LoginRequestCtx(ctx, login, password)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -184,6 +341,11 @@ func PurgeCredentials(config Config) error {
 
 // Authenticate obtains a new access token using the internal authenticator.
 func (c *Client) InternalAuthenticate() ([]byte, error) {
+	return c.InternalAuthenticateCtx(context.Background())
+}
+
+// InternalAuthenticateCtx is like InternalAuthenticate but uses ctx for cancellation and deadlines.
+func (c *Client) InternalAuthenticateCtx(ctx context.Context) ([]byte, error) {
 	if c.authenticator == nil {
 		return nil, errors.New("unable to authenticate using client without authenticator")
 	}
@@ -201,12 +363,20 @@ func (c *Client) InternalAuthenticate() ([]byte, error) {
 	}
 
 	// Otherwise refresh the token
-	return c.authenticator.RefreshToken()
+	return c.refreshAuthenticatorToken(ctx)
 }
 
 // WhoAmI obtains information on the current user.
 func (c *Client) WhoAmI() ([]byte, error) {
-	req, err := c.WhoAmIRequest()
+	return c.WhoAmICtx(context.Background())
+}
+
+// WhoAmICtx is like WhoAmI but uses ctx for cancellation and deadlines.
+func (c *Client) WhoAmICtx(ctx context.Context) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "WhoAmI", "", "")
+	defer func() { span.end(err) }()
+
+	req, err := c.WhoAmIRequestCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -221,7 +391,15 @@ func (c *Client) WhoAmI() ([]byte, error) {
 
 // Authenticate obtains a new access token.
 func (c *Client) Authenticate(loginPair authn.LoginPair) ([]byte, error) {
-	resp, err := c.authenticate(loginPair)
+	return c.AuthenticateCtx(context.Background(), loginPair)
+}
+
+// AuthenticateCtx is like Authenticate but uses ctx for cancellation and deadlines.
+func (c *Client) AuthenticateCtx(ctx context.Context, loginPair authn.LoginPair) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "Authenticate", "", loginResourceID(loginPair.Login))
+	defer func() { span.end(err) }()
+
+	resp, err := c.authenticate(ctx, loginPair)
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +409,15 @@ func (c *Client) Authenticate(loginPair authn.LoginPair) ([]byte, error) {
 
 // AuthenticateReader obtains a new access token and returns it as a data stream.
 func (c *Client) AuthenticateReader(loginPair authn.LoginPair) (io.ReadCloser, error) {
-	resp, err := c.authenticate(loginPair)
+	return c.AuthenticateReaderCtx(context.Background(), loginPair)
+}
+
+// AuthenticateReaderCtx is like AuthenticateReader but uses ctx for cancellation and deadlines.
+func (c *Client) AuthenticateReaderCtx(ctx context.Context, loginPair authn.LoginPair) (_ io.ReadCloser, err error) {
+	ctx, span := c.startSpan(ctx, "AuthenticateReader", "", loginResourceID(loginPair.Login))
+	defer func() { span.end(err) }()
+
+	resp, err := c.authenticate(ctx, loginPair)
 	if err != nil {
 		return nil, err
 	}
@@ -239,22 +425,30 @@ func (c *Client) AuthenticateReader(loginPair authn.LoginPair) (io.ReadCloser, e
 	return response.SecretDataResponse(resp)
 }
 
-func (c *Client) authenticate(loginPair authn.LoginPair) (*http.Response, error) {
-	req, err := c.AuthenticateRequest(loginPair)
+func (c *Client) authenticate(ctx context.Context, loginPair authn.LoginPair) (*http.Response, error) {
+	req, err := c.AuthenticateRequestCtx(ctx, loginPair)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 func (c *Client) OidcAuthenticate(code, nonce, code_verifier string) ([]byte, error) {
-	req, err := c.OidcAuthenticateRequest(code, nonce, code_verifier)
+	return c.OidcAuthenticateCtx(context.Background(), code, nonce, code_verifier)
+}
+
+// OidcAuthenticateCtx is like OidcAuthenticate but uses ctx for cancellation and deadlines.
+func (c *Client) OidcAuthenticateCtx(ctx context.Context, code, nonce, code_verifier string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "OidcAuthenticate", "", "")
+	defer func() { span.end(err) }()
+
+	req, err := c.OidcAuthenticateRequestCtx(ctx, code, nonce, code_verifier)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -269,12 +463,20 @@ func (c *Client) OidcAuthenticate(code, nonce, code_verifier string) ([]byte, er
 }
 
 func (c *Client) OidcTokenAuthenticate(token string) ([]byte, error) {
-	req, err := c.OidcTokenAuthenticateRequest(token)
+	return c.OidcTokenAuthenticateCtx(context.Background(), token)
+}
+
+// OidcTokenAuthenticateCtx is like OidcTokenAuthenticate but uses ctx for cancellation and deadlines.
+func (c *Client) OidcTokenAuthenticateCtx(ctx context.Context, token string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "OidcTokenAuthenticate", "", "")
+	defer func() { span.end(err) }()
+
+	req, err := c.OidcTokenAuthenticateRequestCtx(ctx, token)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -289,12 +491,20 @@ func (c *Client) OidcTokenAuthenticate(token string) ([]byte, error) {
 }
 
 func (c *Client) JWTAuthenticate(jwt, hostID string) ([]byte, error) {
-	req, err := c.JWTAuthenticateRequest(jwt, hostID)
+	return c.JWTAuthenticateCtx(context.Background(), jwt, hostID)
+}
+
+// JWTAuthenticateCtx is like JWTAuthenticate but uses ctx for cancellation and deadlines.
+func (c *Client) JWTAuthenticateCtx(ctx context.Context, jwt, hostID string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "JWTAuthenticate", "", loginResourceID(hostID))
+	defer func() { span.end(err) }()
+
+	req, err := c.JWTAuthenticateRequestCtx(ctx, jwt, hostID)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := c.httpClient.Do(req)
+	res, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -309,16 +519,24 @@ func (c *Client) JWTAuthenticate(jwt, hostID string) ([]byte, error) {
 }
 
 func (c *Client) ListOidcProviders() ([]OidcProvider, error) {
+	return c.ListOidcProvidersCtx(context.Background())
+}
+
+// ListOidcProvidersCtx is like ListOidcProviders but uses ctx for cancellation and deadlines.
+func (c *Client) ListOidcProvidersCtx(ctx context.Context) (_ []OidcProvider, err error) {
+	ctx, span := c.startSpan(ctx, "ListOidcProviders", "", "")
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return nil, errors.New("List OIDC Providers is not supported in Conjur Cloud")
 	}
 
-	req, err := c.ListOidcProvidersRequest()
+	req, err := c.ListOidcProvidersRequestCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -335,7 +553,15 @@ func (c *Client) ListOidcProviders() ([]OidcProvider, error) {
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateAPIKey(roleID string) ([]byte, error) {
-	resp, err := c.rotateAPIKey(roleID)
+	return c.RotateAPIKeyCtx(context.Background(), roleID)
+}
+
+// RotateAPIKeyCtx is like RotateAPIKey but uses ctx for cancellation and deadlines.
+func (c *Client) RotateAPIKeyCtx(ctx context.Context, roleID string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "RotateAPIKey", "", roleID)
+	defer func() { span.end(err) }()
+
+	resp, err := c.rotateAPIKey(ctx, roleID)
 	if err != nil {
 		return nil, err
 	}
@@ -347,12 +573,25 @@ func (c *Client) RotateAPIKey(roleID string) ([]byte, error) {
 // role with a new random secret. It is a wrapper for RotateCurrentRoleAPIKey
 // for backwards-compatiblity.
 func (c *Client) RotateCurrentUserAPIKey() ([]byte, error) {
-	return c.RotateCurrentRoleAPIKey()
+	return c.RotateCurrentUserAPIKeyCtx(context.Background())
+}
+
+// RotateCurrentUserAPIKeyCtx is like RotateCurrentUserAPIKey but uses ctx for cancellation and deadlines.
+func (c *Client) RotateCurrentUserAPIKeyCtx(ctx context.Context) ([]byte, error) {
+	return c.RotateCurrentRoleAPIKeyCtx(ctx)
 }
 
 // RotateCurrentRoleAPIKey replaces the API key of the currently authenticated
 // role with a new random secret.
 func (c *Client) RotateCurrentRoleAPIKey() ([]byte, error) {
+	return c.RotateCurrentRoleAPIKeyCtx(context.Background())
+}
+
+// RotateCurrentRoleAPIKeyCtx is like RotateCurrentRoleAPIKey but uses ctx for cancellation and deadlines.
+func (c *Client) RotateCurrentRoleAPIKeyCtx(ctx context.Context) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "RotateCurrentRoleAPIKey", "", "")
+	defer func() { span.end(err) }()
+
 	roleID, password, err := c.storage.ReadCredentials()
 	if err != nil {
 		return nil, err
@@ -362,7 +601,7 @@ func (c *Client) RotateCurrentRoleAPIKey() ([]byte, error) {
 		return nil, errors.New("Rotate API Key for users is not supported in Conjur Cloud")
 	}
 
-	resp, err := c.rotateCurrentRoleAPIKey(roleID, password)
+	resp, err := c.rotateCurrentRoleAPIKey(ctx, roleID, password)
 	if err != nil {
 		return nil, err
 	}
@@ -378,10 +617,15 @@ func (c *Client) RotateCurrentRoleAPIKey() ([]byte, error) {
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateUserAPIKey(userID string) ([]byte, error) {
+	return c.RotateUserAPIKeyCtx(context.Background(), userID)
+}
+
+// RotateUserAPIKeyCtx is like RotateUserAPIKey but uses ctx for cancellation and deadlines.
+func (c *Client) RotateUserAPIKeyCtx(ctx context.Context, userID string) ([]byte, error) {
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return nil, errors.New("Rotate API Key for users is not supported in Conjur Cloud")
 	}
-	return c.rotateApiKeyAndEnforceKind(userID, "user")
+	return c.rotateApiKeyAndEnforceKind(ctx, userID, "user")
 }
 
 // RotateHostAPIKey constructs a role ID from a given host ID then replaces the
@@ -392,17 +636,22 @@ func (c *Client) RotateUserAPIKey(userID string) ([]byte, error) {
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateHostAPIKey(hostID string) ([]byte, error) {
-	return c.rotateApiKeyAndEnforceKind(hostID, "host")
+	return c.RotateHostAPIKeyCtx(context.Background(), hostID)
+}
+
+// RotateHostAPIKeyCtx is like RotateHostAPIKey but uses ctx for cancellation and deadlines.
+func (c *Client) RotateHostAPIKeyCtx(ctx context.Context, hostID string) ([]byte, error) {
+	return c.rotateApiKeyAndEnforceKind(ctx, hostID, "host")
 }
 
-func (c *Client) rotateApiKeyAndEnforceKind(roleID, kind string) ([]byte, error) {
+func (c *Client) rotateApiKeyAndEnforceKind(ctx context.Context, roleID, kind string) ([]byte, error) {
 	account, kind, identifier, err := c.parseIDandEnforceKind(roleID, kind)
 	if err != nil {
 		return nil, err
 	}
 
 	roleID = fmt.Sprintf("%s:%s:%s", account, kind, identifier)
-	return c.RotateAPIKey(roleID)
+	return c.RotateAPIKeyCtx(ctx, roleID)
 }
 
 // RotateAPIKeyReader replaces the API key of a role on the server with a new
@@ -410,7 +659,15 @@ func (c *Client) rotateApiKeyAndEnforceKind(roleID, kind string) ([]byte, error)
 //
 // The authenticated user must have update privilege on the role.
 func (c *Client) RotateAPIKeyReader(roleID string) (io.ReadCloser, error) {
-	resp, err := c.rotateAPIKey(roleID)
+	return c.RotateAPIKeyReaderCtx(context.Background(), roleID)
+}
+
+// RotateAPIKeyReaderCtx is like RotateAPIKeyReader but uses ctx for cancellation and deadlines.
+func (c *Client) RotateAPIKeyReaderCtx(ctx context.Context, roleID string) (_ io.ReadCloser, err error) {
+	ctx, span := c.startSpan(ctx, "RotateAPIKeyReader", "", roleID)
+	defer func() { span.end(err) }()
+
+	resp, err := c.rotateAPIKey(ctx, roleID)
 	if err != nil {
 		return nil, err
 	}
@@ -418,8 +675,8 @@ func (c *Client) RotateAPIKeyReader(roleID string) (io.ReadCloser, error) {
 	return response.SecretDataResponse(resp)
 }
 
-func (c *Client) rotateAPIKey(roleID string) (*http.Response, error) {
-	req, err := c.RotateAPIKeyRequest(roleID)
+func (c *Client) rotateAPIKey(ctx context.Context, roleID string) (*http.Response, error) {
+	req, err := c.RotateAPIKeyRequestCtx(ctx, roleID)
 	if err != nil {
 		return nil, err
 	}
@@ -427,21 +684,29 @@ func (c *Client) rotateAPIKey(roleID string) (*http.Response, error) {
 	return c.SubmitRequest(req)
 }
 
-func (c *Client) rotateCurrentRoleAPIKey(roleID string, password string) (*http.Response, error) {
-	req, err := c.RotateCurrentRoleAPIKeyRequest(roleID, password)
+func (c *Client) rotateCurrentRoleAPIKey(ctx context.Context, roleID string, password string) (*http.Response, error) {
+	req, err := c.RotateCurrentRoleAPIKeyRequestCtx(ctx, roleID, password)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.httpClient.Do(req)
+	return c.do(req)
 }
 
 func (c *Client) PublicKeys(kind string, identifier string) ([]byte, error) {
+	return c.PublicKeysCtx(context.Background(), kind, identifier)
+}
+
+// PublicKeysCtx is like PublicKeys but uses ctx for cancellation and deadlines.
+func (c *Client) PublicKeysCtx(ctx context.Context, kind string, identifier string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "PublicKeys", kind, identifier)
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return nil, errors.New("Public Keys is not supported in Conjur Cloud")
 	}
 
-	req, err := c.PublicKeysRequest(kind, identifier)
+	req, err := c.PublicKeysRequestCtx(ctx, kind, identifier)
 	if err != nil {
 		return nil, err
 	}
@@ -458,7 +723,15 @@ func (c *Client) PublicKeys(kind string, identifier string) ([]byte, error) {
 //
 // The authenticated user must be admin
 func (c *Client) EnableAuthenticator(authenticatorType string, serviceID string, enabled bool) error {
-	req, err := c.EnableAuthenticatorRequest(authenticatorType, serviceID, enabled)
+	return c.EnableAuthenticatorCtx(context.Background(), authenticatorType, serviceID, enabled)
+}
+
+// EnableAuthenticatorCtx is like EnableAuthenticator but uses ctx for cancellation and deadlines.
+func (c *Client) EnableAuthenticatorCtx(ctx context.Context, authenticatorType string, serviceID string, enabled bool) (err error) {
+	ctx, span := c.startSpan(ctx, "EnableAuthenticator", "webservice", "")
+	defer func() { span.end(err) }()
+
+	req, err := c.EnableAuthenticatorRequestCtx(ctx, authenticatorType, serviceID, enabled)
 	if err != nil {
 		return err
 	}
@@ -477,7 +750,15 @@ func (c *Client) EnableAuthenticator(authenticatorType string, serviceID string,
 }
 
 func (c *Client) AuthenticatorStatus(authenticatorType string, serviceID string) (*AuthenticatorStatusResponse, error) {
-	req, err := c.AuthenticatorStatusRequest(authenticatorType, serviceID)
+	return c.AuthenticatorStatusCtx(context.Background(), authenticatorType, serviceID)
+}
+
+// AuthenticatorStatusCtx is like AuthenticatorStatus but uses ctx for cancellation and deadlines.
+func (c *Client) AuthenticatorStatusCtx(ctx context.Context, authenticatorType string, serviceID string) (_ *AuthenticatorStatusResponse, err error) {
+	ctx, span := c.startSpan(ctx, "AuthenticatorStatus", "webservice", "")
+	defer func() { span.end(err) }()
+
+	req, err := c.AuthenticatorStatusRequestCtx(ctx, authenticatorType, serviceID)
 	if err != nil {
 		return nil, err
 	}
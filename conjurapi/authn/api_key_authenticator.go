@@ -1,7 +1,10 @@
 package authn
 
+import "context"
+
 type APIKeyAuthenticator struct {
-	Authenticate func(loginPair LoginPair) ([]byte, error)
+	Authenticate    func(loginPair LoginPair) ([]byte, error)
+	AuthenticateCtx func(ctx context.Context, loginPair LoginPair) ([]byte, error)
 	LoginPair
 }
 
@@ -11,6 +14,15 @@ type LoginPair struct {
 }
 
 func (a *APIKeyAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenCtx(context.Background())
+}
+
+// RefreshTokenCtx exchanges the API key for a new access token. The context is
+// honored when AuthenticateCtx is set, otherwise Authenticate is used.
+func (a *APIKeyAuthenticator) RefreshTokenCtx(ctx context.Context) ([]byte, error) {
+	if a.AuthenticateCtx != nil {
+		return a.AuthenticateCtx(ctx, a.LoginPair)
+	}
 	return a.Authenticate(a.LoginPair)
 }
 
@@ -1,6 +1,7 @@
 package authn
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -51,6 +52,22 @@ func TestAPIKeyAuthenticator_RefreshToken(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "401")
 	})
+
+	t.Run("Prefers AuthenticateCtx and passes the context through", func(t *testing.T) {
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+		authenticator := APIKeyAuthenticator{
+			Authenticate: authenticate,
+			AuthenticateCtx: func(ctx context.Context, loginPair LoginPair) ([]byte, error) {
+				return []byte(ctx.Value(ctxKey{}).(string)), nil
+			},
+		}
+
+		token, err := authenticator.RefreshTokenCtx(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "value", string(token))
+	})
 }
 
 func TestAPIKeyAuthenticator_NeedsTokenRefresh(t *testing.T) {
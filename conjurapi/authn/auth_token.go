@@ -98,14 +98,32 @@ func (t *AuthnToken) Raw() []byte {
 	return t.bytes
 }
 
-func (t *AuthnToken) ShouldRefresh() bool {
+// IssuedAt returns the time at which the token was issued.
+func (t *AuthnToken) IssuedAt() time.Time {
+	return t.iat
+}
+
+// ExpiresAt returns the time at which the token expires. Tokens which don't
+// carry an expiration expire 8 minutes after they are issued.
+func (t *AuthnToken) ExpiresAt() time.Time {
+	if t.exp != nil {
+		return *t.exp
+	}
+	return t.iat.Add(8 * time.Minute)
+}
+
+// RefreshAt returns the time after which the token should be refreshed.
+func (t *AuthnToken) RefreshAt() time.Time {
 	if t.exp != nil {
 		// Expire when the token is 85% expired
 		lifespan := t.exp.Sub(t.iat)
 		duration := float32(lifespan) * 0.85
-		return time.Now().After(t.iat.Add(time.Duration(duration)))
-	} else {
-		// Token expires 8 minutes after issue, by default
-		return time.Now().After(t.iat.Add(5 * time.Minute))
+		return t.iat.Add(time.Duration(duration))
 	}
+	// Token expires 8 minutes after issue, by default
+	return t.iat.Add(5 * time.Minute)
+}
+
+func (t *AuthnToken) ShouldRefresh() bool {
+	return time.Now().After(t.RefreshAt())
 }
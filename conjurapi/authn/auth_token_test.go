@@ -45,6 +45,21 @@ func TestToken_Parse(t *testing.T) {
 		assert.True(t, token.ShouldRefresh())
 	})
 
+	t.Run("Token lifetime is reported", func(t *testing.T) {
+		token, err := NewToken([]byte(token_with_exp_s))
+		assert.NoError(t, err)
+
+		assert.Equal(t, time.Unix(1510753259, 0), token.IssuedAt())
+		assert.Equal(t, time.Unix(1510753359, 0), token.ExpiresAt())
+		assert.WithinDuration(t, time.Unix(1510753344, 0), token.RefreshAt(), time.Millisecond)
+
+		token, err = NewToken([]byte(token_s))
+		assert.NoError(t, err)
+
+		assert.Equal(t, time.Unix(1510753259, 0).Add(8*time.Minute), token.ExpiresAt())
+		assert.Equal(t, time.Unix(1510753259, 0).Add(5*time.Minute), token.RefreshAt())
+	})
+
 	t.Run("Malformed base64 in token is reported", func(t *testing.T) {
 		_, err := NewToken([]byte(token_mangled_s))
 		assert.Equal(t, "access token field 'payload' is not valid base64", err.Error())
@@ -1,27 +1,42 @@
 package authn
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"sync"
 
 	"github.com/cyberark/conjur-api-go/conjurapi/logging"
 )
 
 type JWTAuthenticator struct {
-	JWT          string
-	JWTFilePath  string
-	HostID       string
-	Authenticate func(jwt, hostId string) ([]byte, error)
+	JWT             string
+	JWTFilePath     string
+	HostID          string
+	Authenticate    func(jwt, hostId string) ([]byte, error)
+	AuthenticateCtx func(ctx context.Context, jwt, hostId string) ([]byte, error)
+	mutex           *sync.Mutex
 }
 
 const k8sJWTPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 
 func (a *JWTAuthenticator) RefreshToken() ([]byte, error) {
-	err := a.RefreshJWT()
+	return a.RefreshTokenCtx(context.Background())
+}
+
+func (a *JWTAuthenticator) RefreshTokenCtx(ctx context.Context) ([]byte, error) {
+	a.lock().Lock()
+	err := a.refreshJWT(logging.FromContext(ctx))
+	jwt := a.JWT
+	a.lock().Unlock()
 	if err != nil {
 		return nil, fmt.Errorf("Failed to refresh JWT: %v", err)
 	}
-	return a.Authenticate(a.JWT, a.HostID)
+	if a.AuthenticateCtx != nil {
+		return a.AuthenticateCtx(ctx, jwt, a.HostID)
+	}
+	return a.Authenticate(jwt, a.HostID)
 }
 
 func (a *JWTAuthenticator) NeedsTokenRefresh() bool {
@@ -29,9 +44,15 @@ func (a *JWTAuthenticator) NeedsTokenRefresh() bool {
 }
 
 func (a *JWTAuthenticator) RefreshJWT() error {
+	a.lock().Lock()
+	defer a.lock().Unlock()
+	return a.refreshJWT(logging.Default())
+}
+
+func (a *JWTAuthenticator) refreshJWT(logger *slog.Logger) error {
 	// If a JWT token is already set or retrieved, do nothing.
 	if a.JWT != "" {
-		logging.ApiLog.Debugf("Using stored JWT")
+		logger.Debug("Using stored JWT")
 		return nil
 	}
 
@@ -39,11 +60,11 @@ func (a *JWTAuthenticator) RefreshJWT() error {
 	// Otherwise, read the token from the default Kubernetes service account path.
 	var jwtFilePath string
 	if a.JWTFilePath != "" {
-		logging.ApiLog.Debugf("Reading JWT from %s", a.JWTFilePath)
+		logger.Debug("Reading JWT from file", "path", a.JWTFilePath)
 		jwtFilePath = a.JWTFilePath
 	} else {
 		jwtFilePath = k8sJWTPath
-		logging.ApiLog.Debugf("No JWT file path set. Attempting to ready JWT from %s", jwtFilePath)
+		logger.Debug("No JWT file path set, reading JWT from the Kubernetes service account token", "path", jwtFilePath)
 	}
 
 	token, err := readJWTFromFile(jwtFilePath)
@@ -61,3 +82,7 @@ func readJWTFromFile(filePath string) (string, error) {
 	}
 	return string(bytes), nil
 }
+
+func (a *JWTAuthenticator) lock() *sync.Mutex {
+	return lazyMutex(&a.mutex)
+}
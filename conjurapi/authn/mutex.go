@@ -0,0 +1,17 @@
+package authn
+
+import "sync"
+
+// mutexes guards the lazy creation of the mutexes of authenticators.
+var mutexes sync.Mutex
+
+// lazyMutex returns *m, creating it on first use. Authenticators hold their
+// mutex by pointer so that they can be declared as literals and copied.
+func lazyMutex(m **sync.Mutex) *sync.Mutex {
+	mutexes.Lock()
+	defer mutexes.Unlock()
+	if *m == nil {
+		*m = &sync.Mutex{}
+	}
+	return *m
+}
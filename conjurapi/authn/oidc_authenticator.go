@@ -1,13 +1,23 @@
 package authn
 
+import "context"
+
 type OidcAuthenticator struct {
-	Code         string
-	Nonce        string
-	CodeVerifier string
-	Authenticate func(code, nonce, code_verifier string) ([]byte, error)
+	Code            string
+	Nonce           string
+	CodeVerifier    string
+	Authenticate    func(code, nonce, code_verifier string) ([]byte, error)
+	AuthenticateCtx func(ctx context.Context, code, nonce, code_verifier string) ([]byte, error)
 }
 
 func (a *OidcAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenCtx(context.Background())
+}
+
+func (a *OidcAuthenticator) RefreshTokenCtx(ctx context.Context) ([]byte, error) {
+	if a.AuthenticateCtx != nil {
+		return a.AuthenticateCtx(ctx, a.Code, a.Nonce, a.CodeVerifier)
+	}
 	return a.Authenticate(a.Code, a.Nonce, a.CodeVerifier)
 }
 
@@ -16,11 +26,19 @@ func (a *OidcAuthenticator) NeedsTokenRefresh() bool {
 }
 
 type OidcTokenAuthenticator struct {
-	Token        string
-	Authenticate func(token string) ([]byte, error)
+	Token           string
+	Authenticate    func(token string) ([]byte, error)
+	AuthenticateCtx func(ctx context.Context, token string) ([]byte, error)
 }
 
 func (a *OidcTokenAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenCtx(context.Background())
+}
+
+func (a *OidcTokenAuthenticator) RefreshTokenCtx(ctx context.Context) ([]byte, error) {
+	if a.AuthenticateCtx != nil {
+		return a.AuthenticateCtx(ctx, a.Token)
+	}
 	return a.Authenticate(a.Token)
 }
 
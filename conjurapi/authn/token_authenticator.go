@@ -1,5 +1,7 @@
 package authn
 
+import "context"
+
 type TokenAuthenticator struct {
 	Token string `env:"CONJUR_AUTHN_TOKEN"`
 }
@@ -8,6 +10,13 @@ func (a *TokenAuthenticator) RefreshToken() ([]byte, error) {
 	return []byte(a.Token), nil
 }
 
+func (a *TokenAuthenticator) RefreshTokenCtx(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.RefreshToken()
+}
+
 func (a *TokenAuthenticator) NeedsTokenRefresh() bool {
 	return false
 }
@@ -1,7 +1,9 @@
 package authn
 
 import (
+	"context"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -9,10 +11,16 @@ type TokenFileAuthenticator struct {
 	TokenFile   string `env:"CONJUR_AUTHN_TOKEN_FILE"`
 	mTime       time.Time
 	MaxWaitTime time.Duration
+	mutex       *sync.Mutex
 }
 
-//  TODO: is this implementation concurrent ?
 func (a *TokenFileAuthenticator) RefreshToken() ([]byte, error) {
+	return a.RefreshTokenCtx(context.Background())
+}
+
+// RefreshTokenCtx waits for the token file to exist and reads it. The wait is
+// abandoned when either MaxWaitTime elapses or the context is done.
+func (a *TokenFileAuthenticator) RefreshTokenCtx(ctx context.Context) ([]byte, error) {
 	maxWaitTime := a.MaxWaitTime
 	var timeout <-chan time.Time
 	if maxWaitTime == -1 {
@@ -21,15 +29,23 @@ func (a *TokenFileAuthenticator) RefreshToken() ([]byte, error) {
 		timeout = time.After(a.MaxWaitTime)
 	}
 
-	bytes, err := waitForTextFile(a.TokenFile, timeout)
+	bytes, err := waitForTextFile(ctx, a.TokenFile, timeout)
 	if err == nil {
 		fi, _ := os.Stat(a.TokenFile)
+		a.lock().Lock()
 		a.mTime = fi.ModTime()
+		a.lock().Unlock()
 	}
 	return bytes, err
 }
 
 func (a *TokenFileAuthenticator) NeedsTokenRefresh() bool {
 	fi, _ := os.Stat(a.TokenFile)
+	a.lock().Lock()
+	defer a.lock().Unlock()
 	return a.mTime != fi.ModTime()
 }
+
+func (a *TokenFileAuthenticator) lock() *sync.Mutex {
+	return lazyMutex(&a.mutex)
+}
@@ -1,6 +1,7 @@
 package authn
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -10,7 +11,7 @@ import (
 
 func Test_waitForTextFile(t *testing.T) {
 	t.Run("Times out for non-existent filename", func(t *testing.T) {
-		bytes, err := waitForTextFile("path/to/non-existent/file", time.After(0))
+		bytes, err := waitForTextFile(context.Background(), "path/to/non-existent/file", time.After(0))
 		assert.Error(t, err)
 		assert.Equal(t, err.Error(), "Operation waitForTextFile timed out.")
 		assert.Nil(t, bytes)
@@ -26,10 +27,19 @@ func Test_waitForTextFile(t *testing.T) {
 		}()
 		defer os.Remove(file_to_exist_name)
 
-		bytes, err := waitForTextFile(file_to_exist_name, nil)
+		bytes, err := waitForTextFile(context.Background(), file_to_exist_name, nil)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "some random stuff", string(bytes))
 
 	})
+
+	t.Run("Returns context error when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		bytes, err := waitForTextFile(ctx, "path/to/non-existent/file", nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, bytes)
+	})
 }
@@ -1,14 +1,19 @@
 package conjurapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -745,6 +750,145 @@ func TestClient_ForceRefreshToken(t *testing.T) {
 	})
 }
 
+func TestClient_ConcurrentTokenRefresh(t *testing.T) {
+	const goroutines = 50
+
+	// holdAuthentications returns an option which makes a test server call
+	// hold before serving each authentication.
+	holdAuthentications := func(hold func(w http.ResponseWriter) bool) conjurtest.Option {
+		return conjurtest.WithMiddleware(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasPrefix(r.URL.Path, "/authn") && !hold(w) {
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+
+	// hammer runs fn from many goroutines at once and returns their errors
+	hammer := func(fn func() error) []error {
+		var wg sync.WaitGroup
+		errs := make([]error, goroutines)
+		start := make(chan struct{})
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				errs[i] = fn()
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+		return errs
+	}
+
+	t.Run("Authenticates once for concurrent requests", func(t *testing.T) {
+		server := newTestServer(t)
+		client := newTestServerClient(t, server)
+
+		errs := hammer(func() error {
+			_, err := client.RetrieveSecret("my-var")
+			return err
+		})
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 1, server.CountRequests("/authn"))
+		assert.Equal(t, goroutines, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Collapses concurrent forced refreshes into one authentication", func(t *testing.T) {
+		release := make(chan struct{})
+		server := newTestServer(t, holdAuthentications(func(w http.ResponseWriter) bool {
+			<-release
+			return true
+		}))
+		client := newTestServerClient(t, server)
+		client.authToken, _ = authn.NewToken([]byte(makeTestToken(0, time.Now(), 8*time.Minute)))
+		stale := client.currentToken()
+
+		// Hold the first authentication for a while so that the other
+		// goroutines pile up behind it. Any goroutine arriving after it
+		// completes finds the stale token already replaced.
+		go func() {
+			for server.CountRequests("/authn") == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+		}()
+
+		errs := hammer(func() error {
+			return client.refreshToken(context.Background(), stale)
+		})
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, 1, server.CountRequests("/authn"))
+		assert.NotEqual(t, stale, client.currentToken())
+	})
+
+	t.Run("Shares the error of a failed refresh", func(t *testing.T) {
+		var client *Client
+		server := newTestServer(t, holdAuthentications(func(w http.ResponseWriter) bool {
+			// Fail only once all the other goroutines wait for this
+			// authentication, so that none of them can start another one.
+			for refreshWaiters(client) < goroutines-1 {
+				time.Sleep(time.Millisecond)
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			return false
+		}))
+		client = newTestServerClient(t, server)
+
+		errs := hammer(func() error {
+			_, err := client.RetrieveSecret("my-var")
+			return err
+		})
+		for _, err := range errs {
+			assert.ErrorContains(t, err, "401 Unauthorized")
+		}
+		assert.Equal(t, 1, server.CountRequests("/authn"))
+	})
+
+	t.Run("Waiting callers honor their context", func(t *testing.T) {
+		release := make(chan struct{})
+		server := newTestServer(t, holdAuthentications(func(w http.ResponseWriter) bool {
+			<-release
+			return true
+		}))
+		defer close(release)
+		client := newTestServerClient(t, server)
+
+		go client.RefreshToken()
+		for !refreshInFlight(client) {
+			time.Sleep(time.Millisecond)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err := client.RefreshTokenCtx(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func refreshInFlight(client *Client) bool {
+	client.tokenMutex.RLock()
+	defer client.tokenMutex.RUnlock()
+	return client.tokenRefresh != nil
+}
+
+func refreshWaiters(client *Client) int {
+	client.tokenMutex.RLock()
+	defer client.tokenMutex.RUnlock()
+	if client.tokenRefresh == nil {
+		return 0
+	}
+	return client.tokenRefresh.waiters
+}
+
 func runOIDCInternalAuthenticateTest(t *testing.T, token string, injectErr error) ([]byte, error) {
 	client, err := NewClient(Config{
 		Account:      "conjur",
@@ -0,0 +1,198 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+)
+
+const (
+	// BatchMaxURLLengthDefaultValue is the default upper bound of the length
+	// of the URL of a batch secret request. It stays well below the 8 KiB
+	// request line limit common to proxies and servers.
+	BatchMaxURLLengthDefaultValue = 4096
+	// BatchConcurrencyDefaultValue is the default number of chunks of a batch
+	// secret request which are fetched at the same time.
+	BatchConcurrencyDefaultValue = 1
+)
+
+// BatchOptions controls how RetrieveBatchSecrets and RetrieveBatchSecretsSafe
+// split large lists of variables into several requests, whose results are
+// merged into a single map.
+type BatchOptions struct {
+	// MaxURLLength is the upper bound of the length of the URL of each
+	// request, BatchMaxURLLengthDefaultValue if zero. A variable whose ID
+	// alone exceeds it is fetched in a request of its own.
+	MaxURLLength int
+	// Concurrency is the number of requests sent at the same time,
+	// BatchConcurrencyDefaultValue if zero.
+	Concurrency int
+}
+
+// SetBatchOptions sets how batch secret requests are split into chunks.
+func (c *Client) SetBatchOptions(options BatchOptions) {
+	c.batchOptions = options
+}
+
+// GetBatchOptions returns how batch secret requests are split into chunks.
+func (c *Client) GetBatchOptions() BatchOptions {
+	return c.batchOptions
+}
+
+// batchChunks splits variableIDs into chunks whose batch URL is no longer
+// than the configured maximum.
+func (c *Client) batchChunks(variableIDs []string) [][]string {
+	maxLength := c.batchOptions.MaxURLLength
+	if maxLength <= 0 {
+		maxLength = BatchMaxURLLengthDefaultValue
+	}
+
+	baseLength := len(c.batchVariableURL(nil))
+	separatorLength := len(url.QueryEscape(","))
+
+	var chunks [][]string
+	var chunk []string
+	length := baseLength
+	for _, id := range variableIDs {
+		idLength := len(url.QueryEscape(makeFullID(c.config.Account, "variable", id)))
+		if len(chunk) > 0 && length+separatorLength+idLength > maxLength {
+			chunks = append(chunks, chunk)
+			chunk = nil
+			length = baseLength
+		}
+		if len(chunk) > 0 {
+			length += separatorLength
+		}
+		chunk = append(chunk, id)
+		length += idLength
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// retrieveBatchChunks fetches the values of variableIDs in as many requests as
+// needed, and merges them. It fails as soon as one of the requests does.
+func (c *Client) retrieveBatchChunks(ctx context.Context, variableIDs []string, base64Flag bool) (map[string]string, error) {
+	chunks := c.batchChunks(variableIDs)
+	if len(chunks) <= 1 {
+		return c.retrieveBatchSecrets(ctx, variableIDs, base64Flag)
+	}
+
+	concurrency := c.batchOptions.Concurrency
+	if concurrency <= 0 {
+		concurrency = BatchConcurrencyDefaultValue
+	}
+	if concurrency > len(chunks) {
+		concurrency = len(chunks)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mutex    sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	values := map[string]string{}
+	work := make(chan []string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range work {
+				chunkValues, err := c.retrieveBatchSecrets(ctx, chunk, base64Flag)
+
+				mutex.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+				} else {
+					for id, value := range chunkValues {
+						values[id] = value
+					}
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+send:
+	for _, chunk := range chunks {
+		select {
+		case work <- chunk:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// retrieveBatchPartial fetches the values of variableIDs chunk by chunk, and
+// bisects the chunks which fail because of some of their variables to find
+// them.
+func (c *Client) retrieveBatchPartial(ctx context.Context, variableIDs []string, base64Flag bool) (map[string]string, map[string]error, error) {
+	values := map[string]string{}
+	variableErrors := map[string]error{}
+	for _, chunk := range c.batchChunks(variableIDs) {
+		if err := c.bisectBatch(ctx, chunk, base64Flag, values, variableErrors); err != nil {
+			return nil, nil, err
+		}
+	}
+	return values, variableErrors, nil
+}
+
+func (c *Client) bisectBatch(ctx context.Context, variableIDs []string, base64Flag bool, values map[string]string, variableErrors map[string]error) error {
+	chunkValues, err := c.retrieveBatchSecrets(ctx, variableIDs, base64Flag)
+	if err == nil {
+		for id, value := range chunkValues {
+			values[id] = value
+		}
+		return nil
+	}
+	if !isVariableError(err) {
+		return err
+	}
+
+	if len(variableIDs) == 1 {
+		variableErrors[makeFullID(c.config.Account, "variable", variableIDs[0])] = err
+		return nil
+	}
+	middle := len(variableIDs) / 2
+	if err := c.bisectBatch(ctx, variableIDs[:middle], base64Flag, values, variableErrors); err != nil {
+		return err
+	}
+	return c.bisectBatch(ctx, variableIDs[middle:], base64Flag, values, variableErrors)
+}
+
+// isVariableError reports whether a batch secret request failed because of
+// some of its variables: they don't exist, have no value, aren't permitted,
+// or hold binary values which can't be sent without base64 encoding.
+func isVariableError(err error) bool {
+	var conjurErr *response.ConjurError
+	if !errors.As(err, &conjurErr) {
+		return false
+	}
+	switch conjurErr.Code {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusNotAcceptable:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package conjurapi
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBatchServer returns a test server with the variables of
+// batchVariableIDs(count), each with its fully-qualified ID as its value.
+func newBatchServer(t *testing.T, count int, options ...conjurtest.Option) *conjurtest.Server {
+	server := newTestServer(t, options...)
+	var policy strings.Builder
+	for _, id := range batchVariableIDs(count) {
+		fmt.Fprintf(&policy, "- !variable %s\n", id)
+	}
+	require.NoError(t, server.LoadPolicy("root", policy.String()))
+	for _, id := range batchVariableIDs(count) {
+		require.NoError(t, server.AddSecret("variable:"+id, "conjur:variable:"+id))
+	}
+	return server
+}
+
+// batchURLLengths returns the length of the URLs of the batch requests
+// received by server.
+func batchURLLengths(server *conjurtest.Server) []int {
+	lengths := []int{}
+	for _, r := range server.Requests() {
+		if r.URL.Path == "/secrets/" {
+			lengths = append(lengths, len(server.URL)+len(r.URL.RequestURI()))
+		}
+	}
+	return lengths
+}
+
+func batchVariableIDs(count int) []string {
+	ids := []string{}
+	for i := 0; i < count; i++ {
+		ids = append(ids, fmt.Sprintf("app/database/password-%03d", i))
+	}
+	return ids
+}
+
+func TestClient_RetrieveBatchSecrets_Chunks(t *testing.T) {
+	newClient := func(t *testing.T, server *conjurtest.Server, options BatchOptions) *Client {
+		client := newTestServerClient(t, server)
+		client.SetBatchOptions(options)
+		return client
+	}
+
+	t.Run("Sends small batches in a single request", func(t *testing.T) {
+		server := newBatchServer(t, 10)
+		client := newClient(t, server, BatchOptions{})
+
+		values, err := client.RetrieveBatchSecrets(batchVariableIDs(10))
+		require.NoError(t, err)
+		assert.Len(t, values, 10)
+		assert.Len(t, batchURLLengths(server), 1)
+	})
+
+	t.Run("Splits large batches into bounded requests", func(t *testing.T) {
+		server := newBatchServer(t, 300)
+		client := newClient(t, server, BatchOptions{MaxURLLength: 1000})
+
+		ids := batchVariableIDs(300)
+		values, err := client.RetrieveBatchSecrets(ids)
+		require.NoError(t, err)
+		require.Len(t, values, 300)
+		for _, id := range ids {
+			assert.Equal(t, "conjur:variable:"+id, string(values["conjur:variable:"+id]))
+		}
+		lengths := batchURLLengths(server)
+		assert.Greater(t, len(lengths), 1)
+		for _, length := range lengths {
+			assert.LessOrEqual(t, length, 1000)
+		}
+		assert.Equal(t, 1, server.MaxConcurrentRequests())
+	})
+
+	t.Run("Fetches chunks concurrently", func(t *testing.T) {
+		server := newBatchServer(t, 100, conjurtest.WithLatency(20*time.Millisecond))
+		client := newClient(t, server, BatchOptions{MaxURLLength: 500, Concurrency: 3})
+
+		values, err := client.RetrieveBatchSecretsSafe(batchVariableIDs(100))
+		require.NoError(t, err)
+		assert.Len(t, values, 100)
+		assert.Equal(t, "conjur:variable:app/database/password-042", string(values["conjur:variable:app/database/password-042"]))
+		assert.Greater(t, len(batchURLLengths(server)), 3)
+		assert.Equal(t, 3, server.MaxConcurrentRequests())
+	})
+
+	t.Run("Fails when any chunk fails", func(t *testing.T) {
+		server := newBatchServer(t, 300)
+		client := newClient(t, server, BatchOptions{MaxURLLength: 1000, Concurrency: 2})
+
+		ids := batchVariableIDs(300)
+		ids[150] = "app/database/missing"
+		values, err := client.RetrieveBatchSecrets(ids)
+		assert.Error(t, err)
+		assert.Nil(t, values)
+	})
+
+	t.Run("Sends IDs longer than the limit on their own", func(t *testing.T) {
+		server := newBatchServer(t, 3)
+		client := newClient(t, server, BatchOptions{MaxURLLength: 10})
+
+		values, err := client.RetrieveBatchSecrets(batchVariableIDs(3))
+		require.NoError(t, err)
+		assert.Len(t, values, 3)
+		assert.Len(t, batchURLLengths(server), 3)
+	})
+}
+
+func TestClient_RetrieveBatchSecretsPartial(t *testing.T) {
+	server := conjurtest.NewServer("conjur")
+	defer server.Close()
+	require.NoError(t, server.LoadPolicy("root", `
+- !host app
+- !variable db/password
+- !variable db/username
+- !variable empty
+- !variable certificate
+- !variable forbidden
+- !permit
+  role: !host app
+  privileges: [ read, execute ]
+  resources: [ !variable db/password, !variable db/username, !variable empty, !variable certificate ]
+- !permit
+  role: !host app
+  privileges: [ read ]
+  resource: !variable forbidden
+`))
+	require.NoError(t, server.AddSecret("variable:db/password", "secret"))
+	require.NoError(t, server.AddSecret("variable:db/username", "app"))
+	require.NoError(t, server.AddSecret("variable:certificate", "\xff\xfe"))
+	require.NoError(t, server.AddSecret("variable:forbidden", "hidden"))
+
+	client, err := NewClientFromKey(
+		Config{Account: "conjur", ApplianceURL: server.URL, CredentialStorage: CredentialStorageNone},
+		authn.LoginPair{Login: "host/app", APIKey: server.APIKey("host:app")},
+	)
+	require.NoError(t, err)
+
+	errorCodes := func(variableErrors map[string]error) map[string]int {
+		codes := map[string]int{}
+		for id, err := range variableErrors {
+			conjurErr, ok := err.(*response.ConjurError)
+			require.True(t, ok, "%s: %v", id, err)
+			codes[id] = conjurErr.Code
+		}
+		return codes
+	}
+
+	t.Run("Returns the values which could be fetched", func(t *testing.T) {
+		values, variableErrors, err := client.RetrieveBatchSecretsPartial([]string{
+			"db/password", "missing", "db/username", "empty", "forbidden", "certificate",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"conjur:variable:db/password": []byte("secret"),
+			"conjur:variable:db/username": []byte("app"),
+		}, values)
+		assert.Equal(t, map[string]int{
+			"conjur:variable:missing":     http.StatusNotFound,
+			"conjur:variable:empty":       http.StatusNotFound,
+			"conjur:variable:forbidden":   http.StatusForbidden,
+			"conjur:variable:certificate": http.StatusNotAcceptable,
+		}, errorCodes(variableErrors))
+	})
+
+	t.Run("Fetches binary values base64-encoded", func(t *testing.T) {
+		values, variableErrors, err := client.RetrieveBatchSecretsPartialSafe([]string{"certificate", "missing"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"conjur:variable:certificate": []byte("\xff\xfe")}, values)
+		assert.Equal(t, map[string]int{"conjur:variable:missing": http.StatusNotFound}, errorCodes(variableErrors))
+	})
+
+	t.Run("Fails on other errors", func(t *testing.T) {
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+
+		values, variableErrors, err := client.RetrieveBatchSecretsPartial([]string{"db/password"})
+		assert.Error(t, err)
+		assert.Nil(t, values)
+		assert.Nil(t, variableErrors)
+	})
+}
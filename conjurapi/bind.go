@@ -0,0 +1,293 @@
+package conjurapi
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+)
+
+// SecretFieldError is the error of a struct field which LoadSecretsInto could
+// not set.
+type SecretFieldError struct {
+	// Field is the path of the field in the struct, e.g. "Database.Password".
+	Field string
+	// VariableID is the ID of the variable, as given in the field's tag.
+	VariableID string
+	// Err is the error retrieving or converting the value.
+	Err error
+}
+
+func (e *SecretFieldError) Error() string {
+	return fmt.Sprintf("%s (%s): %s", e.Field, e.VariableID, e.Err)
+}
+
+func (e *SecretFieldError) Unwrap() error {
+	return e.Err
+}
+
+// secretField is a struct field bound to a variable.
+type secretField struct {
+	path       string
+	value      reflect.Value
+	variableID string
+	optional   bool
+	hasDefault bool
+	defaultVal string
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// LoadSecretsInto sets the fields of the struct pointed to by target to the
+// values of the variables named in their `conjur` tags, retrieved from
+// secrets, e.g. a *Client, in a single batch:
+//
+//	type Config struct {
+//		Username string        `conjur:"db/username"`
+//		Password []byte        `conjur:"db/password"`
+//		Port     int           `conjur:"db/port,default=5432"`
+//		Timeout  time.Duration `conjur:"db/timeout,optional"`
+//		TLS      TLSConfig     `conjur:"db/tls"`
+//	}
+//
+// Values are converted to strings, byte slices, integers, floats, booleans
+// and durations, with encoding.TextUnmarshaler when the field implements it,
+// and otherwise decoded as JSON, e.g. for structs, maps and slices. Untagged
+// struct fields are loaded recursively.
+//
+// A variable which is missing or has no value fails its field unless the
+// field is optional, in which case it's left unchanged, or has a default
+// value, which is converted like a value. The errors of all the fields are
+// returned together, each as a *SecretFieldError.
+//
+// Client.LoadSecretsInto loads the variables with the client itself.
+func LoadSecretsInto(ctx context.Context, secrets PartialSecretsReader, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("LoadSecretsInto requires a non-nil pointer to a struct, not %T", target)
+	}
+
+	fields, err := secretFields(value.Elem(), "")
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	variableIDs := []string{}
+	for _, field := range fields {
+		variableIDs = append(variableIDs, field.variableID)
+	}
+	values, variableErrors, err := secrets.RetrieveBatchSecretsPartialSafeCtx(ctx, variableIDs)
+	if err != nil {
+		return err
+	}
+
+	// The results are keyed by fully-qualified ID, so they are also indexed
+	// without the account, which the tags usually omit.
+	fullIDs := map[string]string{}
+	for id := range values {
+		indexFullID(fullIDs, id)
+	}
+	for id := range variableErrors {
+		indexFullID(fullIDs, id)
+	}
+
+	var fieldErrors []error
+	for _, field := range fields {
+		fullID, ok := fullIDs[field.variableID]
+		if !ok {
+			fullID, ok = fullIDs["variable:"+field.variableID]
+		}
+		if !ok {
+			err = fmt.Errorf("No value returned for variable '%s'", field.variableID)
+		} else {
+			err = field.set(values[fullID], variableErrors[fullID])
+		}
+		if err != nil {
+			fieldErrors = append(fieldErrors, &SecretFieldError{
+				Field:      field.path,
+				VariableID: field.variableID,
+				Err:        err,
+			})
+		}
+	}
+	return errors.Join(fieldErrors...)
+}
+
+// LoadSecretsInto sets the fields of the struct pointed to by target to the
+// values of the variables named in their `conjur` tags, retrieved with a
+// single batch request. See the LoadSecretsInto function for the supported
+// tags and conversions.
+func (c *Client) LoadSecretsInto(target interface{}) error {
+	return c.LoadSecretsIntoCtx(context.Background(), target)
+}
+
+// LoadSecretsIntoCtx is like LoadSecretsInto but uses ctx for cancellation and deadlines.
+func (c *Client) LoadSecretsIntoCtx(ctx context.Context, target interface{}) error {
+	return LoadSecretsInto(ctx, c, target)
+}
+
+func indexFullID(fullIDs map[string]string, id string) {
+	fullIDs[id] = id
+	if _, unqualified, ok := strings.Cut(id, ":"); ok {
+		fullIDs[unqualified] = id
+	}
+}
+
+// secretFields returns the fields of a struct which are bound to variables,
+// including those of its untagged struct fields.
+func secretFields(value reflect.Value, prefix string) ([]secretField, error) {
+	fields := []secretField{}
+	for i := 0; i < value.NumField(); i++ {
+		structField := value.Type().Field(i)
+		tag, tagged := structField.Tag.Lookup("conjur")
+		if tag == "-" {
+			continue
+		}
+
+		path := prefix + structField.Name
+		if !tagged {
+			if structField.Type.Kind() == reflect.Struct && structField.IsExported() {
+				nested, err := secretFields(value.Field(i), path+".")
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, nested...)
+			}
+			continue
+		}
+		if !structField.IsExported() {
+			return nil, fmt.Errorf("Field %s has a conjur tag but is not exported", path)
+		}
+
+		field, err := parseSecretTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("Field %s: %s", path, err)
+		}
+		field.path = path
+		field.value = value.Field(i)
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// parseSecretTag parses a tag of the form "path/to/var[,optional][,default=value]".
+// The default value extends to the end of the tag, so it may contain commas.
+func parseSecretTag(tag string) (secretField, error) {
+	variableID, options, _ := strings.Cut(tag, ",")
+	field := secretField{variableID: variableID}
+	if variableID == "" {
+		return field, errors.New("conjur tag must name a variable")
+	}
+
+	for options != "" {
+		if defaultVal, ok := strings.CutPrefix(options, "default="); ok {
+			field.hasDefault = true
+			field.defaultVal = defaultVal
+			break
+		}
+		var option string
+		option, options, _ = strings.Cut(options, ",")
+		switch option {
+		case "optional":
+			field.optional = true
+		default:
+			return field, fmt.Errorf("unknown conjur tag option %q", option)
+		}
+	}
+	return field, nil
+}
+
+// set sets the field to the value of its variable, or handles the error
+// retrieving it.
+func (f secretField) set(value []byte, err error) error {
+	if err != nil {
+		var conjurErr *response.ConjurError
+		if !errors.As(err, &conjurErr) || conjurErr.Code != http.StatusNotFound {
+			return err
+		}
+		switch {
+		case f.hasDefault:
+			value = []byte(f.defaultVal)
+		case f.optional:
+			return nil
+		default:
+			return err
+		}
+	}
+	return setSecretValue(f.value, value)
+}
+
+func setSecretValue(field reflect.Value, value []byte) error {
+	if field.Kind() == reflect.Pointer {
+		target := reflect.New(field.Type().Elem())
+		if err := setSecretValue(target.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(target)
+		return nil
+	}
+
+	if reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(value)
+	}
+
+	if field.Type() == durationType {
+		duration, err := time.ParseDuration(string(value))
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(duration))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(string(value))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return json.Unmarshal(value, field.Addr().Interface())
+		}
+		field.SetBytes(append([]byte{}, value...))
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(string(value))
+		if err != nil {
+			return err
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(string(value), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(string(value), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(string(value), field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(parsed)
+	default:
+		return json.Unmarshal(value, field.Addr().Interface())
+	}
+	return nil
+}
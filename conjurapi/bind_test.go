@@ -0,0 +1,204 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bindTLSConfig struct {
+	Verify bool     `json:"verify"`
+	Hosts  []string `json:"hosts"`
+}
+
+type bindDatabaseConfig struct {
+	Username string        `conjur:"db/username"`
+	Password []byte        `conjur:"db/password"`
+	Port     int           `conjur:"db/port,default=5432"`
+	Timeout  time.Duration `conjur:"db/timeout"`
+	Replica  *string       `conjur:"db/replica,optional"`
+}
+
+type bindConfig struct {
+	Database bindDatabaseConfig
+	TLS      bindTLSConfig     `conjur:"tls"`
+	Labels   map[string]string `conjur:"labels,default={\"env\":\"dev\",\"team\":\"ops\"}"`
+	Debug    bool              `conjur:"debug,optional"`
+	Address  net.IP            `conjur:"address"`
+	Ignored  string            `conjur:"-"`
+}
+
+func TestLoadSecretsInto(t *testing.T) {
+	server := conjurtest.NewServer("conjur")
+	defer server.Close()
+	require.NoError(t, server.LoadPolicy("root", `
+- !variable db/username
+- !variable db/password
+- !variable db/port
+- !variable db/timeout
+- !variable db/replica
+- !variable tls
+- !variable labels
+- !variable debug
+- !variable address
+- !variable forbidden
+`))
+	require.NoError(t, server.AddSecret("variable:db/username", "app"))
+	require.NoError(t, server.AddSecret("variable:db/password", "\xff\x00secret"))
+	require.NoError(t, server.AddSecret("variable:db/timeout", "30s"))
+	require.NoError(t, server.AddSecret("variable:tls", `{"verify": true, "hosts": ["db1", "db2"]}`))
+	require.NoError(t, server.AddSecret("variable:address", "10.0.0.1"))
+
+	client, err := NewClientFromKey(
+		Config{Account: "conjur", ApplianceURL: server.URL, CredentialStorage: CredentialStorageNone},
+		authn.LoginPair{Login: "admin", APIKey: server.APIKey("user:admin")},
+	)
+	require.NoError(t, err)
+
+	t.Run("Sets the fields", func(t *testing.T) {
+		config := bindConfig{Debug: true, Ignored: "kept"}
+		require.NoError(t, client.LoadSecretsInto(&config))
+
+		assert.Equal(t, bindConfig{
+			Database: bindDatabaseConfig{
+				Username: "app",
+				Password: []byte("\xff\x00secret"),
+				Port:     5432,
+				Timeout:  30 * time.Second,
+			},
+			TLS:     bindTLSConfig{Verify: true, Hosts: []string{"db1", "db2"}},
+			Labels:  map[string]string{"env": "dev", "team": "ops"},
+			Debug:   true,
+			Address: net.ParseIP("10.0.0.1"),
+			Ignored: "kept",
+		}, config)
+	})
+
+	t.Run("Sets optional fields which have values", func(t *testing.T) {
+		require.NoError(t, server.AddSecret("variable:db/replica", "replica.example.com"))
+		require.NoError(t, server.AddSecret("variable:db/port", "6432"))
+		require.NoError(t, server.AddSecret("variable:debug", "false"))
+
+		config := bindConfig{Debug: true}
+		require.NoError(t, client.LoadSecretsIntoCtx(context.Background(), &config))
+		require.NotNil(t, config.Database.Replica)
+		assert.Equal(t, "replica.example.com", *config.Database.Replica)
+		assert.Equal(t, 6432, config.Database.Port)
+		assert.False(t, config.Debug)
+	})
+
+	t.Run("Reports the errors of all the fields", func(t *testing.T) {
+		var config struct {
+			Port    int    `conjur:"db/username"`
+			Missing string `conjur:"missing"`
+			Name    string `conjur:"db/username"`
+		}
+		err := client.LoadSecretsInto(&config)
+		require.Error(t, err)
+		assert.Equal(t, "app", config.Name)
+
+		var fieldErr *SecretFieldError
+		require.ErrorAs(t, err, &fieldErr)
+		assert.Equal(t, "Port", fieldErr.Field)
+		assert.Contains(t, err.Error(), `Port (db/username): strconv.ParseInt: parsing "app": invalid syntax`)
+
+		var conjurErr *response.ConjurError
+		require.ErrorAs(t, err, &conjurErr)
+		assert.Equal(t, http.StatusNotFound, conjurErr.Code)
+		assert.Contains(t, err.Error(), "Missing (missing)")
+	})
+
+	t.Run("Rejects invalid targets", func(t *testing.T) {
+		assert.EqualError(t, client.LoadSecretsInto(bindConfig{}), "LoadSecretsInto requires a non-nil pointer to a struct, not conjurapi.bindConfig")
+
+		var unexported struct {
+			password string `conjur:"db/password"`
+		}
+		assert.EqualError(t, client.LoadSecretsInto(&unexported), "Field password has a conjur tag but is not exported")
+
+		var unknownOption struct {
+			Password string `conjur:"db/password,required"`
+		}
+		assert.EqualError(t, client.LoadSecretsInto(&unknownOption), `Field Password: unknown conjur tag option "required"`)
+	})
+
+	t.Run("Fails on errors which aren't caused by variables", func(t *testing.T) {
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+
+		config := bindConfig{}
+		err = client.LoadSecretsInto(&config)
+		require.Error(t, err)
+		var fieldErr *SecretFieldError
+		assert.False(t, errors.As(err, &fieldErr))
+	})
+
+	t.Run("Matches the results of other readers whatever their account", func(t *testing.T) {
+		secrets := partialSecrets{
+			values: map[string][]byte{
+				"myorg:variable:db/username": []byte("app"),
+				"myorg:variable:db/password": []byte("secret"),
+				"myorg:variable:db/timeout":  []byte("1m"),
+			},
+			variableErrors: map[string]error{
+				"myorg:variable:db/port":    &response.ConjurError{Code: http.StatusNotFound},
+				"myorg:variable:db/replica": &response.ConjurError{Code: http.StatusNotFound},
+			},
+		}
+
+		config := bindDatabaseConfig{}
+		require.NoError(t, LoadSecretsInto(context.Background(), secrets, &config))
+		assert.Equal(t, bindDatabaseConfig{
+			Username: "app",
+			Password: []byte("secret"),
+			Port:     5432,
+			Timeout:  time.Minute,
+		}, config)
+
+		err := LoadSecretsInto(context.Background(), partialSecrets{}, &config)
+		assert.ErrorContains(t, err, "No value returned for variable 'db/username'")
+	})
+}
+
+// partialSecrets is a PartialSecretsReader which returns fixed results.
+type partialSecrets struct {
+	values         map[string][]byte
+	variableErrors map[string]error
+}
+
+func (p partialSecrets) RetrieveBatchSecretsPartial(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return p.values, p.variableErrors, nil
+}
+
+func (p partialSecrets) RetrieveBatchSecretsPartialCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return p.values, p.variableErrors, nil
+}
+
+func (p partialSecrets) RetrieveBatchSecretsPartialSafe(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return p.values, p.variableErrors, nil
+}
+
+func (p partialSecrets) RetrieveBatchSecretsPartialSafeCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return p.values, p.variableErrors, nil
+}
+
+func TestParseSecretTag(t *testing.T) {
+	field, err := parseSecretTag("db/hosts,optional,default=a,b")
+	require.NoError(t, err)
+	assert.Equal(t, "db/hosts", field.variableID)
+	assert.True(t, field.optional)
+	assert.True(t, field.hasDefault)
+	assert.Equal(t, "a,b", field.defaultVal)
+
+	_, err = parseSecretTag(",optional")
+	assert.EqualError(t, err, "conjur tag must name a variable")
+}
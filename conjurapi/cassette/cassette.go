@@ -0,0 +1,466 @@
+// Package cassette records the requests sent to Conjur and their responses
+// to a file, and replays them later without a server.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/internal/redact"
+	"gopkg.in/yaml.v2"
+)
+
+// Mode is how a Cassette handles requests.
+type Mode int
+
+const (
+	// Replay responds to requests with the interactions recorded in the
+	// cassette's file, and fails those which weren't recorded.
+	Replay Mode = iota
+	// Record sends requests to Conjur and records them, replacing the
+	// interactions in the cassette's file when it is saved.
+	Record
+	// Auto replays the cassette's file if it exists, and records it
+	// otherwise.
+	Auto
+)
+
+// Match selects the parts of a request which must be the same as those of a
+// recorded one for the recorded response to be replayed.
+type Match int
+
+const (
+	MatchMethod Match = 1 << iota
+	MatchPath
+	MatchQuery
+	// MatchBody compares the SHA-256 hashes of the bodies.
+	MatchBody
+
+	MatchAll = MatchMethod | MatchPath | MatchQuery | MatchBody
+)
+
+// Interaction is a request and its response, as recorded in a cassette's
+// file.
+type Interaction struct {
+	Request  Request  `yaml:"request" json:"request"`
+	Response Response `yaml:"response" json:"response"`
+}
+
+// Request is a scrubbed request.
+type Request struct {
+	Method     string              `yaml:"method" json:"method"`
+	URL        string              `yaml:"url" json:"url"`
+	Headers    map[string][]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body       string              `yaml:"body,omitempty" json:"body,omitempty"`
+	BodySHA256 string              `yaml:"body_sha256,omitempty" json:"body_sha256,omitempty"`
+	// Encoding is "base64" when Body is base64-encoded because it isn't
+	// valid UTF-8.
+	Encoding string `yaml:"encoding,omitempty" json:"encoding,omitempty"`
+}
+
+// Response is a scrubbed response.
+type Response struct {
+	StatusCode int                 `yaml:"status_code" json:"status_code"`
+	Headers    map[string][]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Body       string              `yaml:"body,omitempty" json:"body,omitempty"`
+	Encoding   string              `yaml:"encoding,omitempty" json:"encoding,omitempty"`
+}
+
+type cassetteFile struct {
+	Interactions []*Interaction `yaml:"interactions" json:"interactions"`
+}
+
+// tokenLifetime is how long the access tokens in cassettes are valid for, so
+// that clients don't refresh them while replaying.
+const tokenLifetime = 100 * 365 * 24 * time.Hour
+
+// Cassette records the requests sent to Conjur and their responses to a
+// file, and replays them later without a server, e.g. in integration tests.
+// It is an http.RoundTripper, to be used as the transport of the client's
+// HTTP client:
+//
+//	c, err := cassette.New("testdata/secrets.yml", cassette.Auto, conjur.GetHttpClient().Transport)
+//	...
+//	conjur.SetHttpClient(&http.Client{Transport: c})
+//	defer c.Save()
+//
+// or as middleware, which also replays the requests other middleware would
+// send (see Middleware):
+//
+//	c, err := cassette.New("testdata/secrets.yml", cassette.Auto, nil)
+//	...
+//	conjur.Use(c.Middleware())
+//	defer c.Save()
+//
+// Files whose name ends in .json are written as JSON, others as YAML.
+//
+// Credentials and secrets are scrubbed before they are recorded, as they are
+// from wire dumps (see conjurapi.WireDump). API keys, passwords and secret values are
+// replaced with "[REDACTED]", and access tokens with unsigned tokens for the
+// same role which don't expire. Requests are matched against the scrubbed
+// recordings, so replays succeed whichever credentials the client uses.
+//
+// When several recorded interactions match a request, they are replayed in
+// the order they were recorded, the last one being repeated once the others
+// have been used.
+type Cassette struct {
+	path      string
+	recording bool
+	transport http.RoundTripper
+
+	mutex        sync.Mutex
+	match        Match
+	interactions []*Interaction
+	used         []bool
+}
+
+// New returns a Cassette for the file at path. Requests which are recorded
+// are sent with transport, or http.DefaultTransport if it is nil, except
+// when the cassette is used as middleware.
+func New(path string, mode Mode, transport http.RoundTripper) (*Cassette, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	c := &Cassette{
+		path:      path,
+		transport: transport,
+		match:     MatchAll,
+	}
+
+	switch mode {
+	case Record:
+		c.recording = true
+	case Replay, Auto:
+		content, err := os.ReadFile(path)
+		if mode == Auto && errors.Is(err, os.ErrNotExist) {
+			c.recording = true
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read cassette: %s", err)
+		}
+		var file cassetteFile
+		if isJSON(path) {
+			err = json.Unmarshal(content, &file)
+		} else {
+			err = yaml.Unmarshal(content, &file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Unable to parse cassette %s: %s", path, err)
+		}
+		c.interactions = file.Interactions
+		c.used = make([]bool, len(file.Interactions))
+	default:
+		return nil, fmt.Errorf("Unknown cassette mode %d", mode)
+	}
+	return c, nil
+}
+
+// Recording reports whether the cassette records requests rather than
+// replaying them.
+func (c *Cassette) Recording() bool {
+	return c.recording
+}
+
+// SetMatch sets the parts of requests which are matched against the
+// recorded ones, MatchAll by default.
+func (c *Cassette) SetMatch(match Match) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.match = match
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (c *Cassette) Interactions() []Interaction {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	interactions := make([]Interaction, len(c.interactions))
+	for i, interaction := range c.interactions {
+		interactions[i] = *interaction
+	}
+	return interactions
+}
+
+// Save writes the recorded interactions to the cassette's file, creating its
+// directory if needed. It does nothing when replaying.
+func (c *Cassette) Save() error {
+	if !c.recording {
+		return nil
+	}
+
+	c.mutex.Lock()
+	file := cassetteFile{Interactions: c.interactions}
+	var content []byte
+	var err error
+	if isJSON(c.path) {
+		content, err = json.MarshalIndent(file, "", "  ")
+	} else {
+		content, err = yaml.Marshal(file)
+	}
+	c.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, content, 0644)
+}
+
+// RoundTrip records or replays req.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	return c.roundTrip(req, c.transport.RoundTrip)
+}
+
+// Middleware returns middleware which records or replays the requests sent
+// by a client. When recording, requests are sent with the next handler in
+// the chain rather than the cassette's transport. The middleware added
+// before it, such as conjurapi.WireDump, sees the replayed responses.
+func (c *Cassette) Middleware() conjurapi.Middleware {
+	return func(next conjurapi.RequestHandler) conjurapi.RequestHandler {
+		return func(req *http.Request) (*http.Response, error) {
+			return c.roundTrip(req, next)
+		}
+	}
+}
+
+// roundTrip records req, sending it with send, or replays it.
+func (c *Cassette) roundTrip(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := newRequest(req, body)
+
+	if c.recording {
+		return c.record(req, body, recorded, send)
+	}
+	return c.replay(req, recorded)
+}
+
+func (c *Cassette) record(req *http.Request, body []byte, recorded Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	if req.Body != nil {
+		outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := send(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(content))
+
+	response := Response{
+		StatusCode: resp.StatusCode,
+		Headers:    scrubHeaders(resp.Header),
+	}
+	response.Body, response.Encoding = encodeBody(scrubResponseBody(req, resp, content))
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.interactions = append(c.interactions, &Interaction{Request: recorded, Response: response})
+	c.used = append(c.used, true)
+	return resp, nil
+}
+
+func (c *Cassette) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	found := -1
+	for i, interaction := range c.interactions {
+		if !c.matches(recorded, interaction.Request) {
+			continue
+		}
+		found = i
+		if !c.used[i] {
+			break
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("Cassette %s has no recorded response to %s %s", c.path, recorded.Method, recorded.URL)
+	}
+	c.used[found] = true
+
+	response := c.interactions[found].Response
+	body, err := decodeBody(response.Body, response.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode recorded response body: %s", err)
+	}
+	header := http.Header{}
+	for name, values := range response.Headers {
+		header[http.CanonicalHeaderKey(name)] = append([]string{}, values...)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", response.StatusCode, http.StatusText(response.StatusCode)),
+		StatusCode:    response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (c *Cassette) matches(request Request, recorded Request) bool {
+	if c.match&MatchMethod != 0 && request.Method != recorded.Method {
+		return false
+	}
+	if c.match&MatchBody != 0 && request.BodySHA256 != recorded.BodySHA256 {
+		return false
+	}
+	if c.match&(MatchPath|MatchQuery) == 0 {
+		return true
+	}
+
+	requestURL, err := url.Parse(request.URL)
+	if err != nil {
+		return false
+	}
+	recordedURL, err := url.Parse(recorded.URL)
+	if err != nil {
+		return false
+	}
+	if c.match&MatchPath != 0 && requestURL.EscapedPath() != recordedURL.EscapedPath() {
+		return false
+	}
+	return c.match&MatchQuery == 0 || reflect.DeepEqual(requestURL.Query(), recordedURL.Query())
+}
+
+// newRequest returns the scrubbed recording of req, whose body is
+// body.
+func newRequest(req *http.Request, body []byte) Request {
+	scrubbed := redact.RequestBody(req, body)
+	request := Request{
+		Method:  req.Method,
+		URL:     redact.URL(req.URL),
+		Headers: scrubHeaders(req.Header),
+	}
+	if len(scrubbed) > 0 {
+		hash := sha256.Sum256(scrubbed)
+		request.BodySHA256 = hex.EncodeToString(hash[:])
+	}
+	request.Body, request.Encoding = encodeBody(scrubbed)
+	return request
+}
+
+func scrubHeaders(headers http.Header) map[string][]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	scrubbed := map[string][]string{}
+	for name, values := range headers {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie":
+			scrubbed[name] = []string{redact.Redacted}
+		case "Content-Length":
+			// Scrubbing changes the length of bodies.
+		default:
+			scrubbed[name] = append([]string{}, values...)
+		}
+	}
+	return scrubbed
+}
+
+// scrubResponseBody scrubs body like a wire dump does, except for
+// access tokens which are replaced with unsigned ones, so that clients can
+// still use them when replaying.
+func scrubResponseBody(req *http.Request, resp *http.Response, body []byte) []byte {
+	if resp.StatusCode < 300 && redact.IsAuthnPath(req.URL.Path) && strings.HasSuffix(req.URL.Path, "/authenticate") {
+		if token, err := unsignedToken(body); err == nil {
+			return token
+		}
+	}
+	return redact.ResponseBody(req, resp, body)
+}
+
+// unsignedToken returns an access token with the same header and subject as
+// the one in body, without a signature and valid for tokenLifetime.
+func unsignedToken(body []byte) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(body))
+	encoded := err == nil
+	if !encoded {
+		raw = body
+	}
+	token, err := authn.NewToken(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"iat": token.IssuedAt().Unix(),
+		"exp": token.IssuedAt().Add(tokenLifetime).Unix(),
+	}
+	if content, err := base64.StdEncoding.DecodeString(token.Payload); err == nil {
+		var fields map[string]interface{}
+		if json.Unmarshal(content, &fields) == nil && fields["sub"] != nil {
+			payload["sub"] = fields["sub"]
+		}
+	}
+	content, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	unsigned, err := json.Marshal(authn.AuthnToken{
+		Protected: token.Protected,
+		Payload:   base64.StdEncoding.EncodeToString(content),
+		Signature: redact.Redacted,
+	})
+	if err != nil || !encoded {
+		return unsigned, err
+	}
+	return []byte(base64.StdEncoding.EncodeToString(unsigned)), nil
+}
+
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+func decodeBody(body string, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
+
+func isJSON(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".json")
+}
@@ -0,0 +1,140 @@
+package cassette
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attach makes client send its requests through cassette.
+type attach func(client *conjurapi.Client, cassette *Cassette)
+
+func asTransport(client *conjurapi.Client, cassette *Cassette) {
+	client.SetHttpClient(&http.Client{Transport: cassette})
+}
+
+func asMiddleware(client *conjurapi.Client, cassette *Cassette) {
+	client.Use(cassette.Middleware())
+}
+
+func newCassetteClient(t *testing.T, applianceURL string, apiKey string, cassette *Cassette, attach attach) *conjurapi.Client {
+	client, err := conjurapi.NewClientFromKey(
+		conjurapi.Config{Account: "conjur", ApplianceURL: applianceURL, CredentialStorage: conjurapi.CredentialStorageNone},
+		authn.LoginPair{Login: "host/app", APIKey: apiKey},
+	)
+	require.NoError(t, err)
+	attach(client, cassette)
+	return client
+}
+
+func recordCassette(t *testing.T, path string, attach attach) {
+	server := conjurtest.NewServer("conjur")
+	defer server.Close()
+	require.NoError(t, server.LoadPolicy("root", `
+- !host app
+- !variable db/password
+- !permit
+  role: !host app
+  privileges: [ read, execute, update ]
+  resource: !variable db/password
+`))
+	require.NoError(t, server.AddSecret("variable:db/password", "first-secret"))
+
+	cassette, err := New(path, Auto, nil)
+	require.NoError(t, err)
+	require.True(t, cassette.Recording())
+	client := newCassetteClient(t, server.URL, server.APIKey("host:app"), cassette, attach)
+
+	value, err := client.RetrieveSecret("db/password")
+	require.NoError(t, err)
+	assert.Equal(t, "first-secret", string(value))
+	require.NoError(t, client.AddSecret("db/password", "second-secret"))
+	value, err = client.RetrieveSecret("db/password")
+	require.NoError(t, err)
+	assert.Equal(t, "second-secret", string(value))
+	_, err = client.RetrieveSecret("missing")
+	require.Error(t, err)
+
+	require.NoError(t, cassette.Save())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), server.APIKey("host:app"))
+	assert.NotContains(t, string(content), "first-secret")
+	assert.NotContains(t, string(content), "second-secret")
+	assert.Contains(t, string(content), "[REDACTED]")
+}
+
+func TestCassette(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		name        string
+		attach      attach
+	}{
+		{"Records and replays YAML", "secrets.yml", asTransport},
+		{"Records and replays JSON", "secrets.json", asTransport},
+		{"Records and replays as middleware", "secrets.yml", asMiddleware},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassettes", tc.name)
+			recordCassette(t, path, tc.attach)
+
+			cassette, err := New(path, Auto, nil)
+			require.NoError(t, err)
+			require.False(t, cassette.Recording())
+			require.Len(t, cassette.Interactions(), 5)
+
+			// The server is gone, and the credentials differ
+			client := newCassetteClient(t, "http://localhost:1", "other-api-key", cassette, tc.attach)
+
+			value, err := client.RetrieveSecret("db/password")
+			require.NoError(t, err)
+			assert.Equal(t, "[REDACTED]", string(value))
+			require.NoError(t, client.AddSecret("db/password", "any-secret"))
+			_, err = client.RetrieveSecret("db/password")
+			require.NoError(t, err)
+
+			_, err = client.RetrieveSecret("missing")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "not found")
+
+			token, err := client.GetAuthenticator().RefreshToken()
+			require.NoError(t, err)
+			parsed, err := authn.NewToken(token)
+			require.NoError(t, err)
+			assert.False(t, parsed.ShouldRefresh())
+
+			_, err = client.RetrieveSecret("unrecorded")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "no recorded response to GET")
+		})
+	}
+
+	t.Run("Matches the configured parts of requests", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secrets.yml")
+		recordCassette(t, path, asTransport)
+
+		cassette, err := New(path, Replay, nil)
+		require.NoError(t, err)
+		client := newCassetteClient(t, "http://localhost:1", "other-api-key", cassette, asTransport)
+
+		_, err = client.RetrieveSecretWithVersion("db/password", 1)
+		require.Error(t, err)
+
+		cassette.SetMatch(MatchMethod | MatchPath)
+		_, err = client.RetrieveSecretWithVersion("db/password", 1)
+		require.NoError(t, err)
+	})
+
+	t.Run("Fails to replay a missing cassette", func(t *testing.T) {
+		_, err := New(filepath.Join(t.TempDir(), "missing.yml"), Replay, nil)
+		require.Error(t, err)
+	})
+}
@@ -1,16 +1,20 @@
 package conjurapi
 
 import (
-	"crypto/tls"
-	"crypto/x509"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/logging"
 )
 
 type Authenticator interface {
@@ -18,6 +22,13 @@ type Authenticator interface {
 	NeedsTokenRefresh() bool
 }
 
+// ContextAuthenticator is implemented by authenticators which can honor
+// cancellation and deadlines while obtaining a new access token.
+type ContextAuthenticator interface {
+	Authenticator
+	RefreshTokenCtx(ctx context.Context) ([]byte, error)
+}
+
 type CredentialStorageProvider interface {
 	StoreCredentials(login string, password string) error
 	ReadCredentials() (login string, password string, err error)
@@ -29,9 +40,21 @@ type CredentialStorageProvider interface {
 type Client struct {
 	config        Config
 	authToken     *authn.AuthnToken
+	tokenMutex    sync.RWMutex
+	tokenRefresh  *tokenRefresh
 	httpClient    *http.Client
 	authenticator Authenticator
 	storage       CredentialStorageProvider
+	retryPolicy   *RetryPolicy
+	batchOptions  BatchOptions
+	router        *endpointRouter
+	middleware    []Middleware
+	tracer        trace.Tracer
+	metrics       Metrics
+	logger        *slog.Logger
+
+	cloudURLLogged          sync.Once
+	disableReauthentication bool
 }
 
 func NewClientFromKey(config Config, loginPair authn.LoginPair) (*Client, error) {
@@ -43,6 +66,7 @@ func NewClientFromKey(config Config, loginPair authn.LoginPair) (*Client, error)
 		authenticator,
 	)
 	authenticator.Authenticate = client.Authenticate
+	authenticator.AuthenticateCtx = client.AuthenticateCtx
 	return client, err
 }
 
@@ -58,6 +82,7 @@ func NewClientFromOidcCode(config Config, code, nonce, code_verifier string) (*C
 	)
 	if err == nil {
 		authenticator.Authenticate = client.OidcAuthenticate
+		authenticator.AuthenticateCtx = client.OidcAuthenticateCtx
 	}
 	return client, err
 }
@@ -72,6 +97,7 @@ func NewClientFromOidcToken(config Config, token string) (*Client, error) {
 	)
 	if err == nil {
 		authenticator.Authenticate = client.OidcTokenAuthenticate
+		authenticator.AuthenticateCtx = client.OidcTokenAuthenticateCtx
 	}
 	return client, err
 }
@@ -148,6 +174,7 @@ func NewClientFromJwt(config Config) (*Client, error) {
 	)
 	if err == nil {
 		authenticator.Authenticate = client.JWTAuthenticate
+		authenticator.AuthenticateCtx = client.JWTAuthenticateCtx
 	}
 	return client, err
 }
@@ -191,6 +218,26 @@ func (c *Client) SetAuthenticator(authenticator Authenticator) {
 	c.authenticator = authenticator
 }
 
+// SetLogger sets the logger used for the client's messages, e.g. one created
+// with logging.NewLogger. With a nil logger, the default, messages go to
+// logging.ApiLog, which is configured with the CONJURAPI_LOG environment
+// variable.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.logger = logger
+}
+
+// GetLogger returns the logger used for the client's messages.
+func (c *Client) GetLogger() *slog.Logger {
+	return c.log()
+}
+
+func (c *Client) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return logging.Default()
+}
+
 func (c *Client) GetHttpClient() *http.Client {
 	return c.httpClient
 }
@@ -199,6 +246,21 @@ func (c *Client) SetHttpClient(httpClient *http.Client) {
 	c.httpClient = httpClient
 }
 
+// ReloadSSLCert reloads the Conjur CA certificates from the file at
+// SSLCertPath. New connections trust the reloaded certificates. The file is
+// also reloaded automatically when it changes, see Config.SSLCertReloadInterval.
+// It returns an error when reloading is disabled.
+func (c *Client) ReloadSSLCert() error {
+	transport, ok := c.httpClient.Transport.(*sslCertReloadingTransport)
+	if !ok {
+		if c.config.SSLCert == "" && c.config.SSLCertPath != "" {
+			return fmt.Errorf("Conjur SSL cert reloading is disabled")
+		}
+		return fmt.Errorf("Conjur SSL cert is not loaded from a file")
+	}
+	return transport.Reload(c.log())
+}
+
 func (c *Client) GetConfig() Config {
 	return c.config
 }
@@ -226,6 +288,7 @@ func NewClient(config Config) (*Client, error) {
 		config:     config,
 		httpClient: httpClient,
 		storage:    storageProvider,
+		router:     newEndpointRouter(config),
 	}, nil
 }
 
@@ -233,17 +296,30 @@ func createHttpClient(config Config) (*http.Client, error) {
 	var httpClient *http.Client
 
 	if config.IsHttps() {
-		cert, err := config.ReadSSLCert()
-		if err != nil {
-			return nil, err
+		// Without a Conjur cert, the system roots or extra certs are trusted
+		var cert []byte
+		var err error
+		if config.hasSSLCert() {
+			cert, err = config.ReadSSLCert()
+			if err != nil {
+				return nil, err
+			}
 		}
 		httpClient, err = newHTTPSClient(cert, config)
 		if err != nil {
 			return nil, err
 		}
+		// The transport stays an *http.Transport unless the cert is reloaded
+		if config.SSLCert == "" && config.SSLCertPath != "" && config.GetSSLCertReloadInterval() > 0 {
+			httpClient.Transport = newSSLCertReloadingTransport(httpClient.Transport.(*http.Transport), config)
+		}
 	} else {
+		tr, err := newHTTPTransport(config)
+		if err != nil {
+			return nil, err
+		}
 		httpClient = &http.Client{
-			Transport: newHTTPTransport(),
+			Transport: tr,
 			Timeout:   time.Second * time.Duration(config.GetHttpTimeout()),
 		}
 	}
@@ -261,22 +337,33 @@ func newClientWithAuthenticator(config Config, authenticator Authenticator) (*Cl
 }
 
 func newHTTPSClient(cert []byte, config Config) (*http.Client, error) {
-	pool := x509.NewCertPool()
-	ok := pool.AppendCertsFromPEM(cert)
-	if !ok {
-		return nil, fmt.Errorf("Can't append Conjur SSL cert")
+	pool, err := newCertPool(cert, config)
+	if err != nil {
+		return nil, err
 	}
 	//TODO: Test what happens if this cert is expired
-	//TODO: What if server cert is rotated
-	tr := newHTTPTransport()
-	tr.TLSClientConfig = &tls.Config{RootCAs: pool}
+	tlsConfig, err := newTLSConfig(pool, config)
+	if err != nil {
+		return nil, err
+	}
+	tr, err := newHTTPTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	tr.TLSClientConfig = tlsConfig
 	return &http.Client{Transport: tr, Timeout: time.Second * time.Duration(config.GetHttpTimeout())}, nil
 }
 
-func newHTTPTransport() *http.Transport {
+func newHTTPTransport(config Config) (*http.Transport, error) {
+	proxy, err := newProxyFunc(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &http.Transport{
+		Proxy: proxy,
 		DialContext: (&net.Dialer{
 			Timeout: time.Second * time.Duration(HTTPDailTimeout),
 		}).DialContext,
-	}
+	}, nil
 }
@@ -1,7 +1,10 @@
 package conjurapi
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -11,6 +14,7 @@ import (
 	"time"
 
 	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -452,6 +456,39 @@ func TestClient_SetHttpClient(t *testing.T) {
 	})
 }
 
+func TestClient_SetLogger(t *testing.T) {
+	t.Run("Defaults to ApiLog", func(t *testing.T) {
+		client := Client{}
+		assert.Equal(t, logging.Default(), client.GetLogger())
+	})
+
+	t.Run("Clients log to their own logger", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 1, http.StatusServiceUnavailable))
+
+		var debugBuf, warnBuf bytes.Buffer
+		debugClient := newRetryTestClient(t, server, fastRetryPolicy())
+		debugClient.SetLogger(logging.NewLogger(&debugBuf, slog.LevelDebug))
+		warnClient := newRetryTestClient(t, server, fastRetryPolicy())
+		warnClient.SetLogger(logging.NewLogger(&warnBuf, slog.LevelWarn))
+
+		_, err := debugClient.RetrieveSecret("my-var")
+		require.NoError(t, err)
+
+		records := map[string][]map[string]interface{}{}
+		decoder := json.NewDecoder(&debugBuf)
+		for decoder.More() {
+			var record map[string]interface{}
+			require.NoError(t, decoder.Decode(&record))
+			records[record["msg"].(string)] = append(records[record["msg"].(string)], record)
+		}
+		require.Len(t, records["Retrying request after HTTP error"], 1)
+		assert.EqualValues(t, http.StatusServiceUnavailable, records["Retrying request after HTTP error"][0]["status"])
+		// The responses to the authentication and to the retried request
+		assert.Len(t, records["Received response"], 2)
+		assert.Empty(t, warnBuf.String())
+	})
+}
+
 func TestClient_createHttpClient(t *testing.T) {
 	t.Run("Create HTTP client with HTTPS and valid cert", func(t *testing.T) {
 		config := Config{Account: "account", ApplianceURL: "https://appliance-url", SSLCert: sample_cert}
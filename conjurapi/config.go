@@ -2,11 +2,14 @@ package conjurapi
 
 import (
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
 	"path"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -21,29 +24,137 @@ const (
 	HTTPTimeoutMaxValue = 600
 	// HTTPDailTimeout is the default value for the DialTimeout in the HTTP client
 	HTTPDailTimeout = 10
+	// SSLCertReloadIntervalDefaultValue is the default interval, in seconds, at
+	// which the file at SSLCertPath is checked for changes
+	SSLCertReloadIntervalDefaultValue = 60
+	// FailoverCooldownDefaultValue is the default period, in seconds, for
+	// which an unavailable Conjur node is skipped
+	FailoverCooldownDefaultValue = 30
 )
 
 var supportedAuthnTypes = []string{"authn", "ldap", "oidc", "jwt"}
 
 type Config struct {
-	Account           string `yaml:"account,omitempty"`
-	ApplianceURL      string `yaml:"appliance_url,omitempty"`
-	NetRCPath         string `yaml:"netrc_path,omitempty"`
-	SSLCert           string `yaml:"-"`
-	SSLCertPath       string `yaml:"cert_file,omitempty"`
-	AuthnType         string `yaml:"authn_type,omitempty"`
-	ServiceID         string `yaml:"service_id,omitempty"`
-	CredentialStorage string `yaml:"credential_storage,omitempty"`
-	JWTHostID         string `yaml:"jwt_host_id,omitempty"`
-	JWTContent        string `yaml:"-"`
-	JWTFilePath       string `yaml:"jwt_file,omitempty"`
-	HTTPTimeout       int    `yaml:"http_timeout,omitempty"`
+	Account                string   `yaml:"account,omitempty"`
+	ApplianceURL           string   `yaml:"appliance_url,omitempty"`
+	FollowerURLs           []string `yaml:"follower_urls,omitempty"`
+	FailoverCooldown       int      `yaml:"failover_cooldown,omitempty"`
+	NetRCPath              string   `yaml:"netrc_path,omitempty"`
+	SSLCert                string   `yaml:"-"`
+	SSLCertPath            string   `yaml:"cert_file,omitempty"`
+	SSLCertReloadInterval  int      `yaml:"cert_reload_interval,omitempty"`
+	SSLUseSystemCertPool   bool     `yaml:"use_system_cert_pool,omitempty"`
+	SSLExtraCertPaths      []string `yaml:"extra_cert_files,omitempty"`
+	AuthnType              string   `yaml:"authn_type,omitempty"`
+	ServiceID              string   `yaml:"service_id,omitempty"`
+	CredentialStorage      string   `yaml:"credential_storage,omitempty"`
+	JWTHostID              string   `yaml:"jwt_host_id,omitempty"`
+	JWTContent             string   `yaml:"-"`
+	JWTFilePath            string   `yaml:"jwt_file,omitempty"`
+	HTTPTimeout            int      `yaml:"http_timeout,omitempty"`
+	ClientCert             string   `yaml:"-"`
+	ClientCertPath         string   `yaml:"client_cert_file,omitempty"`
+	ClientKey              string   `yaml:"-"`
+	ClientKeyPath          string   `yaml:"client_key_file,omitempty"`
+	ProxyURL               string   `yaml:"proxy_url,omitempty"`
+	NoProxy                string   `yaml:"no_proxy,omitempty"`
+	ProxyUsername          string   `yaml:"proxy_username,omitempty"`
+	ProxyPassword          string   `yaml:"-"`
+	PinnedFingerprints     []string `yaml:"pinned_fingerprints,omitempty"`
+	PinnedFingerprintsOnly bool     `yaml:"pinned_fingerprints_only,omitempty"`
 }
 
+// IsHttps reports whether the client connects to Conjur over HTTPS, either
+// because ApplianceURL says so or because trusted certificates are configured.
 func (c *Config) IsHttps() bool {
+	return c.hasSSLCert() ||
+		c.SSLUseSystemCertPool ||
+		len(c.SSLExtraCertPaths) > 0 ||
+		strings.HasPrefix(c.ApplianceURL, "https://")
+}
+
+func (c *Config) hasSSLCert() bool {
 	return c.SSLCertPath != "" || c.SSLCert != ""
 }
 
+// HasClientCert reports whether the config specifies a client certificate to
+// present to the server for mutual TLS.
+func (c *Config) HasClientCert() bool {
+	return c.ClientCertPath != "" || c.ClientCert != ""
+}
+
+func (c *Config) hasClientKey() bool {
+	return c.ClientKeyPath != "" || c.ClientKey != ""
+}
+
+// configRedacted replaces the secrets of a Config when it is formatted.
+const configRedacted = "[REDACTED]"
+
+// configFields has the fields of Config without its methods, so that it can
+// be formatted without recursing into them.
+type configFields Config
+
+// redacted returns a copy of c with its secrets replaced by configRedacted,
+// and without the credentials embedded in ProxyURL.
+func (c Config) redacted() configFields {
+	if c.ClientKey != "" {
+		c.ClientKey = configRedacted
+	}
+	if c.JWTContent != "" {
+		c.JWTContent = configRedacted
+	}
+	if c.ProxyPassword != "" {
+		c.ProxyPassword = configRedacted
+	}
+	c.ProxyURL = redactProxyURL(c.ProxyURL)
+	return configFields(c)
+}
+
+// redactProxyURL returns rawURL without its userinfo. A URL which can't be
+// parsed is redacted entirely, since its credentials can't be told apart.
+func redactProxyURL(rawURL string) string {
+	if !strings.Contains(rawURL, "@") {
+		return rawURL
+	}
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil || proxyURL.User == nil {
+		return configRedacted
+	}
+	proxyURL.User = nil
+	return proxyURL.String()
+}
+
+// String formats c like %+v, with its secrets redacted, so that it can be
+// logged and included in errors.
+func (c Config) String() string {
+	return fmt.Sprintf("%+v", c.redacted())
+}
+
+// GoString formats c like %#v, with its secrets redacted.
+func (c Config) GoString() string {
+	return strings.Replace(fmt.Sprintf("%#v", c.redacted()), "conjurapi.configFields", "conjurapi.Config", 1)
+}
+
+// LogValue implements slog.LogValuer, so that c is logged with its secrets
+// redacted.
+func (c Config) LogValue() slog.Value {
+	return slog.AnyValue(c.redacted())
+}
+
+// hasPlainHTTPURL reports whether the ApplianceURL or a follower URL is an
+// http:// URL, over which TLS settings would be ignored.
+func (c *Config) hasPlainHTTPURL() bool {
+	if strings.HasPrefix(c.ApplianceURL, "http://") {
+		return true
+	}
+	for _, followerURL := range c.FollowerURLs {
+		if strings.HasPrefix(followerURL, "http://") {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) Validate() error {
 	errors := []string{}
 
@@ -55,6 +166,16 @@ func (c *Config) Validate() error {
 		errors = append(errors, "Must specify an Account")
 	}
 
+	for _, followerURL := range c.FollowerURLs {
+		if !strings.HasPrefix(followerURL, "http://") && !strings.HasPrefix(followerURL, "https://") {
+			errors = append(errors, fmt.Sprintf("Follower URL %q must start with http:// or https://", followerURL))
+		}
+	}
+
+	if c.FailoverCooldown < 0 {
+		errors = append(errors, "FailoverCooldown must not be negative")
+	}
+
 	if c.AuthnType != "" && !contains(supportedAuthnTypes, c.AuthnType) {
 		errors = append(errors, fmt.Sprintf("AuthnType must be one of %v", supportedAuthnTypes))
 	}
@@ -71,10 +192,38 @@ func (c *Config) Validate() error {
 		errors = append(errors, fmt.Sprintf("HTTPTimeout must be between 1 and %d seconds", HTTPTimeoutMaxValue))
 	}
 
+	if c.HasClientCert() != c.hasClientKey() {
+		errors = append(errors, "Must specify both a client certificate and a client key when using mutual TLS")
+	}
+
+	if (c.HasClientCert() || c.hasClientKey()) && c.hasPlainHTTPURL() {
+		errors = append(errors, "Must use https:// URLs when using mutual TLS")
+	}
+
+	if c.ProxyURL != "" {
+		if _, err := parseProxyURL(c.ProxyURL); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	for _, fingerprint := range c.PinnedFingerprints {
+		if _, err := parseFingerprint(fingerprint); err != nil {
+			errors = append(errors, err.Error())
+		}
+	}
+
+	if c.PinnedFingerprintsOnly && len(c.PinnedFingerprints) == 0 {
+		errors = append(errors, "Must specify PinnedFingerprints when using PinnedFingerprintsOnly")
+	}
+
+	if len(c.PinnedFingerprints) > 0 && c.hasPlainHTTPURL() {
+		errors = append(errors, "Must use https:// URLs when using PinnedFingerprints")
+	}
+
 	if len(errors) == 0 {
 		return nil
 	} else if logging.ApiLog.Level == logrus.DebugLevel {
-		errors = append(errors, fmt.Sprintf("config: %+v", c))
+		errors = append(errors, fmt.Sprintf("config: %s", c))
 	}
 	return fmt.Errorf("%s", strings.Join(errors, " -- "))
 }
@@ -86,6 +235,25 @@ func (c *Config) ReadSSLCert() ([]byte, error) {
 	return os.ReadFile(c.SSLCertPath)
 }
 
+// ReadClientCert returns the PEM-encoded client certificate and key, read
+// from ClientCert and ClientKey or from the files they point to.
+func (c *Config) ReadClientCert() (cert []byte, key []byte, err error) {
+	cert = []byte(c.ClientCert)
+	if c.ClientCert == "" {
+		if cert, err = os.ReadFile(c.ClientCertPath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	key = []byte(c.ClientKey)
+	if c.ClientKey == "" {
+		if key, err = os.ReadFile(c.ClientKeyPath); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cert, key, nil
+}
+
 func (c *Config) BaseURL() string {
 	prefix := ""
 	if !strings.HasPrefix(c.ApplianceURL, "http") {
@@ -115,6 +283,31 @@ func (c *Config) GetHttpTimeout() int {
 	}
 }
 
+// GetSSLCertReloadInterval returns how often the file at SSLCertPath is
+// checked for changes, or 0 if it is never reloaded. config.SSLCertReloadInterval
+// is in seconds: 0 means the default interval (constant
+// SSLCertReloadIntervalDefaultValue) and a negative value disables reloading.
+func (c *Config) GetSSLCertReloadInterval() time.Duration {
+	switch {
+	case c.SSLCertReloadInterval < 0:
+		return 0
+	case c.SSLCertReloadInterval == 0:
+		return time.Second * time.Duration(SSLCertReloadIntervalDefaultValue)
+	default:
+		return time.Second * time.Duration(c.SSLCertReloadInterval)
+	}
+}
+
+// GetFailoverCooldown returns how long an unavailable Conjur node is skipped
+// before requests are sent to it again. config.FailoverCooldown is in seconds,
+// and 0 means the default period (constant FailoverCooldownDefaultValue).
+func (c *Config) GetFailoverCooldown() time.Duration {
+	if c.FailoverCooldown <= 0 {
+		return time.Second * time.Duration(FailoverCooldownDefaultValue)
+	}
+	return time.Second * time.Duration(c.FailoverCooldown)
+}
+
 func mergeValue(a, b string) string {
 	if len(b) != 0 {
 		return b
@@ -122,6 +315,22 @@ func mergeValue(a, b string) string {
 	return a
 }
 
+func mergeSlice(a, b []string) []string {
+	if len(b) != 0 {
+		return b
+	}
+	return a
+}
+
+// mergeBool returns b if it is set, so that a later layer can turn off a
+// setting turned on by a previous one, or else a.
+func mergeBool(a bool, b *bool) bool {
+	if b != nil {
+		return *b
+	}
+	return a
+}
+
 func mergeInt(a, b int) int {
 	if b != 0 {
 		return b
@@ -129,11 +338,23 @@ func mergeInt(a, b int) int {
 	return a
 }
 
-func (c *Config) merge(o *Config) {
+// configBools holds the boolean settings of a config layer which it sets,
+// since false can't be told apart from unset in a Config.
+type configBools struct {
+	SSLUseSystemCertPool   *bool `yaml:"use_system_cert_pool"`
+	PinnedFingerprintsOnly *bool `yaml:"pinned_fingerprints_only"`
+}
+
+func (c *Config) merge(o *Config, bools configBools) {
 	c.ApplianceURL = mergeValue(c.ApplianceURL, o.ApplianceURL)
+	c.FollowerURLs = mergeSlice(c.FollowerURLs, o.FollowerURLs)
+	c.FailoverCooldown = mergeInt(c.FailoverCooldown, o.FailoverCooldown)
 	c.Account = mergeValue(c.Account, o.Account)
 	c.SSLCert = mergeValue(c.SSLCert, o.SSLCert)
 	c.SSLCertPath = mergeValue(c.SSLCertPath, o.SSLCertPath)
+	c.SSLCertReloadInterval = mergeInt(c.SSLCertReloadInterval, o.SSLCertReloadInterval)
+	c.SSLUseSystemCertPool = mergeBool(c.SSLUseSystemCertPool, bools.SSLUseSystemCertPool)
+	c.SSLExtraCertPaths = mergeSlice(c.SSLExtraCertPaths, o.SSLExtraCertPaths)
 	c.NetRCPath = mergeValue(c.NetRCPath, o.NetRCPath)
 	c.CredentialStorage = mergeValue(c.CredentialStorage, o.CredentialStorage)
 	c.AuthnType = mergeValue(c.AuthnType, o.AuthnType)
@@ -142,6 +363,16 @@ func (c *Config) merge(o *Config) {
 	c.JWTContent = mergeValue(c.JWTContent, o.JWTContent)
 	c.JWTFilePath = mergeValue(c.JWTFilePath, o.JWTFilePath)
 	c.HTTPTimeout = mergeInt(c.HTTPTimeout, o.HTTPTimeout)
+	c.ClientCert = mergeValue(c.ClientCert, o.ClientCert)
+	c.ClientCertPath = mergeValue(c.ClientCertPath, o.ClientCertPath)
+	c.ClientKey = mergeValue(c.ClientKey, o.ClientKey)
+	c.ClientKeyPath = mergeValue(c.ClientKeyPath, o.ClientKeyPath)
+	c.ProxyURL = mergeValue(c.ProxyURL, o.ProxyURL)
+	c.NoProxy = mergeValue(c.NoProxy, o.NoProxy)
+	c.ProxyUsername = mergeValue(c.ProxyUsername, o.ProxyUsername)
+	c.ProxyPassword = mergeValue(c.ProxyPassword, o.ProxyPassword)
+	c.PinnedFingerprints = mergeSlice(c.PinnedFingerprints, o.PinnedFingerprints)
+	c.PinnedFingerprintsOnly = mergeBool(c.PinnedFingerprintsOnly, bools.PinnedFingerprintsOnly)
 }
 
 func (c *Config) mergeYAML(filename string) error {
@@ -170,9 +401,16 @@ func (c *Config) mergeYAML(filename string) error {
 		return err
 	}
 
+	// The boolean settings are parsed again to tell false from unset
+	bools := configBools{}
+	if err := yaml.Unmarshal(buf, &bools); err != nil {
+		logging.ApiLog.Errorf("Parsing error %s: %s\n", filename, err)
+		return err
+	}
+
 	// Now merge the parsed config into the current config object
-	logging.ApiLog.Debugf("Config from %s: %+v\n", filename, aux.Config)
-	c.merge(&aux.Config)
+	logging.ApiLog.Debugf("Config from %s: %s\n", filename, aux.Config)
+	c.merge(&aux.Config, bools)
 
 	// BEGIN COMPATIBILITY WITH PYTHON CLI
 	// The Python CLI uses the keys conjur_url and conjur_account
@@ -191,19 +429,38 @@ func (c *Config) mergeYAML(filename string) error {
 }
 
 func (c *Config) mergeEnv() {
+	bools := configBools{
+		SSLUseSystemCertPool:   boolFromEnv("CONJUR_USE_SYSTEM_CERT_POOL"),
+		PinnedFingerprintsOnly: boolFromEnv("CONJUR_PINNED_FINGERPRINTS_ONLY"),
+	}
 	env := Config{
-		ApplianceURL:      os.Getenv("CONJUR_APPLIANCE_URL"),
-		SSLCert:           os.Getenv("CONJUR_SSL_CERTIFICATE"),
-		SSLCertPath:       os.Getenv("CONJUR_CERT_FILE"),
-		Account:           os.Getenv("CONJUR_ACCOUNT"),
-		NetRCPath:         os.Getenv("CONJUR_NETRC_PATH"),
-		CredentialStorage: os.Getenv("CONJUR_CREDENTIAL_STORAGE"),
-		AuthnType:         os.Getenv("CONJUR_AUTHN_TYPE"),
-		ServiceID:         os.Getenv("CONJUR_SERVICE_ID"),
-		JWTContent:        os.Getenv("CONJUR_AUTHN_JWT_TOKEN"),
-		JWTFilePath:       os.Getenv("JWT_TOKEN_PATH"),
-		JWTHostID:         os.Getenv("CONJUR_AUTHN_JWT_HOST_ID"),
-		HTTPTimeout:       httpTimoutFromEnv(),
+		ApplianceURL:           os.Getenv("CONJUR_APPLIANCE_URL"),
+		FollowerURLs:           listFromEnv("CONJUR_FOLLOWER_URLS"),
+		FailoverCooldown:       failoverCooldownFromEnv(),
+		SSLCert:                os.Getenv("CONJUR_SSL_CERTIFICATE"),
+		SSLCertPath:            os.Getenv("CONJUR_CERT_FILE"),
+		SSLCertReloadInterval:  certReloadIntervalFromEnv(),
+		SSLUseSystemCertPool:   bools.SSLUseSystemCertPool != nil && *bools.SSLUseSystemCertPool,
+		SSLExtraCertPaths:      listFromEnv("CONJUR_EXTRA_CERT_FILES"),
+		Account:                os.Getenv("CONJUR_ACCOUNT"),
+		NetRCPath:              os.Getenv("CONJUR_NETRC_PATH"),
+		CredentialStorage:      os.Getenv("CONJUR_CREDENTIAL_STORAGE"),
+		AuthnType:              os.Getenv("CONJUR_AUTHN_TYPE"),
+		ServiceID:              os.Getenv("CONJUR_SERVICE_ID"),
+		JWTContent:             os.Getenv("CONJUR_AUTHN_JWT_TOKEN"),
+		JWTFilePath:            os.Getenv("JWT_TOKEN_PATH"),
+		JWTHostID:              os.Getenv("CONJUR_AUTHN_JWT_HOST_ID"),
+		HTTPTimeout:            httpTimoutFromEnv(),
+		ClientCert:             os.Getenv("CONJUR_CLIENT_CERTIFICATE"),
+		ClientCertPath:         os.Getenv("CONJUR_CLIENT_CERT_FILE"),
+		ClientKey:              os.Getenv("CONJUR_CLIENT_KEY"),
+		ClientKeyPath:          os.Getenv("CONJUR_CLIENT_KEY_FILE"),
+		ProxyURL:               os.Getenv("CONJUR_PROXY_URL"),
+		NoProxy:                os.Getenv("CONJUR_NO_PROXY"),
+		ProxyUsername:          os.Getenv("CONJUR_PROXY_USERNAME"),
+		ProxyPassword:          os.Getenv("CONJUR_PROXY_PASSWORD"),
+		PinnedFingerprints:     listFromEnv("CONJUR_PINNED_FINGERPRINTS"),
+		PinnedFingerprintsOnly: bools.PinnedFingerprintsOnly != nil && *bools.PinnedFingerprintsOnly,
 	}
 
 	if os.Getenv("CONJUR_AUTHN_JWT_SERVICE_ID") != "" {
@@ -213,8 +470,23 @@ func (c *Config) mergeEnv() {
 		env.ServiceID = mergeValue(env.ServiceID, os.Getenv("CONJUR_AUTHN_JWT_SERVICE_ID"))
 	}
 
-	logging.ApiLog.Debugf("Config from environment: %+v\n", env)
-	c.merge(&env)
+	logging.ApiLog.Debugf("Config from environment: %s\n", env)
+	c.merge(&env, bools)
+}
+
+// boolFromEnv returns the boolean value of the environment variable, or nil
+// if it is unset or invalid.
+func boolFromEnv(name string) *bool {
+	valueStr, ok := os.LookupEnv(name)
+	if !ok || len(valueStr) == 0 {
+		return nil
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		logging.ApiLog.Infof("Could not parse %s, ignoring it: %s", name, err)
+		return nil
+	}
+	return &value
 }
 
 func httpTimoutFromEnv() int {
@@ -233,6 +505,49 @@ func httpTimoutFromEnv() int {
 	return timeout
 }
 
+// listFromEnv returns the comma-separated values of the environment variable.
+func listFromEnv(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func certReloadIntervalFromEnv() int {
+	intervalStr, ok := os.LookupEnv("CONJUR_CERT_RELOAD_INTERVAL")
+	if !ok || len(intervalStr) == 0 {
+		return 0
+	}
+	interval, err := strconv.Atoi(intervalStr)
+	if err != nil {
+		logging.ApiLog.Infof(
+			"Could not parse CONJUR_CERT_RELOAD_INTERVAL, using default value (%ds): %s",
+			SSLCertReloadIntervalDefaultValue,
+			err)
+		interval = 0
+	}
+	return interval
+}
+
+func failoverCooldownFromEnv() int {
+	cooldownStr, ok := os.LookupEnv("CONJUR_FAILOVER_COOLDOWN")
+	if !ok || len(cooldownStr) == 0 {
+		return 0
+	}
+	cooldown, err := strconv.Atoi(cooldownStr)
+	if err != nil {
+		logging.ApiLog.Infof(
+			"Could not parse CONJUR_FAILOVER_COOLDOWN, using default value (%ds): %s",
+			FailoverCooldownDefaultValue,
+			err)
+		cooldown = 0
+	}
+	return cooldown
+}
+
 func (c *Config) applyDefaults() {
 	if isConjurCloudURL(c.ApplianceURL) && c.Account == "" {
 		logging.ApiLog.Info("Detected Conjur Cloud URL, setting 'Account' to 'conjur")
@@ -276,7 +591,7 @@ func LoadConfig() (Config, error) {
 
 	config.applyDefaults()
 
-	logging.ApiLog.Debugf("Final config: %+v\n", config)
+	logging.ApiLog.Debugf("Final config: %s\n", config)
 	return config, nil
 }
 
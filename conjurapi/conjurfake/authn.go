@@ -0,0 +1,49 @@
+package conjurfake
+
+import (
+	"context"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// AuthenticatorManager is a fake conjurapi.AuthenticatorManager.
+type AuthenticatorManager struct {
+	EnableAuthenticatorFunc func(ctx context.Context, authenticatorType string, serviceID string, enabled bool) error
+	AuthenticatorStatusFunc func(ctx context.Context, authenticatorType string, serviceID string) (*conjurapi.AuthenticatorStatusResponse, error)
+	ListOidcProvidersFunc   func(ctx context.Context) ([]conjurapi.OidcProvider, error)
+}
+
+var _ conjurapi.AuthenticatorManager = (*AuthenticatorManager)(nil)
+
+func (f *AuthenticatorManager) EnableAuthenticator(authenticatorType string, serviceID string, enabled bool) error {
+	return f.EnableAuthenticatorCtx(context.Background(), authenticatorType, serviceID, enabled)
+}
+
+func (f *AuthenticatorManager) EnableAuthenticatorCtx(ctx context.Context, authenticatorType string, serviceID string, enabled bool) error {
+	if f.EnableAuthenticatorFunc == nil {
+		return notStubbed("EnableAuthenticator")
+	}
+	return f.EnableAuthenticatorFunc(ctx, authenticatorType, serviceID, enabled)
+}
+
+func (f *AuthenticatorManager) AuthenticatorStatus(authenticatorType string, serviceID string) (*conjurapi.AuthenticatorStatusResponse, error) {
+	return f.AuthenticatorStatusCtx(context.Background(), authenticatorType, serviceID)
+}
+
+func (f *AuthenticatorManager) AuthenticatorStatusCtx(ctx context.Context, authenticatorType string, serviceID string) (*conjurapi.AuthenticatorStatusResponse, error) {
+	if f.AuthenticatorStatusFunc == nil {
+		return nil, notStubbed("AuthenticatorStatus")
+	}
+	return f.AuthenticatorStatusFunc(ctx, authenticatorType, serviceID)
+}
+
+func (f *AuthenticatorManager) ListOidcProviders() ([]conjurapi.OidcProvider, error) {
+	return f.ListOidcProvidersCtx(context.Background())
+}
+
+func (f *AuthenticatorManager) ListOidcProvidersCtx(ctx context.Context) ([]conjurapi.OidcProvider, error) {
+	if f.ListOidcProvidersFunc == nil {
+		return nil, notStubbed("ListOidcProviders")
+	}
+	return f.ListOidcProvidersFunc(ctx)
+}
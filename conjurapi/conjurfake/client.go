@@ -0,0 +1,54 @@
+// Package conjurfake provides hand-written fakes of the interfaces of the
+// conjurapi package, for testing code which depends on them rather than on
+// *conjurapi.Client.
+//
+// Each fake has a function field for each group of methods, e.g.
+// RetrieveSecretFunc for RetrieveSecret and RetrieveSecretCtx, which tests set
+// to the behavior they need:
+//
+//	secrets := &conjurfake.SecretsReader{
+//		RetrieveSecretFunc: func(ctx context.Context, variableID string) ([]byte, error) {
+//			return []byte("s3cr3t"), nil
+//		},
+//	}
+//
+// Methods whose function isn't set return an error wrapping ErrNotStubbed.
+// Client combines all the fakes.
+package conjurfake
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// ErrNotStubbed is returned, wrapped, by the methods of fakes whose function
+// isn't set.
+var ErrNotStubbed = errors.New("not stubbed")
+
+func notStubbed(method string) error {
+	return fmt.Errorf("conjurfake: %s: %w", method, ErrNotStubbed)
+}
+
+// Client is a fake of all the interfaces of conjurapi.Client.
+type Client struct {
+	SecretsReader
+	SecretsWriter
+	PolicyLoader
+	ResourceBrowser
+	RoleBrowser
+	HostFactory
+	AuthenticatorManager
+}
+
+var (
+	_ conjurapi.SecretsReader        = (*Client)(nil)
+	_ conjurapi.PartialSecretsReader = (*Client)(nil)
+	_ conjurapi.SecretsWriter        = (*Client)(nil)
+	_ conjurapi.PolicyLoader         = (*Client)(nil)
+	_ conjurapi.ResourceBrowser      = (*Client)(nil)
+	_ conjurapi.RoleBrowser          = (*Client)(nil)
+	_ conjurapi.HostFactory          = (*Client)(nil)
+	_ conjurapi.AuthenticatorManager = (*Client)(nil)
+)
@@ -0,0 +1,90 @@
+package conjurfake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// retrieveDatabasePassword stands for code under test which depends on an
+// interface rather than on *conjurapi.Client.
+func retrieveDatabasePassword(secrets conjurapi.SecretsReader) (string, error) {
+	value, err := secrets.RetrieveSecret("db/password")
+	return string(value), err
+}
+
+func TestClient(t *testing.T) {
+	t.Run("Calls the stubbed functions", func(t *testing.T) {
+		client := &Client{
+			SecretsReader: SecretsReader{
+				RetrieveSecretFunc: func(ctx context.Context, variableID string) ([]byte, error) {
+					assert.NotNil(t, ctx)
+					return []byte("value of " + variableID), nil
+				},
+			},
+			HostFactory: HostFactory{
+				CreateHostFunc: func(ctx context.Context, id string, token string, annotations map[string]string) (conjurapi.HostFactoryHostResponse, error) {
+					return conjurapi.HostFactoryHostResponse{Id: "conjur:host:" + id}, nil
+				},
+			},
+		}
+
+		password, err := retrieveDatabasePassword(client)
+		require.NoError(t, err)
+		assert.Equal(t, "value of db/password", password)
+
+		reader, err := client.RetrieveSecretReader("db/username")
+		require.NoError(t, err)
+		value, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "value of db/username", string(value))
+
+		host, err := client.CreateHost("app", "token")
+		require.NoError(t, err)
+		assert.Equal(t, "conjur:host:app", host.Id)
+	})
+
+	t.Run("Falls back to related functions", func(t *testing.T) {
+		resources := &ResourceBrowser{
+			ResourceFunc: func(ctx context.Context, resourceID string) (map[string]interface{}, error) {
+				if resourceID == "conjur:variable:db/password" {
+					return map[string]interface{}{"id": resourceID}, nil
+				}
+				return nil, nil
+			},
+		}
+
+		exists, err := resources.ResourceExists("conjur:variable:db/password")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = resources.ResourceExists("conjur:variable:missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		secrets := &SecretsReader{
+			RetrieveBatchSecretsPartialFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+				return map[string][]byte{"conjur:variable:db/password": []byte("value")}, map[string]error{}, nil
+			},
+		}
+		values, _, err := secrets.RetrieveBatchSecretsPartialSafe([]string{"db/password"})
+		require.NoError(t, err)
+		assert.Equal(t, "value", string(values["conjur:variable:db/password"]))
+	})
+
+	t.Run("Fails methods which aren't stubbed", func(t *testing.T) {
+		client := &Client{}
+
+		_, err := client.LoadPolicy(conjurapi.PolicyModePost, "root", nil)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrNotStubbed))
+		assert.Contains(t, err.Error(), "LoadPolicy")
+
+		err = client.AddSecretCtx(context.Background(), "db/password", "value")
+		assert.True(t, errors.Is(err, ErrNotStubbed))
+	})
+}
@@ -0,0 +1,58 @@
+package conjurfake
+
+import (
+	"context"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// HostFactory is a fake conjurapi.HostFactory. CreateHost is answered by
+// CreateHostFunc, with nil annotations.
+type HostFactory struct {
+	CreateTokenFunc func(ctx context.Context, durationStr string, hostFactory string, cidrs []string, count int) ([]conjurapi.HostFactoryTokenResponse, error)
+	DeleteTokenFunc func(ctx context.Context, token string) error
+	CreateHostFunc  func(ctx context.Context, id string, token string, annotations map[string]string) (conjurapi.HostFactoryHostResponse, error)
+}
+
+var _ conjurapi.HostFactory = (*HostFactory)(nil)
+
+func (f *HostFactory) CreateToken(durationStr string, hostFactory string, cidrs []string, count int) ([]conjurapi.HostFactoryTokenResponse, error) {
+	return f.CreateTokenCtx(context.Background(), durationStr, hostFactory, cidrs, count)
+}
+
+func (f *HostFactory) CreateTokenCtx(ctx context.Context, durationStr string, hostFactory string, cidrs []string, count int) ([]conjurapi.HostFactoryTokenResponse, error) {
+	if f.CreateTokenFunc == nil {
+		return nil, notStubbed("CreateToken")
+	}
+	return f.CreateTokenFunc(ctx, durationStr, hostFactory, cidrs, count)
+}
+
+func (f *HostFactory) DeleteToken(token string) error {
+	return f.DeleteTokenCtx(context.Background(), token)
+}
+
+func (f *HostFactory) DeleteTokenCtx(ctx context.Context, token string) error {
+	if f.DeleteTokenFunc == nil {
+		return notStubbed("DeleteToken")
+	}
+	return f.DeleteTokenFunc(ctx, token)
+}
+
+func (f *HostFactory) CreateHost(id string, token string) (conjurapi.HostFactoryHostResponse, error) {
+	return f.CreateHostCtx(context.Background(), id, token)
+}
+
+func (f *HostFactory) CreateHostCtx(ctx context.Context, id string, token string) (conjurapi.HostFactoryHostResponse, error) {
+	return f.CreateHostWithAnnotationsCtx(ctx, id, token, nil)
+}
+
+func (f *HostFactory) CreateHostWithAnnotations(id string, token string, annotations map[string]string) (conjurapi.HostFactoryHostResponse, error) {
+	return f.CreateHostWithAnnotationsCtx(context.Background(), id, token, annotations)
+}
+
+func (f *HostFactory) CreateHostWithAnnotationsCtx(ctx context.Context, id string, token string, annotations map[string]string) (conjurapi.HostFactoryHostResponse, error) {
+	if f.CreateHostFunc == nil {
+		return conjurapi.HostFactoryHostResponse{}, notStubbed("CreateHost")
+	}
+	return f.CreateHostFunc(ctx, id, token, annotations)
+}
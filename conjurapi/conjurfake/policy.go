@@ -0,0 +1,50 @@
+package conjurfake
+
+import (
+	"context"
+	"io"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// PolicyLoader is a fake conjurapi.PolicyLoader.
+type PolicyLoader struct {
+	LoadPolicyFunc   func(ctx context.Context, mode conjurapi.PolicyMode, policyID string, policy io.Reader) (*conjurapi.PolicyResponse, error)
+	DryRunPolicyFunc func(ctx context.Context, mode conjurapi.PolicyMode, policyID string, policy io.Reader) (*conjurapi.DryRunPolicyResponse, error)
+	FetchPolicyFunc  func(ctx context.Context, policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error)
+}
+
+var _ conjurapi.PolicyLoader = (*PolicyLoader)(nil)
+
+func (f *PolicyLoader) LoadPolicy(mode conjurapi.PolicyMode, policyID string, policy io.Reader) (*conjurapi.PolicyResponse, error) {
+	return f.LoadPolicyCtx(context.Background(), mode, policyID, policy)
+}
+
+func (f *PolicyLoader) LoadPolicyCtx(ctx context.Context, mode conjurapi.PolicyMode, policyID string, policy io.Reader) (*conjurapi.PolicyResponse, error) {
+	if f.LoadPolicyFunc == nil {
+		return nil, notStubbed("LoadPolicy")
+	}
+	return f.LoadPolicyFunc(ctx, mode, policyID, policy)
+}
+
+func (f *PolicyLoader) DryRunPolicy(mode conjurapi.PolicyMode, policyID string, policy io.Reader) (*conjurapi.DryRunPolicyResponse, error) {
+	return f.DryRunPolicyCtx(context.Background(), mode, policyID, policy)
+}
+
+func (f *PolicyLoader) DryRunPolicyCtx(ctx context.Context, mode conjurapi.PolicyMode, policyID string, policy io.Reader) (*conjurapi.DryRunPolicyResponse, error) {
+	if f.DryRunPolicyFunc == nil {
+		return nil, notStubbed("DryRunPolicy")
+	}
+	return f.DryRunPolicyFunc(ctx, mode, policyID, policy)
+}
+
+func (f *PolicyLoader) FetchPolicy(policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error) {
+	return f.FetchPolicyCtx(context.Background(), policyID, returnJSON, policyTreeDepth, sizeLimit)
+}
+
+func (f *PolicyLoader) FetchPolicyCtx(ctx context.Context, policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error) {
+	if f.FetchPolicyFunc == nil {
+		return nil, notStubbed("FetchPolicy")
+	}
+	return f.FetchPolicyFunc(ctx, policyID, returnJSON, policyTreeDepth, sizeLimit)
+}
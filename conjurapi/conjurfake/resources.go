@@ -0,0 +1,180 @@
+package conjurfake
+
+import (
+	"context"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// ResourceBrowser is a fake conjurapi.ResourceBrowser. CheckPermission and
+// ResourceExists are answered by CheckPermissionForRoleFunc, with an empty
+// role ID, and ResourceFunc when their own functions aren't set.
+type ResourceBrowser struct {
+	CheckPermissionFunc        func(ctx context.Context, resourceID string, privilege string) (bool, error)
+	CheckPermissionForRoleFunc func(ctx context.Context, resourceID string, roleID string, privilege string) (bool, error)
+	ResourceExistsFunc         func(ctx context.Context, resourceID string) (bool, error)
+	ResourceFunc               func(ctx context.Context, resourceID string) (map[string]interface{}, error)
+	ResourcesFunc              func(ctx context.Context, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error)
+	ResourceIDsFunc            func(ctx context.Context, filter *conjurapi.ResourceFilter) ([]string, error)
+	PermittedRolesFunc         func(ctx context.Context, resourceID, privilege string) ([]string, error)
+}
+
+var _ conjurapi.ResourceBrowser = (*ResourceBrowser)(nil)
+
+func (f *ResourceBrowser) CheckPermission(resourceID string, privilege string) (bool, error) {
+	return f.CheckPermissionCtx(context.Background(), resourceID, privilege)
+}
+
+func (f *ResourceBrowser) CheckPermissionCtx(ctx context.Context, resourceID string, privilege string) (bool, error) {
+	switch {
+	case f.CheckPermissionFunc != nil:
+		return f.CheckPermissionFunc(ctx, resourceID, privilege)
+	case f.CheckPermissionForRoleFunc != nil:
+		return f.CheckPermissionForRoleFunc(ctx, resourceID, "", privilege)
+	default:
+		return false, notStubbed("CheckPermission")
+	}
+}
+
+func (f *ResourceBrowser) CheckPermissionForRole(resourceID string, roleID string, privilege string) (bool, error) {
+	return f.CheckPermissionForRoleCtx(context.Background(), resourceID, roleID, privilege)
+}
+
+func (f *ResourceBrowser) CheckPermissionForRoleCtx(ctx context.Context, resourceID string, roleID string, privilege string) (bool, error) {
+	if f.CheckPermissionForRoleFunc == nil {
+		return false, notStubbed("CheckPermissionForRole")
+	}
+	return f.CheckPermissionForRoleFunc(ctx, resourceID, roleID, privilege)
+}
+
+func (f *ResourceBrowser) ResourceExists(resourceID string) (bool, error) {
+	return f.ResourceExistsCtx(context.Background(), resourceID)
+}
+
+func (f *ResourceBrowser) ResourceExistsCtx(ctx context.Context, resourceID string) (bool, error) {
+	switch {
+	case f.ResourceExistsFunc != nil:
+		return f.ResourceExistsFunc(ctx, resourceID)
+	case f.ResourceFunc != nil:
+		resource, err := f.ResourceFunc(ctx, resourceID)
+		return resource != nil, err
+	default:
+		return false, notStubbed("ResourceExists")
+	}
+}
+
+func (f *ResourceBrowser) Resource(resourceID string) (map[string]interface{}, error) {
+	return f.ResourceCtx(context.Background(), resourceID)
+}
+
+func (f *ResourceBrowser) ResourceCtx(ctx context.Context, resourceID string) (map[string]interface{}, error) {
+	if f.ResourceFunc == nil {
+		return nil, notStubbed("Resource")
+	}
+	return f.ResourceFunc(ctx, resourceID)
+}
+
+func (f *ResourceBrowser) Resources(filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+	return f.ResourcesCtx(context.Background(), filter)
+}
+
+func (f *ResourceBrowser) ResourcesCtx(ctx context.Context, filter *conjurapi.ResourceFilter) ([]map[string]interface{}, error) {
+	if f.ResourcesFunc == nil {
+		return nil, notStubbed("Resources")
+	}
+	return f.ResourcesFunc(ctx, filter)
+}
+
+func (f *ResourceBrowser) ResourceIDs(filter *conjurapi.ResourceFilter) ([]string, error) {
+	return f.ResourceIDsCtx(context.Background(), filter)
+}
+
+func (f *ResourceBrowser) ResourceIDsCtx(ctx context.Context, filter *conjurapi.ResourceFilter) ([]string, error) {
+	if f.ResourceIDsFunc == nil {
+		return nil, notStubbed("ResourceIDs")
+	}
+	return f.ResourceIDsFunc(ctx, filter)
+}
+
+func (f *ResourceBrowser) PermittedRoles(resourceID, privilege string) ([]string, error) {
+	return f.PermittedRolesCtx(context.Background(), resourceID, privilege)
+}
+
+func (f *ResourceBrowser) PermittedRolesCtx(ctx context.Context, resourceID, privilege string) ([]string, error) {
+	if f.PermittedRolesFunc == nil {
+		return nil, notStubbed("PermittedRoles")
+	}
+	return f.PermittedRolesFunc(ctx, resourceID, privilege)
+}
+
+// RoleBrowser is a fake conjurapi.RoleBrowser. RoleExists is answered by
+// RoleFunc when RoleExistsFunc isn't set.
+type RoleBrowser struct {
+	RoleExistsFunc         func(ctx context.Context, roleID string) (bool, error)
+	RoleFunc               func(ctx context.Context, roleID string) (map[string]interface{}, error)
+	RoleMembersFunc        func(ctx context.Context, roleID string) ([]map[string]interface{}, error)
+	RoleMembershipsFunc    func(ctx context.Context, roleID string) ([]map[string]interface{}, error)
+	RoleMembershipsAllFunc func(ctx context.Context, roleID string) ([]string, error)
+}
+
+var _ conjurapi.RoleBrowser = (*RoleBrowser)(nil)
+
+func (f *RoleBrowser) RoleExists(roleID string) (bool, error) {
+	return f.RoleExistsCtx(context.Background(), roleID)
+}
+
+func (f *RoleBrowser) RoleExistsCtx(ctx context.Context, roleID string) (bool, error) {
+	switch {
+	case f.RoleExistsFunc != nil:
+		return f.RoleExistsFunc(ctx, roleID)
+	case f.RoleFunc != nil:
+		role, err := f.RoleFunc(ctx, roleID)
+		return role != nil, err
+	default:
+		return false, notStubbed("RoleExists")
+	}
+}
+
+func (f *RoleBrowser) Role(roleID string) (map[string]interface{}, error) {
+	return f.RoleCtx(context.Background(), roleID)
+}
+
+func (f *RoleBrowser) RoleCtx(ctx context.Context, roleID string) (map[string]interface{}, error) {
+	if f.RoleFunc == nil {
+		return nil, notStubbed("Role")
+	}
+	return f.RoleFunc(ctx, roleID)
+}
+
+func (f *RoleBrowser) RoleMembers(roleID string) ([]map[string]interface{}, error) {
+	return f.RoleMembersCtx(context.Background(), roleID)
+}
+
+func (f *RoleBrowser) RoleMembersCtx(ctx context.Context, roleID string) ([]map[string]interface{}, error) {
+	if f.RoleMembersFunc == nil {
+		return nil, notStubbed("RoleMembers")
+	}
+	return f.RoleMembersFunc(ctx, roleID)
+}
+
+func (f *RoleBrowser) RoleMemberships(roleID string) ([]map[string]interface{}, error) {
+	return f.RoleMembershipsCtx(context.Background(), roleID)
+}
+
+func (f *RoleBrowser) RoleMembershipsCtx(ctx context.Context, roleID string) ([]map[string]interface{}, error) {
+	if f.RoleMembershipsFunc == nil {
+		return nil, notStubbed("RoleMemberships")
+	}
+	return f.RoleMembershipsFunc(ctx, roleID)
+}
+
+func (f *RoleBrowser) RoleMembershipsAll(roleID string) ([]string, error) {
+	return f.RoleMembershipsAllCtx(context.Background(), roleID)
+}
+
+func (f *RoleBrowser) RoleMembershipsAllCtx(ctx context.Context, roleID string) ([]string, error) {
+	if f.RoleMembershipsAllFunc == nil {
+		return nil, notStubbed("RoleMembershipsAll")
+	}
+	return f.RoleMembershipsAllFunc(ctx, roleID)
+}
@@ -0,0 +1,142 @@
+package conjurfake
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// SecretsReader is a fake conjurapi.SecretsReader and
+// conjurapi.PartialSecretsReader. The Reader methods return the values of
+// RetrieveSecretFunc and RetrieveSecretWithVersionFunc, and the Safe methods
+// those of RetrieveBatchSecretsFunc and RetrieveBatchSecretsPartialFunc unless
+// RetrieveBatchSecretsSafeFunc and RetrieveBatchSecretsPartialSafeFunc are
+// set.
+type SecretsReader struct {
+	RetrieveSecretFunc                  func(ctx context.Context, variableID string) ([]byte, error)
+	RetrieveSecretWithVersionFunc       func(ctx context.Context, variableID string, version int) ([]byte, error)
+	RetrieveBatchSecretsFunc            func(ctx context.Context, variableIDs []string) (map[string][]byte, error)
+	RetrieveBatchSecretsSafeFunc        func(ctx context.Context, variableIDs []string) (map[string][]byte, error)
+	RetrieveBatchSecretsPartialFunc     func(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error)
+	RetrieveBatchSecretsPartialSafeFunc func(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error)
+}
+
+var (
+	_ conjurapi.SecretsReader        = (*SecretsReader)(nil)
+	_ conjurapi.PartialSecretsReader = (*SecretsReader)(nil)
+)
+
+func (f *SecretsReader) RetrieveSecret(variableID string) ([]byte, error) {
+	return f.RetrieveSecretCtx(context.Background(), variableID)
+}
+
+func (f *SecretsReader) RetrieveSecretCtx(ctx context.Context, variableID string) ([]byte, error) {
+	if f.RetrieveSecretFunc == nil {
+		return nil, notStubbed("RetrieveSecret")
+	}
+	return f.RetrieveSecretFunc(ctx, variableID)
+}
+
+func (f *SecretsReader) RetrieveSecretReader(variableID string) (io.ReadCloser, error) {
+	return f.RetrieveSecretReaderCtx(context.Background(), variableID)
+}
+
+func (f *SecretsReader) RetrieveSecretReaderCtx(ctx context.Context, variableID string) (io.ReadCloser, error) {
+	return readCloser(f.RetrieveSecretCtx(ctx, variableID))
+}
+
+func (f *SecretsReader) RetrieveSecretWithVersion(variableID string, version int) ([]byte, error) {
+	return f.RetrieveSecretWithVersionCtx(context.Background(), variableID, version)
+}
+
+func (f *SecretsReader) RetrieveSecretWithVersionCtx(ctx context.Context, variableID string, version int) ([]byte, error) {
+	if f.RetrieveSecretWithVersionFunc == nil {
+		return nil, notStubbed("RetrieveSecretWithVersion")
+	}
+	return f.RetrieveSecretWithVersionFunc(ctx, variableID, version)
+}
+
+func (f *SecretsReader) RetrieveSecretWithVersionReader(variableID string, version int) (io.ReadCloser, error) {
+	return f.RetrieveSecretWithVersionReaderCtx(context.Background(), variableID, version)
+}
+
+func (f *SecretsReader) RetrieveSecretWithVersionReaderCtx(ctx context.Context, variableID string, version int) (io.ReadCloser, error) {
+	return readCloser(f.RetrieveSecretWithVersionCtx(ctx, variableID, version))
+}
+
+func (f *SecretsReader) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error) {
+	return f.RetrieveBatchSecretsCtx(context.Background(), variableIDs)
+}
+
+func (f *SecretsReader) RetrieveBatchSecretsCtx(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+	if f.RetrieveBatchSecretsFunc == nil {
+		return nil, notStubbed("RetrieveBatchSecrets")
+	}
+	return f.RetrieveBatchSecretsFunc(ctx, variableIDs)
+}
+
+func (f *SecretsReader) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error) {
+	return f.RetrieveBatchSecretsSafeCtx(context.Background(), variableIDs)
+}
+
+func (f *SecretsReader) RetrieveBatchSecretsSafeCtx(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+	if f.RetrieveBatchSecretsSafeFunc == nil {
+		if f.RetrieveBatchSecretsFunc == nil {
+			return nil, notStubbed("RetrieveBatchSecretsSafe")
+		}
+		return f.RetrieveBatchSecretsFunc(ctx, variableIDs)
+	}
+	return f.RetrieveBatchSecretsSafeFunc(ctx, variableIDs)
+}
+
+func (f *SecretsReader) RetrieveBatchSecretsPartial(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return f.RetrieveBatchSecretsPartialCtx(context.Background(), variableIDs)
+}
+
+func (f *SecretsReader) RetrieveBatchSecretsPartialCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+	if f.RetrieveBatchSecretsPartialFunc == nil {
+		return nil, nil, notStubbed("RetrieveBatchSecretsPartial")
+	}
+	return f.RetrieveBatchSecretsPartialFunc(ctx, variableIDs)
+}
+
+func (f *SecretsReader) RetrieveBatchSecretsPartialSafe(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return f.RetrieveBatchSecretsPartialSafeCtx(context.Background(), variableIDs)
+}
+
+func (f *SecretsReader) RetrieveBatchSecretsPartialSafeCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+	if f.RetrieveBatchSecretsPartialSafeFunc == nil {
+		if f.RetrieveBatchSecretsPartialFunc == nil {
+			return nil, nil, notStubbed("RetrieveBatchSecretsPartialSafe")
+		}
+		return f.RetrieveBatchSecretsPartialFunc(ctx, variableIDs)
+	}
+	return f.RetrieveBatchSecretsPartialSafeFunc(ctx, variableIDs)
+}
+
+func readCloser(value []byte, err error) (io.ReadCloser, error) {
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(value)), nil
+}
+
+// SecretsWriter is a fake conjurapi.SecretsWriter.
+type SecretsWriter struct {
+	AddSecretFunc func(ctx context.Context, variableID string, secretValue string) error
+}
+
+var _ conjurapi.SecretsWriter = (*SecretsWriter)(nil)
+
+func (f *SecretsWriter) AddSecret(variableID string, secretValue string) error {
+	return f.AddSecretCtx(context.Background(), variableID, secretValue)
+}
+
+func (f *SecretsWriter) AddSecretCtx(ctx context.Context, variableID string, secretValue string) error {
+	if f.AddSecretFunc == nil {
+		return notStubbed("AddSecret")
+	}
+	return f.AddSecretFunc(ctx, variableID, secretValue)
+}
@@ -0,0 +1,182 @@
+package conjurtest
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// serveAuthn serves the API key authenticator, with path relative to /authn.
+func (s *Server) serveAuthn(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) < 2 || path[0] != s.store.account {
+		writeNotFound(w, r)
+		return
+	}
+
+	switch {
+	case len(path) == 3 && path[2] == "authenticate" && r.Method == http.MethodPost:
+		login, _ := url.QueryUnescape(path[1])
+		role := s.store.loginRole(login)
+		apiKey, _ := io.ReadAll(r.Body)
+		if res, ok := s.store.resources[role]; !ok || res.apiKey == "" || res.apiKey != string(apiKey) {
+			writeUnauthorized(w)
+			return
+		}
+		s.writeToken(w, r, role)
+
+	case len(path) == 2 && path[1] == "login" && r.Method == http.MethodGet:
+		res, ok := s.basicAuth(r)
+		if !ok {
+			writeUnauthorized(w)
+			return
+		}
+		writeText(w, http.StatusOK, []byte(res.apiKey))
+
+	case len(path) == 2 && path[1] == "api_key" && r.Method == http.MethodPut:
+		s.serveRotateAPIKey(w, r)
+
+	case len(path) == 2 && path[1] == "password" && r.Method == http.MethodPut:
+		res, ok := s.basicAuth(r)
+		if !ok {
+			writeUnauthorized(w)
+			return
+		}
+		if kind, _ := parseID(res.id); kind != "user" {
+			writeForbidden(w)
+			return
+		}
+		password, _ := io.ReadAll(r.Body)
+		if len(password) == 0 {
+			writeError(w, http.StatusUnprocessableEntity, "validation_failed", "Password can't be blank")
+			return
+		}
+		res.password = string(password)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// serveRotateAPIKey rotates the API key of the role given with the role
+// parameter, or of the role which authenticates with Basic auth.
+func (s *Server) serveRotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var res *resource
+	if roleID := r.URL.Query().Get("role"); roleID != "" {
+		token, ok := s.authenticate(r)
+		if !ok {
+			writeUnauthorized(w)
+			return
+		}
+		target, exists := s.store.resources[s.store.qualify(roleID)]
+		if !exists || target.apiKey == "" || !s.store.visible(token.role, target) {
+			s.writeRecordNotFound(w, s.store.qualify(roleID))
+			return
+		}
+		if token.role != target.id && !s.store.permitted(token.role, target, "update") {
+			writeForbidden(w)
+			return
+		}
+		res = target
+	} else {
+		var ok bool
+		if res, ok = s.basicAuth(r); !ok {
+			writeUnauthorized(w)
+			return
+		}
+	}
+
+	res.apiKey = newSecretString()
+	writeText(w, http.StatusOK, []byte(res.apiKey))
+}
+
+// basicAuth returns the user or host which authenticates with Basic auth,
+// with its API key or, for users, its password.
+func (s *Server) basicAuth(r *http.Request) (*resource, bool) {
+	login, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, false
+	}
+	res, exists := s.store.resources[s.store.loginRole(login)]
+	if !exists || password == "" || (password != res.apiKey && password != res.password) {
+		return nil, false
+	}
+	return res, true
+}
+
+// serveAuthnJWT serves the JWT authenticator, with path relative to
+// /authn-jwt: <service-id>/<account>[/<host-id>]/authenticate.
+func (s *Server) serveAuthnJWT(w http.ResponseWriter, r *http.Request, path []string) {
+	if (len(path) != 3 && len(path) != 4) || path[1] != s.store.account || path[len(path)-1] != "authenticate" || r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	role, ok := s.jwts[credential{path[0], r.PostFormValue("jwt")}]
+	if len(path) == 4 {
+		hostID, _ := url.PathUnescape(path[2])
+		ok = ok && role == s.store.loginRole(hostID)
+	}
+	if !ok {
+		writeUnauthorized(w)
+		return
+	}
+	s.writeToken(w, r, role)
+}
+
+// serveAuthnOIDC serves the OIDC authenticator, with path relative to
+// /authn-oidc.
+func (s *Server) serveAuthnOIDC(w http.ResponseWriter, r *http.Request, path []string) {
+	switch {
+	case len(path) == 2 && path[0] == s.store.account && path[1] == "providers" && r.Method == http.MethodGet:
+		s.serveOIDCProviders(w)
+
+	case len(path) == 3 && path[1] == s.store.account && path[2] == "authenticate":
+		var role string
+		var ok bool
+		if r.Method == http.MethodGet {
+			role, ok = s.oidcCodes[credential{path[0], r.URL.Query().Get("code")}]
+		} else if r.Method == http.MethodPost {
+			role, ok = s.oidcTokens[credential{path[0], r.PostFormValue("id_token")}]
+		}
+		if !ok {
+			writeUnauthorized(w)
+			return
+		}
+		s.writeToken(w, r, role)
+
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+func (s *Server) serveOIDCProviders(w http.ResponseWriter) {
+	services := map[string]bool{}
+	for c := range s.oidcCodes {
+		services[c.serviceID] = true
+	}
+	for c := range s.oidcTokens {
+		services[c.serviceID] = true
+	}
+
+	providers := []map[string]string{}
+	for serviceID := range services {
+		providers = append(providers, map[string]string{
+			"service_id":    serviceID,
+			"type":          "oidc",
+			"name":          serviceID,
+			"nonce":         newSecretString(),
+			"code_verifier": newSecretString(),
+			"redirect_uri":  "",
+		})
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		return providers[i]["service_id"] < providers[j]["service_id"]
+	})
+	writeJSON(w, http.StatusOK, providers)
+}
+
+func writeUnauthorized(w http.ResponseWriter) {
+	writeError(w, http.StatusUnauthorized, "unauthorized", "Authentication failed")
+}
@@ -0,0 +1,140 @@
+package conjurtest
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// serveHostFactoryTokens creates host factory tokens with POST, and revokes
+// them with DELETE, with path relative to /host_factory_tokens.
+func (s *Server) serveHostFactoryTokens(w http.ResponseWriter, r *http.Request, role string, path []string) {
+	switch {
+	case len(path) == 0 && r.Method == http.MethodPost:
+		s.serveCreateHostFactoryTokens(w, r, role)
+
+	case len(path) == 1 && r.Method == http.MethodDelete:
+		token, exists := s.hostFactoryTokens[path[0]]
+		if !exists {
+			writeError(w, http.StatusNotFound, "not_found", "Host factory token not found")
+			return
+		}
+		if !s.store.permitted(role, s.store.resources[token.hostFactory], "update") {
+			writeForbidden(w)
+			return
+		}
+		delete(s.hostFactoryTokens, path[0])
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+func (s *Server) serveCreateHostFactoryTokens(w http.ResponseWriter, r *http.Request, role string) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+
+	id := s.store.qualify(r.PostForm.Get("host_factory"))
+	hostFactory, exists := s.store.resources[id]
+	if kind, _ := parseID(id); !exists || kind != "host_factory" || !s.store.visible(role, hostFactory) {
+		s.writeRecordNotFound(w, id)
+		return
+	}
+	if !s.store.permitted(role, hostFactory, "execute") {
+		writeForbidden(w)
+		return
+	}
+
+	expiration, err := time.Parse(time.RFC3339, r.PostForm.Get("expiration"))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", fmt.Sprintf("Invalid expiration %q", r.PostForm.Get("expiration")))
+		return
+	}
+	count := 1
+	if c := r.PostForm.Get("count"); c != "" {
+		if count, err = strconv.Atoi(c); err != nil || count < 1 {
+			writeError(w, http.StatusUnprocessableEntity, "validation_failed", fmt.Sprintf("Invalid count %q", c))
+			return
+		}
+	}
+	cidr := r.PostForm["cidr[]"]
+	if cidr == nil {
+		cidr = []string{}
+	}
+
+	tokens := []map[string]interface{}{}
+	for i := 0; i < count; i++ {
+		token := newSecretString()
+		s.hostFactoryTokens[token] = hostFactoryToken{hostFactory: id, expiration: expiration, cidr: cidr}
+		tokens = append(tokens, map[string]interface{}{
+			"expiration": expiration.UTC().Format(time.RFC3339),
+			"cidr":       cidr,
+			"token":      token,
+		})
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+var annotationParam = regexp.MustCompile(`^annotations\[(.+)\]$`)
+
+// serveCreateHost creates a host with a host factory token, in the policy of
+// the host factory and as a member of its layers. A host which exists gets a
+// new API key.
+func (s *Server) serveCreateHost(w http.ResponseWriter, r *http.Request, path []string) {
+	if len(path) != 1 || path[0] != "hosts" || r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	encoded, _ := tokenFromHeader(r)
+	token, exists := s.hostFactoryTokens[encoded]
+	if !exists || time.Now().After(token.expiration) {
+		writeUnauthorized(w)
+		return
+	}
+	hostFactory, exists := s.store.resources[token.hostFactory]
+	if !exists {
+		writeUnauthorized(w)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	name := r.PostForm.Get("id")
+	if name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "id can't be blank")
+		return
+	}
+
+	_, policy := parseID(hostFactory.policy)
+	id := s.store.fullID("host", resolveID(policy, "host", name))
+	host, exists := s.store.resources[id]
+	if !exists {
+		host = &resource{id: id, owner: hostFactory.owner, policy: hostFactory.policy, createdAt: time.Now(), annotations: map[string]string{}}
+		s.store.resources[id] = host
+		s.store.addGrant(grant{role: id, member: host.owner, adminOption: true, ownership: true, policy: host.policy})
+	}
+	host.apiKey = newSecretString()
+	for _, layer := range hostFactory.layers {
+		s.store.addGrant(grant{role: layer, member: id, policy: host.policy})
+	}
+	for param, values := range r.PostForm {
+		if match := annotationParam.FindStringSubmatch(param); match != nil {
+			host.annotations[match[1]] = values[0]
+		}
+	}
+
+	j := newResourceJSON(host)
+	writeJSON(w, http.StatusCreated, struct {
+		resourceJSON
+		Permissions []string `json:"permissions"`
+		APIKey      string   `json:"api_key"`
+	}{j, []string{}, host.apiKey})
+}
@@ -0,0 +1,157 @@
+package conjurtest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithTokenTTL sets how long the access tokens issued by the server are
+// valid, 8 minutes by default.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(s *Server) {
+		s.tokenTTL = ttl
+	}
+}
+
+// WithLatency delays every response by latency. Requests are delayed
+// concurrently, so they overlap as they would with a real server.
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// WithFault makes the server fail some requests, as an overloaded server or
+// a proxy in front of it would. See AddFault.
+func WithFault(fault Fault) Option {
+	return func(s *Server) {
+		s.faults = append(s.faults, &faultState{Fault: fault})
+	}
+}
+
+// WithMiddleware wraps the server's handler, e.g. to hold requests until a
+// test is ready for them to complete. The middleware sees the requests which
+// no fault fails, after they are recorded and delayed.
+func WithMiddleware(middleware func(next http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		s.middleware = middleware
+	}
+}
+
+// Fault describes requests which the server fails with an empty response.
+type Fault struct {
+	// Method is the method of the failed requests, or any method if empty.
+	Method string
+	// PathPrefix is the prefix of the paths of the failed requests, e.g.
+	// "/authn" or "/secrets", or any path if empty.
+	PathPrefix string
+	// Status is the status code of the responses.
+	Status int
+	// Skip is the number of matching requests which succeed before the
+	// first one fails.
+	Skip int
+	// Times is the number of matching requests which fail, or all of them
+	// if zero.
+	Times int
+}
+
+type faultState struct {
+	Fault
+	matched int
+}
+
+// fails reports whether the fault fails r, and counts r if it matches.
+func (f *faultState) fails(r *http.Request) bool {
+	if f.Method != "" && f.Method != r.Method || !strings.HasPrefix(r.URL.Path, f.PathPrefix) {
+		return false
+	}
+	f.matched++
+	if f.matched <= f.Skip {
+		return false
+	}
+	return f.Times == 0 || f.matched <= f.Skip+f.Times
+}
+
+// AddFault makes the server fail the requests described by fault, from now
+// on. When several faults match a request, the first one added applies.
+func (s *Server) AddFault(fault Fault) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults = append(s.faults, &faultState{Fault: fault})
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+// Requests returns the requests received by the server, in order.
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+// CountRequests returns the number of requests received by the server whose
+// paths start with pathPrefix, e.g. "/authn" to count authentications.
+func (s *Server) CountRequests(pathPrefix string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	count := 0
+	for _, r := range s.requests {
+		if strings.HasPrefix(r.URL.Path, pathPrefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// MaxConcurrentRequests returns the largest number of requests the server
+// has served at the same time.
+func (s *Server) MaxConcurrentRequests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.maxInFlight
+}
+
+// record records r, and returns the fault which fails it, if any.
+func (s *Server) record(r *http.Request) *faultState {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	requestURL := *r.URL
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		URL:    &requestURL,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	for _, fault := range s.faults {
+		if fault.fails(r) {
+			return fault
+		}
+	}
+	return nil
+}
+
+func (s *Server) done() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.inFlight--
+}
@@ -0,0 +1,122 @@
+package conjurtest
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// servePolicies serves policies, with path relative to /policies:
+// <account>/policy/<id>. GET fetches the policy, and POST, PUT and PATCH load
+// it, or validate it with the dryRun parameter.
+func (s *Server) servePolicies(w http.ResponseWriter, r *http.Request, role string, path []string) {
+	if len(path) < 3 || path[0] != s.store.account || path[1] != "policy" {
+		writeNotFound(w, r)
+		return
+	}
+
+	policyID := joinSegments(path[2:], queryUnescape)
+	id := s.store.fullID("policy", policyID)
+	res, exists := s.store.resources[id]
+	if !exists || !s.store.visible(role, res) {
+		s.writeRecordNotFound(w, id)
+		return
+	}
+
+	var privilege string
+	switch r.Method {
+	case http.MethodGet:
+		privilege = "read"
+	case http.MethodPost:
+		privilege = "create"
+	case http.MethodPut, http.MethodPatch:
+		privilege = "update"
+	default:
+		writeNotFound(w, r)
+		return
+	}
+	if !s.store.permitted(role, res, privilege) {
+		writeForbidden(w)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s.serveFetchPolicy(w, r, policyID)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return
+	}
+	records, parseErr := parsePolicy(body)
+
+	if r.URL.Query().Get("dryRun") == "true" {
+		var response *dryRunResponse
+		if parseErr == nil {
+			response, err = s.store.dryRun(policyID, r.Method, records)
+		} else {
+			err = parseErr
+		}
+		if err != nil {
+			response = newDryRunResponse()
+			response.Status = "Invalid YAML"
+			message := dryRunMessage{Message: err.Error()}
+			if perr, ok := err.(*policyError); ok {
+				message.Line, message.Column = perr.line, perr.column
+			}
+			response.Errors = append(response.Errors, message)
+			writeJSON(w, http.StatusUnprocessableEntity, response)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if parseErr != nil {
+		writePolicyError(w, parseErr)
+		return
+	}
+	// Load into a copy, so that a failed load changes nothing
+	loaded := s.store.clone()
+	result, err := loaded.loadPolicy(policyID, r.Method, records)
+	if err != nil {
+		writePolicyError(w, err)
+		return
+	}
+	s.store = loaded
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// serveFetchPolicy serves the effective policy, as YAML or, when the request
+// is for JSON, as JSON.
+func (s *Server) serveFetchPolicy(w http.ResponseWriter, r *http.Request, policyID string) {
+	query := r.URL.Query()
+	depth, _ := strconv.Atoi(query.Get("depth"))
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	records, err := s.store.renderPolicy(policyID, depth, limit)
+	if err != nil {
+		writePolicyError(w, err)
+		return
+	}
+	document := &yaml.Node{Kind: yaml.SequenceNode, Content: records}
+
+	if r.Header.Get("Content-Type") == "application/json" {
+		writeJSON(w, http.StatusOK, policyJSON(document))
+		return
+	}
+
+	var body []byte
+	if len(records) > 0 {
+		if body, err = yaml.Marshal(document); err != nil {
+			writePolicyError(w, err)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(body)
+}
@@ -0,0 +1,858 @@
+package conjurtest
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resourceKinds are the kinds of records which declare a resource.
+var resourceKinds = map[string]bool{
+	"user":         true,
+	"host":         true,
+	"group":        true,
+	"layer":        true,
+	"policy":       true,
+	"variable":     true,
+	"webservice":   true,
+	"host_factory": true,
+}
+
+// statementKinds are the kinds of records which change the relationships
+// between resources.
+var statementKinds = map[string]bool{
+	"grant":  true,
+	"revoke": true,
+	"permit": true,
+	"deny":   true,
+	"delete": true,
+}
+
+// record is a statement of a policy document.
+type record struct {
+	kind         string
+	id           string
+	owner        *ref
+	annotations  map[string]string
+	body         []*record
+	restrictedTo []string
+	layers       []ref
+	roles        []ref
+	members      []ref
+	privileges   []string
+	resources    []ref
+	target       *ref
+	line, column int
+}
+
+// ref is a reference to a resource in a policy document, e.g. !user alice.
+type ref struct {
+	kind string
+	id   string
+}
+
+// policyError is an error in a policy document, or with loading it.
+type policyError struct {
+	status       int
+	code         string
+	message      string
+	line, column int
+}
+
+func (e *policyError) Error() string {
+	return e.message
+}
+
+func invalidPolicy(node *yaml.Node, format string, args ...interface{}) *policyError {
+	err := &policyError{status: http.StatusUnprocessableEntity, code: "validation_failed", message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		err.line, err.column = node.Line, node.Column
+	}
+	return err
+}
+
+var yamlErrorLine = regexp.MustCompile(`line (\d+)`)
+
+// parsePolicy parses a policy document, a YAML sequence of records.
+func parsePolicy(text []byte) ([]*record, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(text, &document); err != nil {
+		perr := invalidPolicy(nil, "%s", err)
+		if match := yamlErrorLine.FindStringSubmatch(err.Error()); match != nil {
+			perr.line, _ = strconv.Atoi(match[1])
+		}
+		return nil, perr
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	root := document.Content[0]
+	if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+		return nil, nil
+	}
+	if root.Kind != yaml.SequenceNode {
+		return nil, invalidPolicy(root, "Policy must be a sequence of records")
+	}
+	return parseRecords(root)
+}
+
+func parseRecords(node *yaml.Node) ([]*record, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, invalidPolicy(node, "Expected a sequence of records")
+	}
+
+	records := make([]*record, 0, len(node.Content))
+	for _, item := range node.Content {
+		rec, err := parseRecord(item)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func tagKind(tag string) string {
+	if !strings.HasPrefix(tag, "!") || strings.HasPrefix(tag, "!!") {
+		return ""
+	}
+	return strings.ReplaceAll(strings.TrimPrefix(tag, "!"), "-", "_")
+}
+
+func parseRecord(node *yaml.Node) (*record, error) {
+	kind := tagKind(node.Tag)
+	if !resourceKinds[kind] && !statementKinds[kind] {
+		return nil, invalidPolicy(node, "Unrecognized record type %q", node.Tag)
+	}
+	rec := &record{kind: kind, annotations: map[string]string{}, line: node.Line, column: node.Column}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !resourceKinds[kind] {
+			return nil, invalidPolicy(node, "%s requires attributes", node.Tag)
+		}
+		rec.id = node.Value
+	case yaml.MappingNode:
+		if err := parseAttributes(rec, node); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, invalidPolicy(node, "Invalid %s record", node.Tag)
+	}
+
+	switch {
+	case resourceKinds[kind] && rec.id == "":
+		return nil, invalidPolicy(node, "%s requires an id", node.Tag)
+	case (kind == "grant" || kind == "revoke") && (len(rec.roles) != 1 || len(rec.members) == 0):
+		return nil, invalidPolicy(node, "%s requires a role and a member", node.Tag)
+	case (kind == "permit" || kind == "deny") && (len(rec.roles) == 0 || len(rec.privileges) == 0 || len(rec.resources) == 0):
+		return nil, invalidPolicy(node, "%s requires a role, privileges and a resource", node.Tag)
+	case kind == "delete" && rec.target == nil:
+		return nil, invalidPolicy(node, "!delete requires a record")
+	}
+	return rec, nil
+}
+
+func parseAttributes(rec *record, node *yaml.Node) error {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+
+		var err error
+		switch key {
+		case "id":
+			rec.id = value.Value
+		case "owner":
+			var owner []ref
+			if owner, err = parseRefs(value); err == nil && len(owner) != 1 {
+				err = invalidPolicy(value, "owner must be a single role")
+			}
+			if err == nil {
+				rec.owner = &owner[0]
+			}
+		case "annotations":
+			err = parseAnnotations(rec, value)
+		case "body":
+			rec.body, err = parseRecords(value)
+		case "restricted_to":
+			rec.restrictedTo, err = parseScalars(value)
+		case "layers":
+			rec.layers, err = parseRefs(value)
+		case "role":
+			rec.roles, err = parseRefs(value)
+		case "member", "members":
+			rec.members, err = parseRefs(value)
+		case "privilege", "privileges":
+			rec.privileges, err = parseScalars(value)
+		case "resource", "resources":
+			rec.resources, err = parseRefs(value)
+		case "record":
+			var target []ref
+			if target, err = parseRefs(value); err == nil && len(target) != 1 {
+				err = invalidPolicy(value, "record must be a single record")
+			}
+			if err == nil {
+				rec.target = &target[0]
+			}
+		case "kind", "mime_type":
+			rec.annotations["conjur/"+key] = value.Value
+		default:
+			err = invalidPolicy(node.Content[i], "Unrecognized attribute %q of %s", key, node.Tag)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseAnnotations(rec *record, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return invalidPolicy(node, "annotations must be a mapping")
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		rec.annotations[node.Content[i].Value] = node.Content[i+1].Value
+	}
+	return nil
+}
+
+func parseScalars(node *yaml.Node) ([]string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}, nil
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind != yaml.ScalarNode {
+				return nil, invalidPolicy(item, "Expected a string")
+			}
+			values = append(values, item.Value)
+		}
+		return values, nil
+	default:
+		return nil, invalidPolicy(node, "Expected a string or a sequence of strings")
+	}
+}
+
+func parseRefs(node *yaml.Node) ([]ref, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		kind := tagKind(node.Tag)
+		if !resourceKinds[kind] {
+			return nil, invalidPolicy(node, "Expected a reference such as !user alice, got %q", node.Value)
+		}
+		return []ref{{kind: kind, id: node.Value}}, nil
+	case yaml.SequenceNode:
+		var refs []ref
+		for _, item := range node.Content {
+			itemRefs, err := parseRefs(item)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, itemRefs...)
+		}
+		return refs, nil
+	default:
+		return nil, invalidPolicy(node, "Expected a reference or a sequence of references")
+	}
+}
+
+// resolveID returns the identifier of a record declared or referenced in
+// policy, given by its identifier. IDs starting with "/" are absolute, and
+// users are named after their policy, e.g. alice@myapp-prod.
+func resolveID(policy, kind, id string) string {
+	if absolute, ok := strings.CutPrefix(id, "/"); ok {
+		return absolute
+	}
+	if kind == "user" {
+		if policy == "root" {
+			return id
+		}
+		return id + "@" + strings.ReplaceAll(policy, "/", "-")
+	}
+	if policy == "root" {
+		return id
+	}
+	return policy + "/" + id
+}
+
+// relativeID is the reverse of resolveID for records declared in policy.
+func relativeID(policy, kind, identifier string) string {
+	if kind == "user" {
+		if policy == "root" {
+			return identifier
+		}
+		return strings.TrimSuffix(identifier, "@"+strings.ReplaceAll(policy, "/", "-"))
+	}
+	if policy == "root" {
+		return identifier
+	}
+	return strings.TrimPrefix(identifier, policy+"/")
+}
+
+// inPolicy reports whether policy, an identifier, is policyID or nested in
+// it.
+func inPolicy(policy, policyID string) bool {
+	return policyID == "root" || policy == policyID || strings.HasPrefix(policy, policyID+"/")
+}
+
+// loadResult is the outcome of loading a policy.
+type loadResult struct {
+	CreatedRoles map[string]createdRole `json:"created_roles"`
+	Version      int                    `json:"version"`
+}
+
+type createdRole struct {
+	ID     string `json:"id"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+// policyLoader applies the records of a policy document to a store.
+type policyLoader struct {
+	store    *store
+	method   string
+	created  map[string]createdRole
+	declared map[string]bool
+}
+
+// loadPolicy loads records into the policy with the given identifier, with
+// the semantics of the HTTP method: POST adds records, PATCH also deletes
+// records and relationships, and PUT replaces the policy.
+func (s *store) loadPolicy(policyID string, method string, records []*record) (*loadResult, error) {
+	l := &policyLoader{
+		store:    s,
+		method:   method,
+		created:  map[string]createdRole{},
+		declared: map[string]bool{},
+	}
+
+	if method == http.MethodPut {
+		l.forgetStatements(policyID)
+	}
+	if err := l.apply(policyID, records); err != nil {
+		return nil, err
+	}
+	if method == http.MethodPut {
+		l.deleteUndeclared(policyID)
+	}
+
+	fullPolicyID := s.fullID("policy", policyID)
+	s.policyVersions[fullPolicyID]++
+	return &loadResult{CreatedRoles: l.created, Version: s.policyVersions[fullPolicyID]}, nil
+}
+
+// forgetStatements drops the grants and permissions made by policyID and the
+// policies nested in it, so that those of the new policy replace them.
+func (l *policyLoader) forgetStatements(policyID string) {
+	fromPolicy := func(policy string) bool {
+		_, identifier := parseID(policy)
+		return policy != "" && inPolicy(identifier, policyID)
+	}
+
+	grants := l.store.grants[:0]
+	for _, g := range l.store.grants {
+		if g.ownership || !fromPolicy(g.policy) {
+			grants = append(grants, g)
+		}
+	}
+	l.store.grants = grants
+
+	for _, r := range l.store.resources {
+		permissions := r.permissions[:0]
+		for _, p := range r.permissions {
+			if !fromPolicy(p.policy) {
+				permissions = append(permissions, p)
+			}
+		}
+		r.permissions = permissions
+	}
+}
+
+// deleteUndeclared deletes the records of policyID and the policies nested in
+// it which the new policy doesn't declare.
+func (l *policyLoader) deleteUndeclared(policyID string) {
+	for id, r := range l.store.resources {
+		_, policy := parseID(r.policy)
+		if r.policy != "" && inPolicy(policy, policyID) && !l.declared[id] {
+			l.store.delete(id)
+		}
+	}
+}
+
+func (l *policyLoader) notFound(rec *record, kind, identifier string) *policyError {
+	return &policyError{
+		status:  http.StatusNotFound,
+		code:    "not_found",
+		message: fmt.Sprintf("%s '%s' not found in account '%s'", kindName(kind), identifier, l.store.account),
+		line:    rec.line,
+		column:  rec.column,
+	}
+}
+
+// resolve returns the fully-qualified ID of a reference made in policy,
+// which must exist.
+func (l *policyLoader) resolve(rec *record, policy string, r ref) (string, error) {
+	identifier := resolveID(policy, r.kind, r.id)
+	id := l.store.fullID(r.kind, identifier)
+	if _, exists := l.store.resources[id]; !exists {
+		return "", l.notFound(rec, r.kind, identifier)
+	}
+	return id, nil
+}
+
+func (l *policyLoader) resolveAll(rec *record, policy string, refs []ref) ([]string, error) {
+	ids := make([]string, 0, len(refs))
+	for _, r := range refs {
+		id, err := l.resolve(rec, policy, r)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (l *policyLoader) apply(policy string, records []*record) error {
+	for _, rec := range records {
+		var err error
+		if resourceKinds[rec.kind] {
+			err = l.declare(policy, rec)
+		} else {
+			err = l.applyStatement(policy, rec)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declare creates or updates the resource declared by rec in policy.
+func (l *policyLoader) declare(policy string, rec *record) error {
+	s := l.store
+	policyResource := s.fullID("policy", policy)
+	identifier := resolveID(policy, rec.kind, rec.id)
+	id := s.fullID(rec.kind, identifier)
+
+	owner := policyResource
+	if rec.owner != nil {
+		var err error
+		if owner, err = l.resolve(rec, policy, *rec.owner); err != nil {
+			return err
+		}
+		if !s.isRole(owner) {
+			return invalidPolicy(nil, "Owner %s is not a role", owner)
+		}
+	}
+
+	layers, err := l.resolveAll(rec, policy, rec.layers)
+	if err != nil {
+		return err
+	}
+
+	r, exists := s.resources[id]
+	if !exists {
+		r = &resource{id: id, owner: owner, policy: policyResource, createdAt: time.Now(), annotations: map[string]string{}}
+		s.resources[id] = r
+		if roleKinds[rec.kind] {
+			s.addGrant(grant{role: id, member: owner, adminOption: true, ownership: true, policy: policyResource})
+		}
+		if rec.kind == "user" || rec.kind == "host" {
+			r.apiKey = newSecretString()
+			l.created[id] = createdRole{ID: id, APIKey: r.apiKey}
+		}
+	} else if rec.owner != nil && r.owner != owner {
+		if roleKinds[rec.kind] {
+			s.revokeGrant(id, r.owner)
+			s.addGrant(grant{role: id, member: owner, adminOption: true, ownership: true, policy: policyResource})
+		}
+		r.owner = owner
+	}
+	l.declared[id] = true
+
+	if l.method == http.MethodPut {
+		r.annotations = map[string]string{}
+	}
+	for name, value := range rec.annotations {
+		r.annotations[name] = value
+	}
+	if rec.restrictedTo != nil {
+		r.restrictedTo = rec.restrictedTo
+	}
+	if rec.layers != nil {
+		r.layers = layers
+	}
+
+	if rec.kind == "policy" {
+		return l.apply(identifier, rec.body)
+	}
+	return nil
+}
+
+func (l *policyLoader) applyStatement(policy string, rec *record) error {
+	s := l.store
+	policyResource := s.fullID("policy", policy)
+
+	if (rec.kind == "revoke" || rec.kind == "deny" || rec.kind == "delete") && l.method != http.MethodPatch {
+		return invalidPolicy(nil, "!%s is only allowed when updating a policy with PATCH", rec.kind)
+	}
+
+	switch rec.kind {
+	case "delete":
+		id, err := l.resolve(rec, policy, *rec.target)
+		if err != nil {
+			return err
+		}
+		kind, identifier := parseID(id)
+		s.delete(id)
+		if kind == "policy" {
+			for nestedID, r := range s.resources {
+				if _, nested := parseID(r.policy); r.policy != "" && inPolicy(nested, identifier) {
+					s.delete(nestedID)
+				}
+			}
+		}
+		return nil
+	case "grant", "revoke":
+		role, err := l.resolve(rec, policy, rec.roles[0])
+		if err != nil {
+			return err
+		}
+		members, err := l.resolveAll(rec, policy, rec.members)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			if !s.isRole(role) || !s.isRole(member) {
+				return invalidPolicy(nil, "Only roles can be granted, and to roles")
+			}
+			if rec.kind == "grant" {
+				s.addGrant(grant{role: role, member: member, policy: policyResource})
+			} else {
+				s.revokeGrant(role, member)
+			}
+		}
+		return nil
+	default:
+		roles, err := l.resolveAll(rec, policy, rec.roles)
+		if err != nil {
+			return err
+		}
+		resources, err := l.resolveAll(rec, policy, rec.resources)
+		if err != nil {
+			return err
+		}
+		for _, resourceID := range resources {
+			for _, role := range roles {
+				if !s.isRole(role) {
+					return invalidPolicy(nil, "Permissions can only be given to roles, not %s", role)
+				}
+				for _, privilege := range rec.privileges {
+					if rec.kind == "permit" {
+						s.resources[resourceID].addPermission(permission{privilege: privilege, role: role, policy: policyResource})
+					} else {
+						s.resources[resourceID].removePermission(privilege, role)
+					}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// dryRunItem describes a resource in the response to a dry run.
+type dryRunItem struct {
+	Identifier   string              `json:"identifier"`
+	ID           string              `json:"id"`
+	Type         string              `json:"type"`
+	Owner        string              `json:"owner"`
+	Policy       string              `json:"policy"`
+	Annotations  map[string]string   `json:"annotations"`
+	Permissions  map[string][]string `json:"permissions,omitempty"`
+	Members      []string            `json:"members,omitempty"`
+	Memberships  []string            `json:"memberships,omitempty"`
+	RestrictedTo []string            `json:"restricted_to,omitempty"`
+}
+
+type dryRunItems struct {
+	Items []dryRunItem `json:"items"`
+}
+
+type dryRunResponse struct {
+	Status  string      `json:"status"`
+	Created dryRunItems `json:"created"`
+	Updated struct {
+		Before dryRunItems `json:"before"`
+		After  dryRunItems `json:"after"`
+	} `json:"updated"`
+	Deleted dryRunItems     `json:"deleted"`
+	Errors  []dryRunMessage `json:"errors"`
+}
+
+type dryRunMessage struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func newDryRunResponse() *dryRunResponse {
+	response := &dryRunResponse{Status: "Valid YAML", Errors: []dryRunMessage{}}
+	response.Created.Items = []dryRunItem{}
+	response.Updated.Before.Items = []dryRunItem{}
+	response.Updated.After.Items = []dryRunItem{}
+	response.Deleted.Items = []dryRunItem{}
+	return response
+}
+
+func (s *store) dryRunItem(r *resource) dryRunItem {
+	kind, identifier := parseID(r.id)
+	item := dryRunItem{
+		Identifier:   r.id,
+		ID:           identifier,
+		Type:         kind,
+		Owner:        r.owner,
+		Policy:       r.policy,
+		Annotations:  r.annotations,
+		RestrictedTo: r.restrictedTo,
+	}
+	if len(r.permissions) > 0 {
+		item.Permissions = map[string][]string{}
+		for _, p := range r.permissions {
+			item.Permissions[p.privilege] = append(item.Permissions[p.privilege], p.role)
+		}
+	}
+	for _, g := range s.grants {
+		if g.role == r.id {
+			item.Members = append(item.Members, g.member)
+		}
+		if g.member == r.id {
+			item.Memberships = append(item.Memberships, g.role)
+		}
+	}
+	sort.Strings(item.Members)
+	sort.Strings(item.Memberships)
+	return item
+}
+
+// dryRun describes the changes which loading records into policyID would
+// make, without making them.
+func (s *store) dryRun(policyID string, method string, records []*record) (*dryRunResponse, error) {
+	after := s.clone()
+	if _, err := after.loadPolicy(policyID, method, records); err != nil {
+		return nil, err
+	}
+
+	response := newDryRunResponse()
+	for _, id := range sortedIDs(after.resources) {
+		before, existed := s.resources[id]
+		if !existed {
+			response.Created.Items = append(response.Created.Items, after.dryRunItem(after.resources[id]))
+			continue
+		}
+		beforeItem, afterItem := s.dryRunItem(before), after.dryRunItem(after.resources[id])
+		if !reflect.DeepEqual(beforeItem, afterItem) {
+			response.Updated.Before.Items = append(response.Updated.Before.Items, beforeItem)
+			response.Updated.After.Items = append(response.Updated.After.Items, afterItem)
+		}
+	}
+	for _, id := range sortedIDs(s.resources) {
+		if _, exists := after.resources[id]; !exists {
+			response.Deleted.Items = append(response.Deleted.Items, s.dryRunItem(s.resources[id]))
+		}
+	}
+	return response, nil
+}
+
+func sortedIDs(resources map[string]*resource) []string {
+	ids := make([]string, 0, len(resources))
+	for id := range resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// kindOrder is the order in which records are listed in a fetched policy.
+var kindOrder = []string{"user", "host", "group", "layer", "variable", "webservice", "host_factory", "policy"}
+
+// renderPolicy returns the effective policy of policyID as YAML records,
+// with nested policies down to depth levels, 0 meaning no limit. It fails
+// if there are more than limit records, 0 meaning no limit.
+func (s *store) renderPolicy(policyID string, depth int, limit int) ([]*yaml.Node, error) {
+	count := 0
+	records := s.renderRecords(policyID, depth, 1, &count)
+	if limit > 0 && count > limit {
+		return nil, &policyError{
+			status:  http.StatusUnprocessableEntity,
+			code:    "validation_failed",
+			message: fmt.Sprintf("Policy has %d records, more than the limit of %d", count, limit),
+		}
+	}
+	return records, nil
+}
+
+func (s *store) renderRecords(policyID string, depth int, level int, count *int) []*yaml.Node {
+	policyResource := s.fullID("policy", policyID)
+
+	var declared []*resource
+	for _, id := range sortedIDs(s.resources) {
+		if s.resources[id].policy == policyResource {
+			declared = append(declared, s.resources[id])
+		}
+	}
+	sort.SliceStable(declared, func(i, j int) bool {
+		kindI, _ := parseID(declared[i].id)
+		kindJ, _ := parseID(declared[j].id)
+		return kindRank(kindI) < kindRank(kindJ)
+	})
+
+	var records []*yaml.Node
+	for _, r := range declared {
+		*count++
+		kind, identifier := parseID(r.id)
+		tag := "!" + strings.ReplaceAll(kind, "_", "-")
+		id := relativeID(policyID, kind, identifier)
+
+		var body []*yaml.Node
+		if kind == "policy" && (depth == 0 || level < depth) {
+			body = s.renderRecords(identifier, depth, level+1, count)
+		}
+		if r.owner == policyResource && len(r.annotations) == 0 && len(r.restrictedTo) == 0 && len(r.layers) == 0 && body == nil {
+			records = append(records, &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: id})
+			continue
+		}
+
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: tag}
+		addField(node, "id", scalarNode(id))
+		if r.owner != policyResource {
+			addField(node, "owner", refNode(r.owner))
+		}
+		if len(r.annotations) > 0 {
+			annotations := &yaml.Node{Kind: yaml.MappingNode}
+			names := make([]string, 0, len(r.annotations))
+			for name := range r.annotations {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				addField(annotations, name, scalarNode(r.annotations[name]))
+			}
+			addField(node, "annotations", annotations)
+		}
+		if len(r.restrictedTo) > 0 {
+			addField(node, "restricted_to", sequenceNode(r.restrictedTo, scalarNode))
+		}
+		if len(r.layers) > 0 {
+			addField(node, "layers", sequenceNode(r.layers, refNode))
+		}
+		if body != nil {
+			addField(node, "body", &yaml.Node{Kind: yaml.SequenceNode, Content: body})
+		}
+		records = append(records, node)
+	}
+
+	for _, g := range s.grants {
+		if g.policy == policyResource && !g.ownership {
+			*count++
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!grant"}
+			addField(node, "role", refNode(g.role))
+			addField(node, "member", refNode(g.member))
+			records = append(records, node)
+		}
+	}
+
+	for _, id := range sortedIDs(s.resources) {
+		privileges := map[string][]string{}
+		var roles []string
+		for _, p := range s.resources[id].permissions {
+			if p.policy == policyResource {
+				if privileges[p.role] == nil {
+					roles = append(roles, p.role)
+				}
+				privileges[p.role] = append(privileges[p.role], p.privilege)
+			}
+		}
+		for _, role := range roles {
+			*count++
+			node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!permit"}
+			addField(node, "role", refNode(role))
+			addField(node, "privileges", sequenceNode(privileges[role], scalarNode))
+			addField(node, "resource", refNode(id))
+			records = append(records, node)
+		}
+	}
+	return records
+}
+
+func kindRank(kind string) int {
+	for i, k := range kindOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(kindOrder)
+}
+
+func addField(mapping *yaml.Node, key string, value *yaml.Node) {
+	mapping.Content = append(mapping.Content, scalarNode(key), value)
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// refNode returns an absolute reference to the resource with the given ID.
+func refNode(id string) *yaml.Node {
+	kind, identifier := parseID(id)
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!" + strings.ReplaceAll(kind, "_", "-"), Value: "/" + identifier}
+}
+
+func sequenceNode(values []string, node func(string) *yaml.Node) *yaml.Node {
+	sequence := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, value := range values {
+		sequence.Content = append(sequence.Content, node(value))
+	}
+	return sequence
+}
+
+// policyJSON converts rendered records to JSON values, each record being an
+// object with its tag as the only key, e.g. {"!variable": "password"}.
+func policyJSON(node *yaml.Node) interface{} {
+	var value interface{}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		values := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			values = append(values, policyJSON(item))
+		}
+		value = values
+	case yaml.MappingNode:
+		fields := map[string]interface{}{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			fields[node.Content[i].Value] = policyJSON(node.Content[i+1])
+		}
+		value = fields
+	default:
+		value = node.Value
+	}
+
+	if tagKind(node.Tag) != "" {
+		return map[string]interface{}{node.Tag: value}
+	}
+	return value
+}
+
+func kindName(kind string) string {
+	name := strings.ReplaceAll(kind, "_", " ")
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
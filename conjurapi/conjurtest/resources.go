@@ -0,0 +1,226 @@
+package conjurtest
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type resourceJSON struct {
+	CreatedAt    string           `json:"created_at"`
+	ID           string           `json:"id"`
+	Owner        string           `json:"owner"`
+	Policy       string           `json:"policy,omitempty"`
+	Permissions  []permissionJSON `json:"permissions"`
+	Annotations  []annotationJSON `json:"annotations"`
+	Secrets      []secretJSON     `json:"secrets,omitempty"`
+	RestrictedTo []string         `json:"restricted_to,omitempty"`
+}
+
+type permissionJSON struct {
+	Privilege string `json:"privilege"`
+	Role      string `json:"role"`
+	Policy    string `json:"policy,omitempty"`
+}
+
+type annotationJSON struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Policy string `json:"policy,omitempty"`
+}
+
+type secretJSON struct {
+	Version   int     `json:"version"`
+	ExpiresAt *string `json:"expires_at"`
+}
+
+type grantJSON struct {
+	AdminOption bool   `json:"admin_option"`
+	Ownership   bool   `json:"ownership"`
+	Role        string `json:"role"`
+	Member      string `json:"member"`
+	Policy      string `json:"policy,omitempty"`
+}
+
+func newResourceJSON(r *resource) resourceJSON {
+	j := resourceJSON{
+		CreatedAt:   r.createdAt.UTC().Format(time.RFC3339),
+		ID:          r.id,
+		Owner:       r.owner,
+		Policy:      r.policy,
+		Permissions: []permissionJSON{},
+		Annotations: []annotationJSON{},
+	}
+	for _, p := range r.permissions {
+		j.Permissions = append(j.Permissions, permissionJSON{Privilege: p.privilege, Role: p.role, Policy: p.policy})
+	}
+
+	names := make([]string, 0, len(r.annotations))
+	for name := range r.annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		j.Annotations = append(j.Annotations, annotationJSON{Name: name, Value: r.annotations[name], Policy: r.policy})
+	}
+
+	for version := r.secretVersion - len(r.secrets) + 1; version <= r.secretVersion; version++ {
+		j.Secrets = append(j.Secrets, secretJSON{Version: version})
+	}
+
+	if kind, _ := parseID(r.id); kind == "user" || kind == "host" {
+		j.RestrictedTo = append([]string{}, r.restrictedTo...)
+	}
+	return j
+}
+
+func newGrantJSON(g grant) grantJSON {
+	return grantJSON{AdminOption: g.adminOption, Ownership: g.ownership, Role: g.role, Member: g.member, Policy: g.policy}
+}
+
+// serveResources serves resources, with path relative to /resources:
+// <account> to list them or <account>/<kind>/<id> for a single one.
+func (s *Server) serveResources(w http.ResponseWriter, r *http.Request, role string, path []string) {
+	if r.Method != http.MethodGet || len(path) == 0 || path[0] != s.store.account || len(path) == 2 {
+		writeNotFound(w, r)
+		return
+	}
+	if len(path) == 1 {
+		s.serveResourceList(w, r, role)
+		return
+	}
+
+	query := r.URL.Query()
+	id := s.store.fullID(path[1], joinSegments(path[2:], queryUnescape))
+	res, exists := s.store.resources[id]
+
+	switch {
+	case query.Get("check") == "true":
+		checked := role
+		if roleID := query.Get("role"); roleID != "" {
+			checked = s.store.qualify(roleID)
+		}
+		if exists && s.store.permitted(checked, res, query.Get("privilege")) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.writeRecordNotFound(w, id)
+
+	case !exists || !s.store.visible(role, res):
+		s.writeRecordNotFound(w, id)
+
+	case query.Get("permitted_roles") == "true":
+		writeJSON(w, http.StatusOK, s.store.permittedRoles(res, query.Get("privilege")))
+
+	default:
+		writeJSON(w, http.StatusOK, newResourceJSON(res))
+	}
+}
+
+// serveResourceList lists the resources visible to the client, or to the
+// role given with acting_as, which the client must hold.
+func (s *Server) serveResourceList(w http.ResponseWriter, r *http.Request, role string) {
+	query := r.URL.Query()
+	if actingAs := query.Get("acting_as"); actingAs != "" {
+		actingAs = s.store.qualify(actingAs)
+		if !s.store.memberships(role)[actingAs] {
+			writeForbidden(w)
+			return
+		}
+		role = actingAs
+	}
+
+	kind := query.Get("kind")
+	search := strings.ToLower(query.Get("search"))
+	var resources []resourceJSON
+	for _, id := range sortedIDs(s.store.resources) {
+		res := s.store.resources[id]
+		resourceKind, identifier := parseID(id)
+		if (kind != "" && resourceKind != kind) || !s.store.visible(role, res) {
+			continue
+		}
+		if search != "" && !matchesSearch(identifier, res.annotations, search) {
+			continue
+		}
+		resources = append(resources, newResourceJSON(res))
+	}
+
+	if query.Get("count") == "true" {
+		writeJSON(w, http.StatusOK, map[string]int{"count": len(resources)})
+		return
+	}
+
+	offset, _ := strconv.Atoi(query.Get("offset"))
+	resources = resources[min(max(offset, 0), len(resources)):]
+	if limit, _ := strconv.Atoi(query.Get("limit")); limit > 0 && limit < len(resources) {
+		resources = resources[:limit]
+	}
+	if resources == nil {
+		resources = []resourceJSON{}
+	}
+	writeJSON(w, http.StatusOK, resources)
+}
+
+func matchesSearch(identifier string, annotations map[string]string, search string) bool {
+	if strings.Contains(strings.ToLower(identifier), search) {
+		return true
+	}
+	for _, value := range annotations {
+		if strings.Contains(strings.ToLower(value), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveRoles serves roles, with path relative to /roles:
+// <account>/<kind>/<id>, with the members, memberships or all parameter to
+// list the role's members, its direct memberships or all of them.
+func (s *Server) serveRoles(w http.ResponseWriter, r *http.Request, role string, path []string) {
+	if r.Method != http.MethodGet || len(path) < 3 || path[0] != s.store.account {
+		writeNotFound(w, r)
+		return
+	}
+
+	id := s.store.fullID(path[1], joinSegments(path[2:], queryUnescape))
+	if !s.store.isRole(id) {
+		s.writeRecordNotFound(w, id)
+		return
+	}
+
+	members := []grantJSON{}
+	memberships := []grantJSON{}
+	for _, g := range s.store.grants {
+		if g.role == id {
+			members = append(members, newGrantJSON(g))
+		}
+		if g.member == id {
+			memberships = append(memberships, newGrantJSON(g))
+		}
+	}
+
+	query := r.URL.Query()
+	switch {
+	case query.Has("members"):
+		writeJSON(w, http.StatusOK, members)
+	case query.Has("memberships"):
+		writeJSON(w, http.StatusOK, memberships)
+	case query.Has("all"):
+		all := []string{}
+		for held := range s.store.memberships(id) {
+			all = append(all, held)
+		}
+		sort.Strings(all)
+		writeJSON(w, http.StatusOK, all)
+	default:
+		res := s.store.resources[id]
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"created_at": res.createdAt.UTC().Format(time.RFC3339),
+			"id":         id,
+			"policy":     res.policy,
+			"members":    members,
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package conjurtest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// serveSecrets serves secrets, with path relative to /secrets:
+// <account>/variable/<id> for a single variable, or nothing for batch
+// retrieval.
+func (s *Server) serveSecrets(w http.ResponseWriter, r *http.Request, role string, path []string) {
+	if len(path) == 0 {
+		if r.Method != http.MethodGet {
+			writeNotFound(w, r)
+			return
+		}
+		s.serveBatchSecrets(w, r, role)
+		return
+	}
+	if len(path) < 3 || path[0] != s.store.account || path[1] != "variable" {
+		writeNotFound(w, r)
+		return
+	}
+
+	id := s.store.fullID("variable", joinSegments(path[2:], url.PathUnescape))
+	res, exists := s.store.resources[id]
+	if !exists || !s.store.visible(role, res) {
+		s.writeRecordNotFound(w, id)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if !s.store.permitted(role, res, "execute") {
+			writeForbidden(w)
+			return
+		}
+		version := 0
+		if v := r.URL.Query().Get("version"); v != "" {
+			var err error
+			if version, err = strconv.Atoi(v); err != nil || version < 1 {
+				writeError(w, http.StatusUnprocessableEntity, "validation_failed", fmt.Sprintf("Invalid version %q", v))
+				return
+			}
+		}
+		value, ok := res.secret(version)
+		if !ok {
+			writeSecretNotFound(w, id)
+			return
+		}
+		contentType := res.annotations["conjur/mime_type"]
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(value)
+
+	case http.MethodPost:
+		if !s.store.permitted(role, res, "update") {
+			writeForbidden(w)
+			return
+		}
+		value, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+			return
+		}
+		res.addSecret(value)
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// serveBatchSecrets serves the values of the variables listed in the
+// variable_ids parameter, base64-encoded when the request accepts it.
+func (s *Server) serveBatchSecrets(w http.ResponseWriter, r *http.Request, role string) {
+	param := r.URL.Query().Get("variable_ids")
+	if param == "" {
+		writeError(w, http.StatusUnprocessableEntity, "validation_failed", "variable_ids must not be empty")
+		return
+	}
+	encode := r.Header.Get("Accept-Encoding") == "base64"
+
+	values := map[string]string{}
+	for _, id := range strings.Split(param, ",") {
+		res, exists := s.store.resources[id]
+		if kind, _ := parseID(id); !exists || kind != "variable" || !s.store.visible(role, res) {
+			s.writeRecordNotFound(w, id)
+			return
+		}
+		if !s.store.permitted(role, res, "execute") {
+			writeForbidden(w)
+			return
+		}
+		value, ok := res.secret(0)
+		if !ok {
+			writeSecretNotFound(w, id)
+			return
+		}
+
+		switch {
+		case encode:
+			values[id] = base64.StdEncoding.EncodeToString(value)
+		case !utf8.Valid(value):
+			writeError(w, http.StatusNotAcceptable, "not_acceptable", "Issue encoding secret into JSON format, try including 'Accept-Encoding: base64' header in request.")
+			return
+		default:
+			values[id] = string(value)
+		}
+	}
+
+	if encode {
+		w.Header().Set("Content-Encoding", "base64")
+	}
+	writeJSON(w, http.StatusOK, values)
+}
+
+func writeSecretNotFound(w http.ResponseWriter, id string) {
+	writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("CONJ00076E Variable %s is empty or not found.", id))
+}
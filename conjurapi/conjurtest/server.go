@@ -0,0 +1,462 @@
+// Package conjurtest provides an in-memory fake Conjur server for testing
+// code which uses the Conjur API, e.g. with a conjurapi.Client:
+//
+//	server := conjurtest.NewServer("myorg")
+//	defer server.Close()
+//
+//	err := server.LoadPolicy("root", `
+//	- !variable db/password
+//	`)
+//	...
+//	err = server.AddSecret("variable:db/password", "s3cr3t")
+//	...
+//	client, err := conjurapi.NewClientFromKey(
+//		conjurapi.Config{Account: "myorg", ApplianceURL: server.URL},
+//		authn.LoginPair{Login: "admin", APIKey: server.APIKey("user:admin")},
+//	)
+//
+// The server keeps its state in memory and implements the parts of the Conjur
+// API used by this module: authentication with API keys, JWTs and OIDC,
+// secrets with versions and batch retrieval, resources and roles with
+// permissions, loading, dry-running and fetching policies, host factories,
+// /info and /whoami. Errors are reported with the same JSON as Conjur's.
+//
+// Policies support the common records: !policy, !user, !host, !group,
+// !layer, !variable, !webservice, !host-factory, !grant, !revoke, !permit,
+// !deny and !delete. Authenticators don't need to be declared in policy, JWTs
+// and OIDC tokens are accepted once registered with AddJWT, AddOIDCToken or
+// AddOIDCCode.
+package conjurtest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the Conjur version reported by the server by default.
+const Version = "1.22.0"
+
+// Server is a fake Conjur server with an account whose administrator is
+// user:admin.
+type Server struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	store    *store
+	version  string
+	tokenTTL time.Duration
+	tokens   map[string]accessToken
+	handler  http.Handler
+	// latency, faults and middleware are set with options.
+	latency    time.Duration
+	faults     []*faultState
+	middleware func(next http.Handler) http.Handler
+	// requests are the requests received, of which inFlight are being
+	// served, and at most maxInFlight were at once.
+	requests    []Request
+	inFlight    int
+	maxInFlight int
+	// hostFactoryTokens are the host factory tokens by token.
+	hostFactoryTokens map[string]hostFactoryToken
+	// jwts, oidcTokens and oidcCodes map service IDs and credentials to
+	// the roles which authenticate with them.
+	jwts       map[credential]string
+	oidcTokens map[credential]string
+	oidcCodes  map[credential]string
+}
+
+type accessToken struct {
+	role      string
+	issuedAt  time.Time
+	expiresAt time.Time
+}
+
+type hostFactoryToken struct {
+	hostFactory string
+	expiration  time.Time
+	cidr        []string
+}
+
+type credential struct {
+	serviceID string
+	value     string
+}
+
+// NewServer starts a fake Conjur server for account. Close it when done.
+func NewServer(account string, options ...Option) *Server {
+	s := newServer(account, options)
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewTLSServer is like NewServer but serves HTTPS. Its certificate is
+// available with Certificate.
+func NewTLSServer(account string, options ...Option) *Server {
+	s := newServer(account, options)
+	s.Server = httptest.NewTLSServer(s)
+	return s
+}
+
+func newServer(account string, options []Option) *Server {
+	s := &Server{
+		store:             newStore(account),
+		version:           Version,
+		tokenTTL:          8 * time.Minute,
+		tokens:            map[string]accessToken{},
+		hostFactoryTokens: map[string]hostFactoryToken{},
+		jwts:              map[credential]string{},
+		oidcTokens:        map[credential]string{},
+		oidcCodes:         map[credential]string{},
+	}
+	for _, option := range options {
+		option(s)
+	}
+
+	s.handler = http.HandlerFunc(s.serveAPI)
+	if s.middleware != nil {
+		s.handler = s.middleware(s.handler)
+	}
+	return s
+}
+
+// Account returns the server's account.
+func (s *Server) Account() string {
+	return s.store.account
+}
+
+// SetVersion sets the Conjur version reported by the server.
+func (s *Server) SetVersion(version string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.version = version
+}
+
+// SetTokenTTL sets how long the access tokens issued from now on are valid, 8
+// minutes by default.
+func (s *Server) SetTokenTTL(ttl time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokenTTL = ttl
+}
+
+// APIKey returns the API key of a user or host, given by its partially- or
+// fully-qualified ID, e.g. "user:admin", or "" if there is none.
+func (s *Server) APIKey(roleID string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if r, ok := s.store.resources[s.store.qualify(roleID)]; ok {
+		return r.apiKey
+	}
+	return ""
+}
+
+// LoadPolicy loads policy into the policy with the given identifier, e.g.
+// "root", as user:admin with POST.
+func (s *Server) LoadPolicy(policyID string, policy string) error {
+	records, err := parsePolicy([]byte(policy))
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.store.resources[s.store.fullID("policy", policyID)]; !ok {
+		return fmt.Errorf("Policy '%s' not found in account '%s'", policyID, s.store.account)
+	}
+	_, err = s.store.loadPolicy(policyID, http.MethodPost, records)
+	return err
+}
+
+// AddSecret adds a new version of the value of a variable, given by its
+// partially- or fully-qualified ID, e.g. "variable:db/password". The
+// variable must have been declared in policy.
+func (s *Server) AddSecret(variableID string, value string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := s.store.qualify(variableID)
+	r, ok := s.store.resources[id]
+	if kind, _ := parseID(id); !ok || kind != "variable" {
+		return fmt.Errorf("Variable %s not found", id)
+	}
+	r.addSecret([]byte(value))
+	return nil
+}
+
+// AddJWT makes the JWT authenticator with the given service ID accept jwt
+// for the role with the given partially- or fully-qualified ID, e.g.
+// "host:myapp".
+func (s *Server) AddJWT(serviceID string, jwt string, roleID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jwts[credential{serviceID, jwt}] = s.store.qualify(roleID)
+}
+
+// AddOIDCToken makes the OIDC authenticator with the given service ID accept
+// idToken for the role with the given partially- or fully-qualified ID.
+func (s *Server) AddOIDCToken(serviceID string, idToken string, roleID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.oidcTokens[credential{serviceID, idToken}] = s.store.qualify(roleID)
+}
+
+// AddOIDCCode makes the OIDC authenticator with the given service ID accept
+// the authorization code for the role with the given partially- or
+// fully-qualified ID.
+func (s *Server) AddOIDCCode(serviceID string, code string, roleID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.oidcCodes[credential{serviceID, code}] = s.store.qualify(roleID)
+}
+
+// ServeHTTP records r, and serves the Conjur API after the configured
+// latency, unless a fault fails r.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fault := s.record(r)
+	defer s.done()
+	time.Sleep(s.latency)
+
+	if fault != nil {
+		w.WriteHeader(fault.Status)
+		return
+	}
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	switch path[0] {
+	case "":
+		s.serveRoot(w, r)
+	case "info":
+		s.serveInfo(w, r)
+	case "authn":
+		s.serveAuthn(w, r, path[1:])
+	case "authn-jwt":
+		s.serveAuthnJWT(w, r, path[1:])
+	case "authn-oidc":
+		s.serveAuthnOIDC(w, r, path[1:])
+	case "host_factories":
+		s.serveCreateHost(w, r, path[1:])
+	default:
+		token, ok := s.authenticate(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Authorization missing or invalid")
+			return
+		}
+		role := token.role
+
+		switch path[0] {
+		case "whoami":
+			s.serveWhoAmI(w, r, token)
+		case "secrets":
+			s.serveSecrets(w, r, role, path[1:])
+		case "resources":
+			s.serveResources(w, r, role, path[1:])
+		case "roles":
+			s.serveRoles(w, r, role, path[1:])
+		case "policies":
+			s.servePolicies(w, r, role, path[1:])
+		case "host_factory_tokens":
+			s.serveHostFactoryTokens(w, r, role, path[1:])
+		default:
+			writeNotFound(w, r)
+		}
+	}
+}
+
+// joinSegments unescapes and joins the segments of a path which make up an
+// ID. IDs are escaped in Conjur URLs, so slashes in them usually don't split
+// segments. They are query-escaped, except in /secrets URLs.
+func joinSegments(segments []string, unescape func(string) (string, error)) string {
+	unescaped := make([]string, len(segments))
+	for i, segment := range segments {
+		var err error
+		if unescaped[i], err = unescape(segment); err != nil {
+			unescaped[i] = segment
+		}
+	}
+	return strings.Join(unescaped, "/")
+}
+
+// queryUnescape unescapes a query-escaped path segment.
+func queryUnescape(segment string) (string, error) {
+	return url.QueryUnescape(segment)
+}
+
+// conjurError is the JSON body of Conjur's error responses.
+type conjurError struct {
+	Error conjurErrorDetails `json:"error"`
+}
+
+type conjurErrorDetails struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Target  string `json:"target,omitempty"`
+}
+
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	writeJSON(w, status, conjurError{conjurErrorDetails{Code: code, Message: message}})
+}
+
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("No route matches [%s] %q", r.Method, r.URL.Path))
+}
+
+func writeForbidden(w http.ResponseWriter) {
+	writeError(w, http.StatusForbidden, "forbidden", "Forbidden")
+}
+
+// writeRecordNotFound writes the error Conjur returns for a missing record,
+// or one which the client isn't allowed to see.
+func (s *Server) writeRecordNotFound(w http.ResponseWriter, id string) {
+	kind, identifier := parseID(id)
+	writeJSON(w, http.StatusNotFound, conjurError{conjurErrorDetails{
+		Code:    "not_found",
+		Message: fmt.Sprintf("%s '%s' not found in account '%s'", kindName(kind), identifier, s.store.account),
+		Target:  "id",
+	}})
+}
+
+func writePolicyError(w http.ResponseWriter, err error) {
+	if perr, ok := err.(*policyError); ok {
+		writeError(w, perr.status, perr.code, perr.message)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeText(w http.ResponseWriter, status int, text []byte) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(status)
+	w.Write(text)
+}
+
+// issueToken returns a new access token for role, in the format of Conjur's.
+// Tokens aren't signed, they are recognized by their signature field.
+func (s *Server) issueToken(role string) []byte {
+	token := accessToken{role: role, issuedAt: time.Now()}
+	token.expiresAt = token.issuedAt.Add(s.tokenTTL)
+
+	protected, _ := json.Marshal(map[string]string{"alg": "conjur.org/slosilo/v2", "kid": "conjurtest"})
+	payload, _ := json.Marshal(map[string]interface{}{
+		"sub": login(role),
+		"iat": token.issuedAt.Unix(),
+		"exp": token.expiresAt.Unix(),
+	})
+	signature := newSecretString()
+	s.tokens[signature] = token
+
+	body, _ := json.Marshal(map[string]string{
+		"protected": base64.StdEncoding.EncodeToString(protected),
+		"payload":   base64.StdEncoding.EncodeToString(payload),
+		"signature": signature,
+	})
+	return body
+}
+
+// writeToken responds to a successful authentication.
+func (s *Server) writeToken(w http.ResponseWriter, r *http.Request, role string) {
+	token := s.issueToken(role)
+	if r.Header.Get("Accept-Encoding") == "base64" {
+		w.Header().Set("Content-Encoding", "base64")
+		writeText(w, http.StatusOK, []byte(base64.StdEncoding.EncodeToString(token)))
+		return
+	}
+	writeJSON(w, http.StatusOK, json.RawMessage(token))
+}
+
+// authenticate returns the access token which r carries.
+func (s *Server) authenticate(r *http.Request) (accessToken, bool) {
+	encoded, ok := tokenFromHeader(r)
+	if !ok {
+		return accessToken{}, false
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return accessToken{}, false
+	}
+	var fields struct {
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return accessToken{}, false
+	}
+
+	token, ok := s.tokens[fields.Signature]
+	if !ok || time.Now().After(token.expiresAt) {
+		return accessToken{}, false
+	}
+	if _, exists := s.store.resources[token.role]; !exists {
+		return accessToken{}, false
+	}
+	return token, true
+}
+
+// tokenFromHeader returns the token of an Authorization header of the form
+// Token token="...".
+func tokenFromHeader(r *http.Request) (string, bool) {
+	header, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Token token=")
+	if !ok {
+		return "", false
+	}
+	return strings.Trim(header, `"`), true
+}
+
+func (s *Server) serveRoot(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"version": s.version})
+}
+
+func (s *Server) serveInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"release": s.version,
+		"version": s.version,
+		"services": map[string]interface{}{
+			"possum": map[string]string{
+				"desired":     "i",
+				"status":      "i",
+				"description": "Conjur",
+				"name":        "conjur-possum",
+				"version":     s.version,
+				"arch":        "amd64",
+			},
+		},
+		"container": "conjurtest",
+		"role":      "master",
+		"configuration": map[string]interface{}{
+			"conjur": map[string]string{"account": s.store.account, "role": "master"},
+		},
+	})
+}
+
+func (s *Server) serveWhoAmI(w http.ResponseWriter, r *http.Request, token accessToken) {
+	clientIP, _, _ := strings.Cut(r.RemoteAddr, ":")
+	writeJSON(w, http.StatusOK, map[string]string{
+		"client_ip":       clientIP,
+		"user_agent":      r.UserAgent(),
+		"account":         s.store.account,
+		"username":        login(token.role),
+		"token_issued_at": token.issuedAt.UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,408 @@
+package conjurtest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicy = `
+- !policy
+  id: myapp
+  body:
+  - !host app
+  - !variable db/password
+  - !variable db/username
+  - !group readers
+  - !layer hosts
+  - !host-factory
+    id: factory
+    layers: [ !layer hosts ]
+  - !grant
+    role: !group readers
+    member: !host app
+  - !permit
+    role: !group readers
+    privileges: [ read, execute ]
+    resources:
+    - !variable db/password
+    - !variable db/username
+`
+
+func newServer(t *testing.T) *conjurtest.Server {
+	server := conjurtest.NewServer("conjur")
+	t.Cleanup(server.Close)
+	require.NoError(t, server.LoadPolicy("root", testPolicy))
+	return server
+}
+
+func newClient(t *testing.T, server *conjurtest.Server, login string, roleID string) *conjurapi.Client {
+	client, err := conjurapi.NewClientFromKey(
+		conjurapi.Config{Account: server.Account(), ApplianceURL: server.URL},
+		authn.LoginPair{Login: login, APIKey: server.APIKey(roleID)},
+	)
+	require.NoError(t, err)
+	return client
+}
+
+func requireConjurError(t *testing.T, err error, code int) *response.ConjurError {
+	require.Error(t, err)
+	conjurError, ok := err.(*response.ConjurError)
+	require.True(t, ok, "expected a ConjurError, got %T: %v", err, err)
+	assert.Equal(t, code, conjurError.Code)
+	return conjurError
+}
+
+func TestServer_Authn(t *testing.T) {
+	server := newServer(t)
+
+	t.Run("Authenticates with an API key", func(t *testing.T) {
+		client := newClient(t, server, "host/myapp/app", "host:myapp/app")
+
+		whoami, err := client.WhoAmI()
+		require.NoError(t, err)
+		assert.Contains(t, string(whoami), `"username":"host/myapp/app"`)
+		assert.Contains(t, string(whoami), `"account":"conjur"`)
+	})
+
+	t.Run("Rejects a wrong API key", func(t *testing.T) {
+		client, err := conjurapi.NewClientFromKey(
+			conjurapi.Config{Account: "conjur", ApplianceURL: server.URL},
+			authn.LoginPair{Login: "admin", APIKey: "wrong"},
+		)
+		require.NoError(t, err)
+
+		_, err = client.WhoAmI()
+		requireConjurError(t, err, 401)
+	})
+
+	t.Run("Rotates API keys", func(t *testing.T) {
+		client := newClient(t, server, "admin", "user:admin")
+
+		rotated, err := client.RotateHostAPIKey("myapp/app")
+		require.NoError(t, err)
+		assert.Equal(t, server.APIKey("host:myapp/app"), string(rotated))
+	})
+
+	t.Run("Authenticates with a JWT", func(t *testing.T) {
+		server.AddJWT("github", "header.payload.signature", "host:myapp/app")
+		client, err := conjurapi.NewClientFromJwt(conjurapi.Config{
+			Account:      "conjur",
+			ApplianceURL: server.URL,
+			AuthnType:    "jwt",
+			ServiceID:    "github",
+			JWTContent:   "header.payload.signature",
+		})
+		require.NoError(t, err)
+
+		whoami, err := client.WhoAmI()
+		require.NoError(t, err)
+		assert.Contains(t, string(whoami), `"username":"host/myapp/app"`)
+	})
+
+	t.Run("Authenticates with OIDC", func(t *testing.T) {
+		server.AddOIDCToken("okta", "id-token", "user:admin")
+		server.AddOIDCCode("okta", "auth-code", "user:admin")
+		config := conjurapi.Config{Account: "conjur", ApplianceURL: server.URL, AuthnType: "oidc", ServiceID: "okta"}
+
+		client, err := conjurapi.NewClientFromOidcToken(config, "id-token")
+		require.NoError(t, err)
+		_, err = client.WhoAmI()
+		require.NoError(t, err)
+
+		client, err = conjurapi.NewClientFromOidcCode(config, "auth-code", "nonce", "verifier")
+		require.NoError(t, err)
+		_, err = client.WhoAmI()
+		require.NoError(t, err)
+
+		providers, err := client.ListOidcProviders()
+		require.NoError(t, err)
+		require.Len(t, providers, 1)
+		assert.Equal(t, "okta", providers[0].ServiceID)
+	})
+
+	t.Run("Expires access tokens", func(t *testing.T) {
+		client := newClient(t, server, "admin", "user:admin")
+		server.SetTokenTTL(time.Nanosecond)
+		defer server.SetTokenTTL(8 * time.Minute)
+
+		token, err := client.Authenticate(authn.LoginPair{Login: "admin", APIKey: server.APIKey("user:admin")})
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+
+		expired, err := conjurapi.NewClientFromToken(conjurapi.Config{Account: "conjur", ApplianceURL: server.URL}, string(token))
+		require.NoError(t, err)
+		_, err = expired.WhoAmI()
+		requireConjurError(t, err, 401)
+	})
+}
+
+func TestServer_Secrets(t *testing.T) {
+	server := newServer(t)
+	admin := newClient(t, server, "admin", "user:admin")
+	host := newClient(t, server, "host/myapp/app", "host:myapp/app")
+
+	t.Run("Stores versions of secrets", func(t *testing.T) {
+		require.NoError(t, admin.AddSecret("myapp/db/password", "first"))
+		require.NoError(t, admin.AddSecret("myapp/db/password", "second"))
+
+		value, err := host.RetrieveSecret("myapp/db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(value))
+
+		value, err = host.RetrieveSecretWithVersion("myapp/db/password", 1)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(value))
+
+		_, err = host.RetrieveSecretWithVersion("myapp/db/password", 3)
+		conjurError := requireConjurError(t, err, 404)
+		assert.Contains(t, conjurError.Details.Message, "is empty or not found")
+	})
+
+	t.Run("Retrieves secrets in batches", func(t *testing.T) {
+		require.NoError(t, server.AddSecret("variable:myapp/db/username", "app\xff"))
+
+		values, err := host.RetrieveBatchSecretsSafe([]string{"myapp/db/password", "myapp/db/username"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"conjur:variable:myapp/db/password": []byte("second"),
+			"conjur:variable:myapp/db/username": []byte("app\xff"),
+		}, values)
+
+		_, err = host.RetrieveBatchSecrets([]string{"myapp/db/username"})
+		requireConjurError(t, err, 406)
+	})
+
+	t.Run("Enforces permissions", func(t *testing.T) {
+		err := host.AddSecret("myapp/db/password", "third")
+		requireConjurError(t, err, 403)
+
+		_, err = host.RetrieveSecret("missing")
+		conjurError := requireConjurError(t, err, 404)
+		assert.Equal(t, "not_found", conjurError.Details.Code)
+	})
+}
+
+func TestServer_ResourcesAndRoles(t *testing.T) {
+	server := newServer(t)
+	admin := newClient(t, server, "admin", "user:admin")
+	host := newClient(t, server, "host/myapp/app", "host:myapp/app")
+
+	t.Run("Shows and lists resources", func(t *testing.T) {
+		resource, err := admin.Resource("conjur:variable:myapp/db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "conjur:variable:myapp/db/password", resource["id"])
+		assert.Equal(t, "conjur:policy:myapp", resource["owner"])
+
+		ids, err := host.ResourceIDs(&conjurapi.ResourceFilter{Kind: "variable"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"conjur:variable:myapp/db/password", "conjur:variable:myapp/db/username"}, ids)
+
+		ids, err = admin.ResourceIDs(&conjurapi.ResourceFilter{Kind: "variable", Search: "user", Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"conjur:variable:myapp/db/username"}, ids)
+	})
+
+	t.Run("Checks permissions", func(t *testing.T) {
+		allowed, err := host.CheckPermission("conjur:variable:myapp/db/password", "execute")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, err = host.CheckPermission("conjur:variable:myapp/db/password", "update")
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		allowed, err = admin.CheckPermissionForRole("conjur:variable:myapp/db/password", "conjur:host:myapp/app", "read")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		roles, err := admin.PermittedRoles("conjur:variable:myapp/db/password", "execute")
+		require.NoError(t, err)
+		assert.Contains(t, roles, "conjur:group:myapp/readers")
+		assert.Contains(t, roles, "conjur:host:myapp/app")
+	})
+
+	t.Run("Shows role members and memberships", func(t *testing.T) {
+		members, err := admin.RoleMembers("conjur:group:myapp/readers")
+		require.NoError(t, err)
+		var ids []string
+		for _, member := range members {
+			ids = append(ids, member["member"].(string))
+		}
+		assert.Contains(t, ids, "conjur:host:myapp/app")
+
+		all, err := admin.RoleMembershipsAll("conjur:host:myapp/app")
+		require.NoError(t, err)
+		assert.Contains(t, all, "conjur:group:myapp/readers")
+		assert.Contains(t, all, "conjur:host:myapp/app")
+
+		exists, err := admin.RoleExists("conjur:group:missing")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
+
+func TestServer_Policies(t *testing.T) {
+	server := newServer(t)
+	admin := newClient(t, server, "admin", "user:admin")
+
+	t.Run("Loads policy", func(t *testing.T) {
+		resp, err := admin.LoadPolicy(conjurapi.PolicyModePost, "myapp", strings.NewReader(`
+- !user alice
+- !variable api/token
+`))
+		require.NoError(t, err)
+		require.Contains(t, resp.CreatedRoles, "conjur:user:alice@myapp")
+		assert.Equal(t, server.APIKey("user:alice@myapp"), resp.CreatedRoles["conjur:user:alice@myapp"].APIKey)
+
+		exists, err := admin.ResourceExists("conjur:variable:myapp/api/token")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("Rejects invalid policy", func(t *testing.T) {
+		_, err := admin.LoadPolicy(conjurapi.PolicyModePost, "myapp", strings.NewReader(`
+- !grant
+  role: !group missing
+  member: !host app
+`))
+		requireConjurError(t, err, 404)
+
+		_, err = admin.LoadPolicy(conjurapi.PolicyModePost, "myapp", strings.NewReader("- !unknown x"))
+		requireConjurError(t, err, 422)
+	})
+
+	t.Run("Dry runs policy", func(t *testing.T) {
+		resp, err := admin.DryRunPolicy(conjurapi.PolicyModePatch, "myapp", strings.NewReader(`
+- !delete
+  record: !variable api/token
+- !variable api/key
+`))
+		require.NoError(t, err)
+		assert.Equal(t, "Valid YAML", resp.Status)
+		require.Len(t, resp.Created.Items, 1)
+		assert.Equal(t, "conjur:variable:myapp/api/key", resp.Created.Items[0].Identifier)
+		require.Len(t, resp.Deleted.Items, 1)
+		assert.Equal(t, "conjur:variable:myapp/api/token", resp.Deleted.Items[0].Identifier)
+
+		exists, err := admin.ResourceExists("conjur:variable:myapp/api/key")
+		require.NoError(t, err)
+		assert.False(t, exists)
+
+		resp, err = admin.DryRunPolicy(conjurapi.PolicyModePost, "myapp", strings.NewReader("- !variable\n  id: [x"))
+		require.NoError(t, err)
+		assert.Equal(t, "Invalid YAML", resp.Status)
+		assert.NotEmpty(t, resp.Errors)
+	})
+
+	t.Run("Replaces policy", func(t *testing.T) {
+		_, err := admin.LoadPolicy(conjurapi.PolicyModePut, "myapp", strings.NewReader("- !variable only"))
+		require.NoError(t, err)
+
+		ids, err := admin.ResourceIDs(&conjurapi.ResourceFilter{Kind: "variable"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"conjur:variable:myapp/only"}, ids)
+	})
+
+	t.Run("Fetches policy", func(t *testing.T) {
+		policy, err := admin.FetchPolicy("myapp", false, 0, 0)
+		require.NoError(t, err)
+		assert.Contains(t, string(policy), "!variable")
+		assert.Contains(t, string(policy), "only")
+
+		policy, err = admin.FetchPolicy("root", true, 0, 0)
+		require.NoError(t, err)
+		var records []map[string]interface{}
+		require.NoError(t, json.Unmarshal(policy, &records))
+		assert.NotEmpty(t, records)
+	})
+}
+
+func TestServer_HostFactory(t *testing.T) {
+	server := newServer(t)
+	admin := newClient(t, server, "admin", "user:admin")
+
+	tokens, err := admin.CreateToken("1h", "conjur:host_factory:myapp/factory", []string{"0.0.0.0/0"}, 2)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, []string{"0.0.0.0/0"}, tokens[0].Cidr)
+
+	host, err := admin.CreateHostWithAnnotations("new-host", tokens[0].Token, map[string]string{"team": "a"})
+	require.NoError(t, err)
+	assert.Equal(t, "conjur:host:myapp/new-host", host.Id)
+	assert.Equal(t, server.APIKey("host:myapp/new-host"), host.ApiKey)
+
+	all, err := admin.RoleMembershipsAll("conjur:host:myapp/new-host")
+	require.NoError(t, err)
+	assert.Contains(t, all, "conjur:layer:myapp/hosts")
+
+	require.NoError(t, admin.DeleteToken(tokens[0].Token))
+	_, err = admin.CreateHost("other-host", tokens[0].Token)
+	requireConjurError(t, err, 401)
+}
+
+func TestServer_Info(t *testing.T) {
+	server := newServer(t)
+	server.SetVersion("1.21.1")
+	client := newClient(t, server, "admin", "user:admin")
+
+	version, err := client.ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "1.21.1", version)
+}
+
+func TestServer_Options(t *testing.T) {
+	t.Run("Fails the requests of a fault", func(t *testing.T) {
+		server := conjurtest.NewServer("conjur", conjurtest.WithFault(conjurtest.Fault{
+			Method:     "GET",
+			PathPrefix: "/secrets",
+			Status:     503,
+			Skip:       1,
+			Times:      1,
+		}))
+		t.Cleanup(server.Close)
+		require.NoError(t, server.LoadPolicy("root", testPolicy))
+		require.NoError(t, server.AddSecret("variable:myapp/db/password", "secret"))
+		client := newClient(t, server, "admin", "user:admin")
+
+		_, err := client.RetrieveSecret("myapp/db/password")
+		require.NoError(t, err)
+		_, err = client.RetrieveSecret("myapp/db/password")
+		requireConjurError(t, err, 503)
+		_, err = client.RetrieveSecret("myapp/db/password")
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, server.CountRequests("/secrets"))
+		assert.Equal(t, 1, server.CountRequests("/authn"))
+		assert.Len(t, server.Requests(), 4)
+	})
+
+	t.Run("Delays responses concurrently", func(t *testing.T) {
+		server := conjurtest.NewServer("conjur", conjurtest.WithLatency(50*time.Millisecond))
+		t.Cleanup(server.Close)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := http.Get(server.URL + "/info")
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 3, server.MaxConcurrentRequests())
+	})
+}
@@ -0,0 +1,307 @@
+package conjurtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// roleKinds are the kinds of resources which are also roles.
+var roleKinds = map[string]bool{
+	"user":   true,
+	"host":   true,
+	"group":  true,
+	"layer":  true,
+	"policy": true,
+}
+
+// maxSecretVersions is the number of versions of a secret which are kept,
+// like in Conjur.
+const maxSecretVersions = 20
+
+type resource struct {
+	id          string
+	owner       string
+	policy      string
+	createdAt   time.Time
+	annotations map[string]string
+	permissions []permission
+
+	// secrets are the last versions of a variable's value, oldest first, and
+	// secretVersion is the version of the latest one.
+	secrets       [][]byte
+	secretVersion int
+	// apiKey and password are the credentials of users and hosts.
+	apiKey       string
+	password     string
+	restrictedTo []string
+	// layers are the layers of a host factory.
+	layers []string
+}
+
+type permission struct {
+	privilege string
+	role      string
+	policy    string
+}
+
+type grant struct {
+	role        string
+	member      string
+	adminOption bool
+	ownership   bool
+	policy      string
+}
+
+// store holds the data of an account.
+type store struct {
+	account   string
+	resources map[string]*resource
+	grants    []grant
+	// policyVersions counts the loads of each policy.
+	policyVersions map[string]int
+}
+
+func newStore(account string) *store {
+	s := &store{
+		account:        account,
+		resources:      map[string]*resource{},
+		policyVersions: map[string]int{},
+	}
+
+	admin := s.fullID("user", "admin")
+	root := s.fullID("policy", "root")
+	s.resources[admin] = &resource{id: admin, owner: admin, createdAt: time.Now(), annotations: map[string]string{}, apiKey: newSecretString()}
+	s.resources[root] = &resource{id: root, owner: admin, createdAt: time.Now(), annotations: map[string]string{}}
+	s.grants = append(s.grants, grant{role: root, member: admin, adminOption: true, ownership: true})
+	return s
+}
+
+// clone returns a deep copy of s, used to try out a policy load.
+func (s *store) clone() *store {
+	c := &store{
+		account:        s.account,
+		resources:      make(map[string]*resource, len(s.resources)),
+		grants:         append([]grant(nil), s.grants...),
+		policyVersions: make(map[string]int, len(s.policyVersions)),
+	}
+	for id, r := range s.resources {
+		copied := *r
+		copied.annotations = make(map[string]string, len(r.annotations))
+		for name, value := range r.annotations {
+			copied.annotations[name] = value
+		}
+		copied.permissions = append([]permission(nil), r.permissions...)
+		copied.secrets = append([][]byte(nil), r.secrets...)
+		copied.restrictedTo = append([]string(nil), r.restrictedTo...)
+		copied.layers = append([]string(nil), r.layers...)
+		c.resources[id] = &copied
+	}
+	for id, version := range s.policyVersions {
+		c.policyVersions[id] = version
+	}
+	return c
+}
+
+func (s *store) fullID(kind, identifier string) string {
+	return s.account + ":" + kind + ":" + identifier
+}
+
+// parseID splits a fully-qualified ID into its kind and identifier.
+func parseID(id string) (kind, identifier string) {
+	parts := strings.SplitN(id, ":", 3)
+	if len(parts) != 3 {
+		return "", ""
+	}
+	return parts[1], parts[2]
+}
+
+// qualify returns the fully-qualified form of id, which may omit the
+// account.
+func (s *store) qualify(id string) string {
+	if strings.Count(id, ":") >= 2 {
+		return id
+	}
+	return s.account + ":" + id
+}
+
+// loginRole returns the ID of the role which authenticates as login, e.g.
+// "host/myapp" or "alice".
+func (s *store) loginRole(login string) string {
+	if identifier, ok := strings.CutPrefix(login, "host/"); ok {
+		return s.fullID("host", identifier)
+	}
+	return s.fullID("user", login)
+}
+
+// login returns the login of a user or host role.
+func login(roleID string) string {
+	kind, identifier := parseID(roleID)
+	if kind == "host" {
+		return "host/" + identifier
+	}
+	return identifier
+}
+
+func (s *store) isRole(id string) bool {
+	kind, _ := parseID(id)
+	_, exists := s.resources[id]
+	return exists && roleKinds[kind]
+}
+
+// memberships returns the roles held by role, directly or not, including
+// role itself.
+func (s *store) memberships(role string) map[string]bool {
+	held := map[string]bool{role: true}
+	queue := []string{role}
+	for len(queue) > 0 {
+		member := queue[0]
+		queue = queue[1:]
+		for _, g := range s.grants {
+			if g.member == member && !held[g.role] {
+				held[g.role] = true
+				queue = append(queue, g.role)
+			}
+		}
+	}
+	return held
+}
+
+// permitted reports whether role has privilege on r, either as its owner or
+// through a permission granted to one of its roles.
+func (s *store) permitted(role string, r *resource, privilege string) bool {
+	held := s.memberships(role)
+	if held[r.owner] {
+		return true
+	}
+	for _, p := range r.permissions {
+		if p.privilege == privilege && held[p.role] {
+			return true
+		}
+	}
+	return false
+}
+
+// visible reports whether role can see r, i.e. owns it or has any privilege
+// on it.
+func (s *store) visible(role string, r *resource) bool {
+	held := s.memberships(role)
+	if held[r.owner] || held[r.id] {
+		return true
+	}
+	for _, p := range r.permissions {
+		if held[p.role] {
+			return true
+		}
+	}
+	return false
+}
+
+// permittedRoles returns the roles which have privilege on r.
+func (s *store) permittedRoles(r *resource, privilege string) []string {
+	var roles []string
+	for id := range s.resources {
+		if s.isRole(id) && s.permitted(id, r, privilege) {
+			roles = append(roles, id)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// addGrant grants role to member, unless it is already granted.
+func (s *store) addGrant(g grant) {
+	for _, existing := range s.grants {
+		if existing.role == g.role && existing.member == g.member {
+			return
+		}
+	}
+	s.grants = append(s.grants, g)
+}
+
+func (s *store) revokeGrant(role, member string) {
+	grants := s.grants[:0]
+	for _, g := range s.grants {
+		if g.role != role || g.member != member {
+			grants = append(grants, g)
+		}
+	}
+	s.grants = grants
+}
+
+func (r *resource) addPermission(p permission) {
+	for _, existing := range r.permissions {
+		if existing.privilege == p.privilege && existing.role == p.role {
+			return
+		}
+	}
+	r.permissions = append(r.permissions, p)
+}
+
+func (r *resource) removePermission(privilege, role string) {
+	permissions := r.permissions[:0]
+	for _, p := range r.permissions {
+		if p.privilege != privilege || p.role != role {
+			permissions = append(permissions, p)
+		}
+	}
+	r.permissions = permissions
+}
+
+// delete removes a resource along with its grants and the permissions held
+// by it.
+func (s *store) delete(id string) {
+	delete(s.resources, id)
+
+	grants := s.grants[:0]
+	for _, g := range s.grants {
+		if g.role != id && g.member != id {
+			grants = append(grants, g)
+		}
+	}
+	s.grants = grants
+
+	for _, r := range s.resources {
+		permissions := r.permissions[:0]
+		for _, p := range r.permissions {
+			if p.role != id {
+				permissions = append(permissions, p)
+			}
+		}
+		r.permissions = permissions
+	}
+}
+
+// addSecret stores a new version of the value of a variable.
+func (r *resource) addSecret(value []byte) {
+	r.secrets = append(r.secrets, value)
+	r.secretVersion++
+	if len(r.secrets) > maxSecretVersions {
+		r.secrets = r.secrets[len(r.secrets)-maxSecretVersions:]
+	}
+}
+
+// secret returns the given version of the value of a variable, or the
+// latest one for version 0.
+func (r *resource) secret(version int) ([]byte, bool) {
+	if version == 0 {
+		version = r.secretVersion
+	}
+	index := len(r.secrets) - (r.secretVersion - version) - 1
+	if index < 0 || index >= len(r.secrets) {
+		return nil, false
+	}
+	return r.secrets[index], true
+}
+
+// newSecretString returns a random string for API keys and tokens.
+func newSecretString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("conjurtest: %s", err))
+	}
+	return hex.EncodeToString(b)
+}
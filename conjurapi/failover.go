@@ -0,0 +1,171 @@
+package conjurapi
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// endpoint is a Conjur node which requests can be routed to.
+type endpoint struct {
+	baseURL      string
+	ejectedUntil time.Time
+}
+
+// endpointRouter routes requests between the Conjur leader, at ApplianceURL,
+// and its followers, at FollowerURLs. Reads are sent to the first healthy
+// follower in order, falling back to the next one and eventually to the
+// leader, while writes always go to the leader. A node which fails is ejected
+// for a cool-down period, during which reads skip it.
+type endpointRouter struct {
+	leader    *endpoint
+	followers []*endpoint
+	cooldown  time.Duration
+	mutex     sync.Mutex
+}
+
+func newEndpointRouter(config Config) *endpointRouter {
+	if len(config.FollowerURLs) == 0 {
+		return nil
+	}
+
+	router := &endpointRouter{
+		leader:   &endpoint{baseURL: normalizeBaseURL(config.ApplianceURL)},
+		cooldown: config.GetFailoverCooldown(),
+	}
+	for _, followerURL := range config.FollowerURLs {
+		router.followers = append(router.followers, &endpoint{baseURL: normalizeBaseURL(followerURL)})
+	}
+	return router
+}
+
+// isReadRequest reports whether req can be served by a follower: a GET or
+// HEAD request, or an authentication request.
+func isReadRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		return strings.HasSuffix(req.URL.Path, "/authenticate")
+	}
+	return false
+}
+
+// candidates returns the endpoints to try, in order, for a request.
+func (r *endpointRouter) candidates(req *http.Request) []*endpoint {
+	if !isReadRequest(req) {
+		return []*endpoint{r.leader}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	var healthy, ejected []*endpoint
+	for _, e := range append(r.followers, r.leader) {
+		if now.Before(e.ejectedUntil) {
+			ejected = append(ejected, e)
+		} else {
+			healthy = append(healthy, e)
+		}
+	}
+	// When every node is ejected, try them anyway rather than failing outright
+	return append(healthy, ejected...)
+}
+
+func (r *endpointRouter) eject(e *endpoint) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e.ejectedUntil = time.Now().Add(r.cooldown)
+}
+
+func (r *endpointRouter) restore(e *endpoint) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	e.ejectedUntil = time.Time{}
+}
+
+// isNodeFailure reports whether a response or error means that the node is
+// unavailable, as opposed to rejecting the request.
+func isNodeFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return !isContextError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do sends req through the HTTP client, routing it to a follower when the
+// client is configured with FollowerURLs.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	c.logCloudURL()
+	if c.router == nil {
+		return c.send(req)
+	}
+
+	candidates := c.router.candidates(req)
+	if len(candidates) > 1 {
+		if err := ensureRewindableBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, e := range candidates {
+		routed, err := rebaseRequest(req, c.router.leader.baseURL, e.baseURL)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.send(routed)
+		if !isNodeFailure(resp, err) {
+			c.router.restore(e)
+			return resp, err
+		}
+
+		c.router.eject(e)
+		c.log().Warn("Conjur node is unavailable, ejecting it", "node", e.baseURL, "cooldown", c.router.cooldown)
+		if i == len(candidates)-1 || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			discardResponse(resp)
+		}
+		if err := rewindBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, errors.New("No Conjur node available")
+}
+
+// rebaseRequest returns a copy of req sent to the node at newBase instead of
+// oldBase. Requests which aren't sent to oldBase are returned unchanged.
+func rebaseRequest(req *http.Request, oldBase, newBase string) (*http.Request, error) {
+	if oldBase == newBase {
+		return req, nil
+	}
+
+	rebased, ok := routerURL(req.URL.String()).rebase(oldBase, newBase)
+	if !ok {
+		return req, nil
+	}
+
+	newURL, err := url.Parse(rebased.String())
+	if err != nil {
+		return nil, err
+	}
+
+	routed := req.Clone(req.Context())
+	routed.URL = newURL
+	routed.Host = ""
+	return routed, nil
+}
@@ -0,0 +1,197 @@
+package conjurapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testNode is a fake Conjur node which records the requests it serves and
+// responds with a configurable status.
+type testNode struct {
+	*httptest.Server
+	name   string
+	status int32
+	mutex  sync.Mutex
+	paths  []string
+}
+
+func newTestNode(name string) *testNode {
+	node := &testNode{name: name, status: http.StatusOK}
+	node.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		node.mutex.Lock()
+		node.paths = append(node.paths, r.Method+" "+r.URL.Path)
+		node.mutex.Unlock()
+
+		w.WriteHeader(int(atomic.LoadInt32(&node.status)))
+		if r.URL.Path == "/authn/conjur/alice/authenticate" {
+			w.Write([]byte(sample_token))
+			return
+		}
+		w.Write([]byte(node.name))
+	}))
+	return node
+}
+
+func (n *testNode) setStatus(status int) {
+	atomic.StoreInt32(&n.status, int32(status))
+}
+
+func (n *testNode) received() []string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return append([]string(nil), n.paths...)
+}
+
+func TestClient_Failover(t *testing.T) {
+	newNodes := func(t *testing.T) (leader, follower1, follower2 *testNode) {
+		leader, follower1, follower2 = newTestNode("leader"), newTestNode("follower-1"), newTestNode("follower-2")
+		t.Cleanup(func() {
+			leader.Close()
+			follower1.Close()
+			follower2.Close()
+		})
+		return
+	}
+
+	newClient := func(t *testing.T, leader *testNode, followers ...*testNode) *Client {
+		config := Config{Account: "conjur", ApplianceURL: leader.URL}
+		for _, follower := range followers {
+			config.FollowerURLs = append(config.FollowerURLs, follower.URL)
+		}
+		client, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("Sends reads to the first follower and writes to the leader", func(t *testing.T) {
+		leader, follower1, follower2 := newNodes(t)
+		client := newClient(t, leader, follower1, follower2)
+
+		secret, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "follower-1", string(secret))
+
+		_, err = client.Resources(&ResourceFilter{Kind: "variable"})
+		assert.Error(t, err) // follower-1 doesn't return JSON
+		_, err = client.CheckPermission("conjur:variable:my-var", "execute")
+		assert.NoError(t, err)
+
+		require.NoError(t, client.AddSecret("my-var", "value"))
+		_, err = client.LoadPolicy(PolicyModePost, "root", nil)
+		assert.Error(t, err) // the leader doesn't return JSON
+
+		assert.Equal(t, []string{
+			"GET /secrets/conjur/variable/my-var",
+			"GET /resources/conjur/",
+			"GET /resources/conjur/variable/my-var",
+		}, follower1.received())
+		assert.Equal(t, []string{
+			"POST /secrets/conjur/variable/my-var",
+			"POST /policies/conjur/policy/root",
+		}, leader.received())
+		assert.Empty(t, follower2.received())
+	})
+
+	t.Run("Ejects an unavailable follower for the cool-down period", func(t *testing.T) {
+		leader, follower1, follower2 := newNodes(t)
+		follower1.setStatus(http.StatusServiceUnavailable)
+		client := newClient(t, leader, follower1, follower2)
+
+		for i := 0; i < 2; i++ {
+			secret, err := client.RetrieveSecret("my-var")
+			require.NoError(t, err)
+			assert.Equal(t, "follower-2", string(secret))
+		}
+		assert.Len(t, follower1.received(), 1)
+		assert.Len(t, follower2.received(), 2)
+
+		// Once the cool-down is over, the follower is tried again
+		follower1.setStatus(http.StatusOK)
+		client.router.followers[0].ejectedUntil = time.Now().Add(-time.Second)
+
+		secret, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "follower-1", string(secret))
+		assert.Empty(t, leader.received())
+	})
+
+	t.Run("Falls back to the leader when all followers are unavailable", func(t *testing.T) {
+		leader, follower1, follower2 := newNodes(t)
+		follower1.Close()
+		follower2.setStatus(http.StatusBadGateway)
+		client := newClient(t, leader, follower1, follower2)
+
+		secret, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "leader", string(secret))
+		assert.Len(t, follower2.received(), 1)
+	})
+
+	t.Run("Returns the last failure when every node is unavailable", func(t *testing.T) {
+		leader, follower1, follower2 := newNodes(t)
+		for _, node := range []*testNode{leader, follower1, follower2} {
+			node.setStatus(http.StatusServiceUnavailable)
+		}
+		client := newClient(t, leader, follower1, follower2)
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "leader")
+		assert.Len(t, leader.received(), 1)
+	})
+
+	t.Run("Doesn't fail over when the node rejects the request", func(t *testing.T) {
+		leader, follower1, follower2 := newNodes(t)
+		follower1.setStatus(http.StatusNotFound)
+		client := newClient(t, leader, follower1, follower2)
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.Error(t, err)
+		assert.Empty(t, follower2.received())
+		assert.Empty(t, leader.received())
+	})
+
+	t.Run("Never sends writes to a follower", func(t *testing.T) {
+		leader, follower1, _ := newNodes(t)
+		leader.setStatus(http.StatusServiceUnavailable)
+		client := newClient(t, leader, follower1)
+
+		err := client.AddSecret("my-var", "value")
+		assert.Error(t, err)
+		assert.Empty(t, follower1.received())
+	})
+
+	t.Run("Authenticates with a follower", func(t *testing.T) {
+		leader, follower1, _ := newNodes(t)
+		client, err := NewClientFromKey(
+			Config{Account: "conjur", ApplianceURL: leader.URL, FollowerURLs: []string{follower1.URL}},
+			authn.LoginPair{Login: "alice", APIKey: "api-key"},
+		)
+		require.NoError(t, err)
+
+		_, err = client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"POST /authn/conjur/alice/authenticate",
+			"GET /secrets/conjur/variable/my-var",
+		}, follower1.received())
+		assert.Empty(t, leader.received())
+	})
+
+	t.Run("Sends everything to the leader without followers", func(t *testing.T) {
+		leader, _, _ := newNodes(t)
+		client := newClient(t, leader)
+		assert.Nil(t, client.router)
+
+		secret, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "leader", string(secret))
+	})
+}
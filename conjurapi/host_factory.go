@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -31,6 +32,13 @@ type annotation struct {
 }
 
 func (c *Client) CreateToken(durationStr string, hostFactory string, cidrs []string, count int) ([]HostFactoryTokenResponse, error) {
+	return c.CreateTokenCtx(context.Background(), durationStr, hostFactory, cidrs, count)
+}
+
+// CreateTokenCtx is like CreateToken but uses ctx for cancellation and deadlines.
+func (c *Client) CreateTokenCtx(ctx context.Context, durationStr string, hostFactory string, cidrs []string, count int) (_ []HostFactoryTokenResponse, err error) {
+	ctx, span := c.startSpan(ctx, "CreateToken", "host_factory", hostFactory)
+	defer func() { span.end(err) }()
 
 	data := url.Values{}
 	duration, err := time.ParseDuration(durationStr)
@@ -49,14 +57,14 @@ func (c *Client) CreateToken(durationStr string, hostFactory string, cidrs []str
 	for _, cidr := range cidrs {
 		data.Add("cidr[]", cidr)
 	}
-	return c.createToken(data)
+	return c.createToken(ctx, data)
 }
 
-func (c *Client) createToken(data url.Values) ([]HostFactoryTokenResponse, error) {
+func (c *Client) createToken(ctx context.Context, data url.Values) ([]HostFactoryTokenResponse, error) {
 
 	encodedData := data.Encode()
 
-	req, err := c.CreateTokenRequest(encodedData)
+	req, err := c.CreateTokenRequestCtx(ctx, encodedData)
 	if err != nil {
 		return nil, err
 	}
@@ -79,8 +87,15 @@ func (c *Client) createToken(data url.Values) ([]HostFactoryTokenResponse, error
 }
 
 func (c *Client) DeleteToken(token string) error {
+	return c.DeleteTokenCtx(context.Background(), token)
+}
 
-	req, err := c.DeleteTokenRequest(token)
+// DeleteTokenCtx is like DeleteToken but uses ctx for cancellation and deadlines.
+func (c *Client) DeleteTokenCtx(ctx context.Context, token string) (err error) {
+	ctx, span := c.startSpan(ctx, "DeleteToken", "", "")
+	defer func() { span.end(err) }()
+
+	req, err := c.DeleteTokenRequestCtx(ctx, token)
 	if err != nil {
 		return err
 	}
@@ -93,25 +108,38 @@ func (c *Client) DeleteToken(token string) error {
 }
 
 func (c *Client) CreateHost(id string, token string) (HostFactoryHostResponse, error) {
-	return c.CreateHostWithAnnotations(id, token, nil)
+	return c.CreateHostCtx(context.Background(), id, token)
+}
+
+// CreateHostCtx is like CreateHost but uses ctx for cancellation and deadlines.
+func (c *Client) CreateHostCtx(ctx context.Context, id string, token string) (HostFactoryHostResponse, error) {
+	return c.CreateHostWithAnnotationsCtx(ctx, id, token, nil)
 }
 
 // CreateHostWithAnnotations creates a new host given a Host ID, HostFactory token, and a map of annotations
 func (c *Client) CreateHostWithAnnotations(id string, token string, annotations map[string]string) (HostFactoryHostResponse, error) {
+	return c.CreateHostWithAnnotationsCtx(context.Background(), id, token, annotations)
+}
+
+// CreateHostWithAnnotationsCtx is like CreateHostWithAnnotations but uses ctx for cancellation and deadlines.
+func (c *Client) CreateHostWithAnnotationsCtx(ctx context.Context, id string, token string, annotations map[string]string) (_ HostFactoryHostResponse, err error) {
+	ctx, span := c.startSpan(ctx, "CreateHost", "host", id)
+	defer func() { span.end(err) }()
+
 	data := url.Values{}
 	data.Set("id", id)
 	for name, val := range annotations {
 		data.Add(fmt.Sprintf("annotations[%s]", name), val)
 	}
 
-	return c.createHost(data, token)
+	return c.createHost(ctx, data, token)
 }
 
-func (c *Client) createHost(data url.Values, token string) (HostFactoryHostResponse, error) {
+func (c *Client) createHost(ctx context.Context, data url.Values, token string) (HostFactoryHostResponse, error) {
 
 	var jsonResponse HostFactoryHostResponse
 	encodedData := data.Encode()
-	req, err := c.CreateHostRequest(encodedData, token)
+	req, err := c.CreateHostRequestCtx(ctx, encodedData, token)
 	if err != nil {
 		return jsonResponse, err
 	}
@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -37,17 +38,25 @@ type EnterpriseInfoService struct {
 // or from the root endpoint in Conjur OSS. The version returned corresponds to the Conjur OSS version,
 // which in Conjur Enterprise is the version of the 'possum' service.
 func (c *Client) ServerVersion() (string, error) {
+	return c.ServerVersionCtx(context.Background())
+}
+
+// ServerVersionCtx is like ServerVersion but uses ctx for cancellation and deadlines.
+func (c *Client) ServerVersionCtx(ctx context.Context) (_ string, err error) {
+	ctx, span := c.startSpan(ctx, "ServerVersion", "", "")
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return "", errors.New("Unable to retrieve server version: not supported in Conjur Cloud")
 	}
 
-	info, err := c.EnterpriseServerInfo()
+	info, err := c.EnterpriseServerInfoCtx(ctx)
 	if err == nil {
 		// Return the version of the 'possum' service, which corresponds to the Conjur OSS version
 		return info.Services["possum"].Version, nil
 	}
 
-	version, err := c.ServerVersionFromRoot()
+	version, err := c.ServerVersionFromRootCtx(ctx)
 	if err == nil {
 		return version, nil
 	}
@@ -58,16 +67,24 @@ func (c *Client) ServerVersion() (string, error) {
 // EnterpriseServerInfo retrieves the server information from the '/info' endpoint.
 // This is only available in Conjur Enterprise and will fail with a 404 error in Conjur OSS.
 func (c *Client) EnterpriseServerInfo() (*EnterpriseInfoResponse, error) {
+	return c.EnterpriseServerInfoCtx(context.Background())
+}
+
+// EnterpriseServerInfoCtx is like EnterpriseServerInfo but uses ctx for cancellation and deadlines.
+func (c *Client) EnterpriseServerInfoCtx(ctx context.Context) (_ *EnterpriseInfoResponse, err error) {
+	ctx, span := c.startSpan(ctx, "EnterpriseServerInfo", "", "")
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return nil, errors.New("Unable to retrieve server info: not supported in Conjur Cloud")
 	}
 
-	req, err := c.ServerInfoRequest()
+	req, err := c.ServerInfoRequestCtx(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	// Handle 404 or 401 response, which indicates that the '/info' endpoint is not available (eg. in Conjur OSS)
 	if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnauthorized) {
 		return nil, fmt.Errorf("404 Not Found: Are you using Conjur Enterprise?")
@@ -88,16 +105,24 @@ func (c *Client) EnterpriseServerInfo() (*EnterpriseInfoResponse, error) {
 // this method will parse it from there.
 // In newer Conjur versions, the version is available in a JSON response.
 func (c *Client) ServerVersionFromRoot() (string, error) {
+	return c.ServerVersionFromRootCtx(context.Background())
+}
+
+// ServerVersionFromRootCtx is like ServerVersionFromRoot but uses ctx for cancellation and deadlines.
+func (c *Client) ServerVersionFromRootCtx(ctx context.Context) (_ string, err error) {
+	ctx, span := c.startSpan(ctx, "ServerVersionFromRoot", "", "")
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return "", errors.New("Unable to retrieve server version: not supported in Conjur Cloud")
 	}
 
-	req, err := c.RootRequest()
+	req, err := c.RootRequestCtx(ctx)
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,117 @@
+package conjurapi
+
+import (
+	"context"
+	"io"
+)
+
+// The interfaces below cover the method sets of Client by area, so that code
+// can depend on the part of the API it uses and be tested with a fake, such
+// as those of the conjurfake package, instead of a Conjur server.
+
+// SecretsReader retrieves the values of variables.
+type SecretsReader interface {
+	RetrieveSecret(variableID string) ([]byte, error)
+	RetrieveSecretCtx(ctx context.Context, variableID string) ([]byte, error)
+	RetrieveSecretReader(variableID string) (io.ReadCloser, error)
+	RetrieveSecretReaderCtx(ctx context.Context, variableID string) (io.ReadCloser, error)
+	RetrieveSecretWithVersion(variableID string, version int) ([]byte, error)
+	RetrieveSecretWithVersionCtx(ctx context.Context, variableID string, version int) ([]byte, error)
+	RetrieveSecretWithVersionReader(variableID string, version int) (io.ReadCloser, error)
+	RetrieveSecretWithVersionReaderCtx(ctx context.Context, variableID string, version int) (io.ReadCloser, error)
+	RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error)
+	RetrieveBatchSecretsCtx(ctx context.Context, variableIDs []string) (map[string][]byte, error)
+	RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error)
+	RetrieveBatchSecretsSafeCtx(ctx context.Context, variableIDs []string) (map[string][]byte, error)
+}
+
+// PartialSecretsReader retrieves the values of variables in batches which
+// report the variables that can't be retrieved rather than failing.
+type PartialSecretsReader interface {
+	RetrieveBatchSecretsPartial(variableIDs []string) (map[string][]byte, map[string]error, error)
+	RetrieveBatchSecretsPartialCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error)
+	RetrieveBatchSecretsPartialSafe(variableIDs []string) (map[string][]byte, map[string]error, error)
+	RetrieveBatchSecretsPartialSafeCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error)
+}
+
+// SecretsWriter sets the values of variables.
+type SecretsWriter interface {
+	AddSecret(variableID string, secretValue string) error
+	AddSecretCtx(ctx context.Context, variableID string, secretValue string) error
+}
+
+// PolicyLoader loads, validates and fetches policy.
+type PolicyLoader interface {
+	LoadPolicy(mode PolicyMode, policyID string, policy io.Reader) (*PolicyResponse, error)
+	LoadPolicyCtx(ctx context.Context, mode PolicyMode, policyID string, policy io.Reader) (*PolicyResponse, error)
+	DryRunPolicy(mode PolicyMode, policyID string, policy io.Reader) (*DryRunPolicyResponse, error)
+	DryRunPolicyCtx(ctx context.Context, mode PolicyMode, policyID string, policy io.Reader) (*DryRunPolicyResponse, error)
+	FetchPolicy(policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error)
+	FetchPolicyCtx(ctx context.Context, policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error)
+}
+
+// ResourceBrowser shows resources and checks permissions on them.
+type ResourceBrowser interface {
+	CheckPermission(resourceID string, privilege string) (bool, error)
+	CheckPermissionCtx(ctx context.Context, resourceID string, privilege string) (bool, error)
+	CheckPermissionForRole(resourceID string, roleID string, privilege string) (bool, error)
+	CheckPermissionForRoleCtx(ctx context.Context, resourceID string, roleID string, privilege string) (bool, error)
+	ResourceExists(resourceID string) (bool, error)
+	ResourceExistsCtx(ctx context.Context, resourceID string) (bool, error)
+	Resource(resourceID string) (map[string]interface{}, error)
+	ResourceCtx(ctx context.Context, resourceID string) (map[string]interface{}, error)
+	Resources(filter *ResourceFilter) ([]map[string]interface{}, error)
+	ResourcesCtx(ctx context.Context, filter *ResourceFilter) ([]map[string]interface{}, error)
+	ResourceIDs(filter *ResourceFilter) ([]string, error)
+	ResourceIDsCtx(ctx context.Context, filter *ResourceFilter) ([]string, error)
+	PermittedRoles(resourceID, privilege string) ([]string, error)
+	PermittedRolesCtx(ctx context.Context, resourceID, privilege string) ([]string, error)
+}
+
+// RoleBrowser shows roles, their members and their memberships.
+type RoleBrowser interface {
+	RoleExists(roleID string) (bool, error)
+	RoleExistsCtx(ctx context.Context, roleID string) (bool, error)
+	Role(roleID string) (map[string]interface{}, error)
+	RoleCtx(ctx context.Context, roleID string) (map[string]interface{}, error)
+	RoleMembers(roleID string) ([]map[string]interface{}, error)
+	RoleMembersCtx(ctx context.Context, roleID string) ([]map[string]interface{}, error)
+	RoleMemberships(roleID string) ([]map[string]interface{}, error)
+	RoleMembershipsCtx(ctx context.Context, roleID string) ([]map[string]interface{}, error)
+	RoleMembershipsAll(roleID string) ([]string, error)
+	RoleMembershipsAllCtx(ctx context.Context, roleID string) ([]string, error)
+}
+
+// HostFactory creates host factory tokens and hosts.
+type HostFactory interface {
+	CreateToken(durationStr string, hostFactory string, cidrs []string, count int) ([]HostFactoryTokenResponse, error)
+	CreateTokenCtx(ctx context.Context, durationStr string, hostFactory string, cidrs []string, count int) ([]HostFactoryTokenResponse, error)
+	DeleteToken(token string) error
+	DeleteTokenCtx(ctx context.Context, token string) error
+	CreateHost(id string, token string) (HostFactoryHostResponse, error)
+	CreateHostCtx(ctx context.Context, id string, token string) (HostFactoryHostResponse, error)
+	CreateHostWithAnnotations(id string, token string, annotations map[string]string) (HostFactoryHostResponse, error)
+	CreateHostWithAnnotationsCtx(ctx context.Context, id string, token string, annotations map[string]string) (HostFactoryHostResponse, error)
+}
+
+// AuthenticatorManager enables authenticators, checks their status and
+// lists the OIDC providers.
+type AuthenticatorManager interface {
+	EnableAuthenticator(authenticatorType string, serviceID string, enabled bool) error
+	EnableAuthenticatorCtx(ctx context.Context, authenticatorType string, serviceID string, enabled bool) error
+	AuthenticatorStatus(authenticatorType string, serviceID string) (*AuthenticatorStatusResponse, error)
+	AuthenticatorStatusCtx(ctx context.Context, authenticatorType string, serviceID string) (*AuthenticatorStatusResponse, error)
+	ListOidcProviders() ([]OidcProvider, error)
+	ListOidcProvidersCtx(ctx context.Context) ([]OidcProvider, error)
+}
+
+var (
+	_ SecretsReader        = (*Client)(nil)
+	_ PartialSecretsReader = (*Client)(nil)
+	_ SecretsWriter        = (*Client)(nil)
+	_ PolicyLoader         = (*Client)(nil)
+	_ ResourceBrowser      = (*Client)(nil)
+	_ RoleBrowser          = (*Client)(nil)
+	_ HostFactory          = (*Client)(nil)
+	_ AuthenticatorManager = (*Client)(nil)
+)
@@ -0,0 +1,204 @@
+// Package redact removes credentials and secret values from the requests
+// sent to Conjur and their responses, so that they can be dumped or recorded.
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the credentials and secrets which are redacted.
+const Redacted = "[REDACTED]"
+
+// sensitiveFormKeys are the form and query values which carry credentials.
+var sensitiveFormKeys = map[string]bool{
+	"jwt":           true,
+	"id_token":      true,
+	"code":          true,
+	"code_verifier": true,
+	"password":      true,
+}
+
+// sensitivePathPrefixes are the paths under which the last segment is a
+// credential, such as the host factory token of DELETE
+// /host_factory_tokens/<token>.
+var sensitivePathPrefixes = []string{"/host_factory_tokens/"}
+
+// sensitiveJSONKeys are the fields of JSON responses which carry credentials,
+// such as the API keys of hosts created by a policy or a host factory.
+var sensitiveJSONKeys = map[string]bool{
+	"api_key": true,
+	"token":   true,
+}
+
+// URL returns u without the password of its user info, if any, and with the
+// credentials in its path and the values of sensitive query parameters
+// redacted.
+func URL(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User(redacted.User.Username())
+	}
+	for _, prefix := range sensitivePathPrefixes {
+		// The prefix may follow the '/api' of a Conjur Cloud URL
+		if i := strings.Index(redacted.Path, prefix); i >= 0 && len(redacted.Path) > i+len(prefix) {
+			redacted.Path = redacted.Path[:i+len(prefix)] + Redacted
+			redacted.RawPath = ""
+		}
+	}
+	if query, err := url.ParseQuery(redacted.RawQuery); err == nil && redactForm(query) {
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// redactForm redacts the sensitive values of form, and reports whether there
+// were any.
+func redactForm(form url.Values) bool {
+	redacted := false
+	for key, values := range form {
+		if !sensitiveFormKeys[key] {
+			continue
+		}
+		for i := range values {
+			values[i] = Redacted
+		}
+		redacted = true
+	}
+	return redacted
+}
+
+// RequestBody returns body, the body of req, with the API keys, passwords,
+// tokens and secret values it carries redacted.
+func RequestBody(req *http.Request, body []byte) []byte {
+	switch {
+	case len(body) == 0:
+		return body
+	case hasPathSegment(req.URL.Path, "secrets"):
+		// AddSecret sends the value as is, despite its form Content-Type.
+		return []byte(Redacted)
+	case IsAuthnPath(req.URL.Path):
+		// Only the settings of an authenticator are kept; anything else is an
+		// API key, a password or a token.
+		form, err := url.ParseQuery(string(body))
+		if err != nil || !isFormRequest(req) || !bytes.Contains(body, []byte("=")) {
+			return []byte(Redacted)
+		}
+		for key, values := range form {
+			if key == "enabled" {
+				continue
+			}
+			for i := range values {
+				values[i] = Redacted
+			}
+		}
+		return []byte(form.Encode())
+	case isFormRequest(req):
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return []byte(Redacted)
+		}
+		if redactForm(form) {
+			return []byte(form.Encode())
+		}
+		return body
+	default:
+		return body
+	}
+}
+
+// ResponseBody returns body, the body of resp, with the access tokens, API
+// keys and secret values it carries redacted. Error responses are kept.
+func ResponseBody(req *http.Request, resp *http.Response, body []byte) []byte {
+	switch {
+	case len(body) == 0 || resp.StatusCode >= 300:
+		return body
+	case strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/secrets"):
+		// Batch retrieval: the IDs of the variables are kept.
+		return redactJSON(body, func(string) bool { return true })
+	case hasPathSegment(req.URL.Path, "secrets"):
+		return []byte(Redacted)
+	case IsAuthnPath(req.URL.Path) && !strings.HasSuffix(req.URL.Path, "/status"):
+		// Access tokens, and API keys from login and rotation.
+		return []byte(Redacted)
+	default:
+		return redactJSON(body, func(key string) bool { return sensitiveJSONKeys[key] })
+	}
+}
+
+// redactJSON redacts the values of the fields of body, at any depth, for
+// which sensitive returns true. A body which isn't a JSON object or array is
+// redacted entirely when sensitive returns true for the empty key.
+func redactJSON(body []byte, sensitive func(key string) bool) []byte {
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		if sensitive("") {
+			return []byte(Redacted)
+		}
+		return body
+	}
+
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		if sensitive("") {
+			return []byte(Redacted)
+		}
+		return body
+	}
+
+	redacted, err := json.Marshal(redactJSONValue(value, sensitive))
+	if err != nil {
+		return []byte(Redacted)
+	}
+	return redacted
+}
+
+func redactJSONValue(value interface{}, sensitive func(key string) bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if sensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactJSONValue(field, sensitive)
+			}
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = redactJSONValue(element, sensitive)
+		}
+	}
+	return value
+}
+
+func isFormRequest(req *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	return mediaType == "application/x-www-form-urlencoded"
+}
+
+// IsAuthnPath reports whether path is served by one of the authenticators,
+// e.g. /authn/myorg/admin/authenticate or /authn-jwt/myservice/myorg/authenticate.
+func IsAuthnPath(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "authn" || strings.HasPrefix(segment, "authn-") {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPathSegment(path string, segment string) bool {
+	for _, s := range strings.Split(path, "/") {
+		if s == segment {
+			return true
+		}
+	}
+	return false
+}
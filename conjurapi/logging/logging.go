@@ -11,8 +11,15 @@ import (
 // its messages is controlled by the environment variable
 // CONJURAPI_LOG. CONJRAPI_LOG can be "stdout", "stderr", or the path
 // to a file. If it's a path, the file's contents will be overwritten
-// with new messages. If the environment variable is not set, logging
-// is disabled.
+// with new messages. If the environment variable is not set, only
+// messages at Info level and above are written to stderr.
+//
+// Messages are written as text, or as JSON when CONJURAPI_LOG_FORMAT is
+// "json". With CONJURAPI_LOG, the level defaults to Debug and can be set
+// with CONJURAPI_LOG_LEVEL, e.g. "warn".
+//
+// Clients log through ApiLog unless they are given their own logger, see
+// Client.SetLogger.
 var ApiLog = logrus.New()
 var fatalFn = logrus.Fatalf
 
@@ -21,6 +28,12 @@ func init() {
 }
 
 func initLogger() {
+	if os.Getenv("CONJURAPI_LOG_FORMAT") == "json" {
+		ApiLog.Formatter = &logrus.JSONFormatter{}
+	} else {
+		ApiLog.Formatter = &logrus.TextFormatter{}
+	}
+
 	dest, ok := os.LookupEnv("CONJURAPI_LOG")
 	if !ok {
 		return
@@ -45,4 +58,12 @@ func initLogger() {
 
 	ApiLog.Out = out
 	ApiLog.Level = logrus.DebugLevel
+	if levelStr, ok := os.LookupEnv("CONJURAPI_LOG_LEVEL"); ok {
+		level, err := logrus.ParseLevel(levelStr)
+		if err != nil {
+			logrus.Warnf("Invalid CONJURAPI_LOG_LEVEL %q, using debug: %v", levelStr, err)
+		} else {
+			ApiLog.Level = level
+		}
+	}
 }
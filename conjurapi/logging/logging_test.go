@@ -41,6 +41,29 @@ func TestInitLogger(t *testing.T) {
 		assert.Equal(t, logrus.DebugLevel, ApiLog.Level)
 	})
 
+	t.Run("CONJURAPI_LOG_LEVEL", func(t *testing.T) {
+		defer os.Unsetenv("CONJURAPI_LOG_LEVEL")
+		os.Setenv("CONJURAPI_LOG", "stderr")
+		os.Setenv("CONJURAPI_LOG_LEVEL", "warn")
+		initLogger()
+		assert.Equal(t, logrus.WarnLevel, ApiLog.Level)
+
+		os.Setenv("CONJURAPI_LOG_LEVEL", "verbose")
+		initLogger()
+		assert.Equal(t, logrus.DebugLevel, ApiLog.Level)
+	})
+
+	t.Run("CONJURAPI_LOG_FORMAT", func(t *testing.T) {
+		defer os.Unsetenv("CONJURAPI_LOG_FORMAT")
+		os.Unsetenv("CONJURAPI_LOG")
+		initLogger()
+		assert.IsType(t, &logrus.TextFormatter{}, ApiLog.Formatter)
+
+		os.Setenv("CONJURAPI_LOG_FORMAT", "json")
+		initLogger()
+		assert.IsType(t, &logrus.JSONFormatter{}, ApiLog.Formatter)
+	})
+
 	t.Run("file in nonexistent directory", func(t *testing.T) {
 		tmpFile := "/nonexistent/logfile.log"
 		fatalCalled := false
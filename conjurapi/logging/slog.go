@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewLogger returns a structured logger which writes JSON messages at level
+// and above to w, suitable for Client.SetLogger.
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// Default returns a structured logger which writes to ApiLog, so that its
+// output follows the CONJURAPI_LOG environment variables. It is used by
+// clients which weren't given their own logger.
+func Default() *slog.Logger {
+	return defaultLogger
+}
+
+var defaultLogger = slog.New(&apiLogHandler{})
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx which carries logger. Clients pass their
+// logger this way to the code which runs on their behalf, such as the
+// response helpers, the TLS configuration and the authenticators.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or Default if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return Default()
+}
+
+// apiLogHandler is a slog.Handler which forwards records to ApiLog.
+type apiLogHandler struct {
+	attrs  []slog.Attr
+	prefix string
+}
+
+func (h *apiLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return ApiLog.IsLevelEnabled(logrusLevel(level))
+}
+
+func (h *apiLogHandler) Handle(_ context.Context, record slog.Record) error {
+	fields := logrus.Fields{}
+	for _, attr := range h.attrs {
+		addField(fields, "", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		addField(fields, h.prefix, attr)
+		return true
+	})
+
+	ApiLog.WithFields(fields).WithTime(record.Time).Log(logrusLevel(record.Level), record.Message)
+	return nil
+}
+
+func (h *apiLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	prefixed := make([]slog.Attr, 0, len(h.attrs)+len(attrs))
+	prefixed = append(prefixed, h.attrs...)
+	for _, attr := range attrs {
+		prefixed = append(prefixed, slog.Attr{Key: h.prefix + attr.Key, Value: attr.Value})
+	}
+	return &apiLogHandler{attrs: prefixed, prefix: h.prefix}
+}
+
+func (h *apiLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &apiLogHandler{attrs: h.attrs, prefix: h.prefix + name + "."}
+}
+
+func addField(fields logrus.Fields, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		if attr.Key != "" {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, groupAttr := range value.Group() {
+			addField(fields, groupPrefix, groupAttr)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	fields[prefix+attr.Key] = value.Any()
+}
+
+func logrusLevel(level slog.Level) logrus.Level {
+	switch {
+	case level >= slog.LevelError:
+		return logrus.ErrorLevel
+	case level >= slog.LevelWarn:
+		return logrus.WarnLevel
+	case level >= slog.LevelInfo:
+		return logrus.InfoLevel
+	default:
+		return logrus.DebugLevel
+	}
+}
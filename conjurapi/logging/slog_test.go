@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, slog.LevelInfo)
+
+	logger.Debug("hidden")
+	logger.Info("Reloaded Conjur SSL cert", "path", "/etc/conjur.pem")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "Reloaded Conjur SSL cert", record["msg"])
+	assert.Equal(t, "/etc/conjur.pem", record["path"])
+}
+
+func TestDefault(t *testing.T) {
+	var buf bytes.Buffer
+	ApiLog = logrus.New()
+	ApiLog.Out = &buf
+	ApiLog.Formatter = &logrus.JSONFormatter{}
+	ApiLog.Level = logrus.InfoLevel
+
+	t.Run("Forwards records to ApiLog", func(t *testing.T) {
+		buf.Reset()
+		Default().Warn("Conjur node is unavailable", "node", "https://follower", "status", 503)
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "warning", record["level"])
+		assert.Equal(t, "Conjur node is unavailable", record["msg"])
+		assert.Equal(t, "https://follower", record["node"])
+		assert.EqualValues(t, 503, record["status"])
+	})
+
+	t.Run("Honors the ApiLog level", func(t *testing.T) {
+		buf.Reset()
+		Default().Debug("hidden")
+		assert.Empty(t, buf.String())
+		assert.False(t, Default().Enabled(context.Background(), slog.LevelDebug))
+	})
+
+	t.Run("Prefixes attributes with their groups", func(t *testing.T) {
+		buf.Reset()
+		Default().With("client", "a").WithGroup("request").Info("sent", "method", "GET", slog.Group("response", "status", 200))
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		assert.Equal(t, "a", record["client"])
+		assert.Equal(t, "GET", record["request.method"])
+		assert.EqualValues(t, 200, record["request.response.status"])
+	})
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, slog.LevelInfo)
+
+	assert.Equal(t, Default(), FromContext(context.Background()))
+	assert.Equal(t, logger, FromContext(NewContext(context.Background(), logger)))
+	assert.Equal(t, Default(), FromContext(NewContext(context.Background(), nil)))
+}
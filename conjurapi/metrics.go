@@ -0,0 +1,104 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+)
+
+// Metrics records measurements of the client's activity, e.g. to export them
+// to Prometheus with the conjurapi/prometheus package. Implementations must be
+// safe for concurrent use.
+type Metrics interface {
+	// ObserveRequest records a request sent to Conjur on behalf of operation,
+	// e.g. "RetrieveSecret", with the HTTP status of the response, or 0 if none
+	// was received, and how long it took. Retries are recorded separately.
+	ObserveRequest(operation string, statusCode int, duration time.Duration)
+	// ObserveOperationError records an operation which failed, with the code
+	// returned by Conjur, e.g. "not_found", or the HTTP status when Conjur
+	// didn't give one. See ErrorCode.
+	ObserveOperationError(operation string, errorCode string)
+	// ObserveTokenRefresh records an attempt to obtain a new access token,
+	// with the age of the token it replaces, or 0 if there was none.
+	ObserveTokenRefresh(tokenAge time.Duration, err error)
+	// ObserveCacheLookup records whether a lookup in one of the client's
+	// caches, e.g. "token", was served from the cache.
+	ObserveCacheLookup(cache string, hit bool)
+}
+
+// SetMetrics sets where the client records its metrics. A nil Metrics, the
+// default, disables them.
+func (c *Client) SetMetrics(metrics Metrics) {
+	c.metrics = metrics
+}
+
+// ErrorCode returns the code under which Metrics records err: the Conjur
+// error code, the HTTP status of a Conjur error without one, "canceled" or
+// "deadline_exceeded" for context errors, and "error" otherwise.
+func ErrorCode(err error) string {
+	var cerr *response.ConjurError
+	switch {
+	case errors.As(err, &cerr) && cerr.Details != nil && cerr.Details.Code != "":
+		return cerr.Details.Code
+	case cerr != nil:
+		return strconv.Itoa(cerr.Code)
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+	default:
+		return "error"
+	}
+}
+
+type operationKey struct{}
+
+// withOperation returns a copy of ctx which attributes requests to operation.
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// operationFromContext returns the operation which requests made with ctx
+// are attributed to, or "SubmitRequest" for requests built by the caller.
+func operationFromContext(ctx context.Context) string {
+	if operation, ok := ctx.Value(operationKey{}).(string); ok {
+		return operation
+	}
+	return "SubmitRequest"
+}
+
+func (c *Client) observeRequest(ctx context.Context, statusCode int, start time.Time) {
+	if c.metrics != nil {
+		c.metrics.ObserveRequest(operationFromContext(ctx), statusCode, time.Since(start))
+	}
+}
+
+// observeConjurError returns the function which records the errors returned by
+// Conjur to the requests made with ctx, when the response helpers check them.
+func (c *Client) observeConjurError(ctx context.Context) func(*response.ConjurError) {
+	operation := operationFromContext(ctx)
+	return func(cerr *response.ConjurError) {
+		c.metrics.ObserveOperationError(operation, ErrorCode(cerr))
+	}
+}
+
+func (c *Client) observeTokenRefresh(stale *authn.AuthnToken, err error) {
+	if c.metrics == nil {
+		return
+	}
+	var age time.Duration
+	if stale != nil && !stale.IssuedAt().IsZero() {
+		age = time.Since(stale.IssuedAt())
+	}
+	c.metrics.ObserveTokenRefresh(age, err)
+}
+
+func (c *Client) observeCacheLookup(cache string, hit bool) {
+	if c.metrics != nil {
+		c.metrics.ObserveCacheLookup(cache, hit)
+	}
+}
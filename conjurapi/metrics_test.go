@@ -0,0 +1,171 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+)
+
+// recordingMetrics records the observations made by the client.
+type recordingMetrics struct {
+	mutex        sync.Mutex
+	requests     []string
+	errors       []string
+	tokenRefresh []error
+	tokenAges    []time.Duration
+	cacheLookups []string
+}
+
+func (m *recordingMetrics) ObserveRequest(operation string, statusCode int, duration time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.requests = append(m.requests, fmt.Sprintf("%s %d", operation, statusCode))
+}
+
+func (m *recordingMetrics) ObserveOperationError(operation string, errorCode string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.errors = append(m.errors, operation+" "+errorCode)
+}
+
+func (m *recordingMetrics) ObserveTokenRefresh(tokenAge time.Duration, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.tokenRefresh = append(m.tokenRefresh, err)
+	m.tokenAges = append(m.tokenAges, tokenAge)
+}
+
+func (m *recordingMetrics) ObserveCacheLookup(cache string, hit bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.cacheLookups = append(m.cacheLookups, fmt.Sprintf("%s %t", cache, hit))
+}
+
+func TestClient_Metrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/authenticate"):
+			w.Write([]byte(sample_token))
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"not_found","message":"Variable 'missing' not found"}}`))
+		default:
+			w.Write([]byte("secret"))
+		}
+	}))
+	defer server.Close()
+
+	newClient := func(t *testing.T) (*Client, *recordingMetrics) {
+		client, err := NewClientFromKey(
+			Config{Account: "conjur", ApplianceURL: server.URL},
+			authn.LoginPair{Login: "alice", APIKey: "api-key"},
+		)
+		require.NoError(t, err)
+		metrics := &recordingMetrics{}
+		client.SetMetrics(metrics)
+		return client, metrics
+	}
+
+	t.Run("Records requests by operation and status code", func(t *testing.T) {
+		client, metrics := newClient(t)
+
+		_, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		_, err = client.RetrieveSecret("missing")
+		require.Error(t, err)
+
+		assert.Equal(t, []string{
+			"Authenticate 200",
+			"RetrieveSecret 200",
+			"RetrieveSecret 404",
+		}, metrics.requests)
+		assert.Equal(t, []string{"RetrieveSecret not_found"}, metrics.errors)
+	})
+
+	t.Run("Attributes requests built by the caller to SubmitRequest", func(t *testing.T) {
+		client, metrics := newClient(t)
+
+		req, err := client.RetrieveSecretRequest("my-var")
+		require.NoError(t, err)
+		_, err = client.SubmitRequest(req)
+		require.NoError(t, err)
+
+		assert.Equal(t, "SubmitRequest 200", metrics.requests[len(metrics.requests)-1])
+	})
+
+	t.Run("Records errors checked by the response helpers", func(t *testing.T) {
+		client, metrics := newClient(t)
+
+		req, err := client.RetrieveSecretRequest("missing")
+		require.NoError(t, err)
+		resp, err := client.SubmitRequest(req)
+		require.NoError(t, err)
+		_, err = response.DataResponse(resp)
+		require.Error(t, err)
+
+		assert.Equal(t, []string{"SubmitRequest not_found"}, metrics.errors)
+	})
+
+	t.Run("Records token refreshes and token cache lookups", func(t *testing.T) {
+		client, metrics := newClient(t)
+
+		for i := 0; i < 3; i++ {
+			_, err := client.RetrieveSecret("my-var")
+			require.NoError(t, err)
+		}
+		require.NoError(t, client.ForceRefreshToken())
+
+		assert.Equal(t, []error{nil, nil}, metrics.tokenRefresh)
+		// The first token replaces none, the second replaces a token issued in 2017
+		assert.Zero(t, metrics.tokenAges[0])
+		assert.Greater(t, metrics.tokenAges[1], 24*time.Hour)
+		assert.Equal(t, []string{"token false", "token true", "token true"}, metrics.cacheLookups)
+	})
+
+	t.Run("Records failed token refreshes", func(t *testing.T) {
+		client, metrics := newClient(t)
+		client.SetAuthenticator(&authn.APIKeyAuthenticator{
+			Authenticate: func(authn.LoginPair) ([]byte, error) {
+				return nil, errors.New("401 Unauthorized")
+			},
+		})
+
+		_, err := client.RetrieveSecret("my-var")
+		require.Error(t, err)
+		require.Len(t, metrics.tokenRefresh, 1)
+		assert.EqualError(t, metrics.tokenRefresh[0], "401 Unauthorized")
+		assert.Equal(t, []string{"RetrieveSecret error"}, metrics.errors)
+	})
+}
+
+func TestErrorCode(t *testing.T) {
+	testCases := []struct {
+		err      error
+		expected string
+	}{
+		{&response.ConjurError{Code: 404, Details: &response.ConjurErrorDetails{Code: "not_found"}}, "not_found"},
+		{&response.ConjurError{Code: 502}, "502"},
+		{fmt.Errorf("wrapped: %w", &response.ConjurError{Code: 403}), "403"},
+		{context.Canceled, "canceled"},
+		{fmt.Errorf("Get: %w", context.DeadlineExceeded), "deadline_exceeded"},
+		{errors.New("connection refused"), "error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ErrorCode(tc.err))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package conjurapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+)
+
+// RequestHandler sends a request to Conjur and returns its response.
+type RequestHandler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the RequestHandler which sends requests to Conjur. It can
+// modify the request before calling next, inspect or replace the response
+// afterwards, or respond without calling next at all.
+type Middleware func(next RequestHandler) RequestHandler
+
+// Use adds middleware around every request sent by the client, including
+// authentication requests and those which don't need an access token. The
+// first middleware added is the outermost. Requests reach the middleware
+// with their Authorization header set, and each retry or failover attempt
+// goes through the chain again.
+//
+// Use isn't safe to call concurrently with requests, so add middleware
+// before sharing the client between goroutines.
+func (c *Client) Use(middleware ...Middleware) {
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// send sends req through the middleware chain and then the HTTP client.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	// The transport and the response helpers log with the client's logger
+	req = req.WithContext(logging.NewContext(req.Context(), c.log()))
+	if c.metrics != nil {
+		req = req.WithContext(response.WithErrorObserver(req.Context(), c.observeConjurError(req.Context())))
+	}
+	c.traceRequest(req)
+	start := time.Now()
+
+	handler := RequestHandler(c.httpClient.Do)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		handler = c.middleware[i](handler)
+	}
+	resp, err := handler(req)
+	c.traceResponse(req, resp)
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	c.observeRequest(req.Context(), statusCode, start)
+	return resp, err
+}
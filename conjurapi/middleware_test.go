@@ -0,0 +1,172 @@
+package conjurapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// headerMiddleware sets a request header and records the requests it sees.
+func headerMiddleware(name, value string, seen *[]string, mutex *sync.Mutex) Middleware {
+	return func(next RequestHandler) RequestHandler {
+		return func(req *http.Request) (*http.Response, error) {
+			mutex.Lock()
+			*seen = append(*seen, value+" "+req.Method+" "+req.URL.Path)
+			mutex.Unlock()
+
+			req.Header.Add(name, value)
+			return next(req)
+		}
+	}
+}
+
+// headerValues returns the values of the named header of each request
+// received by server, joined with commas.
+func headerValues(server *conjurtest.Server, name string) []string {
+	values := []string{}
+	for _, r := range server.Requests() {
+		values = append(values, strings.Join(r.Header.Values(name), ","))
+	}
+	return values
+}
+
+func TestClient_Use(t *testing.T) {
+	t.Run("Runs middleware in the order it was added", func(t *testing.T) {
+		server := newTestServer(t)
+		client := newTestServerClient(t, server)
+
+		var seen []string
+		var mutex sync.Mutex
+		client.Use(
+			headerMiddleware("X-Test", "outer", &seen, &mutex),
+			headerMiddleware("X-Test", "inner", &seen, &mutex),
+		)
+
+		_, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			"outer POST /authn/conjur/admin/authenticate",
+			"inner POST /authn/conjur/admin/authenticate",
+			"outer GET /secrets/conjur/variable/my-var",
+			"inner GET /secrets/conjur/variable/my-var",
+		}, seen)
+		assert.Equal(t, []string{"outer,inner", "outer,inner"}, headerValues(server, "X-Test"))
+	})
+
+	t.Run("Wraps authentication and unauthenticated requests", func(t *testing.T) {
+		server := newTestServer(t)
+		client := newTestServerClient(t, server)
+
+		var seen []string
+		var mutex sync.Mutex
+		client.Use(headerMiddleware("X-Test", "audit", &seen, &mutex))
+
+		_, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		_, err = client.Login("admin", server.APIKey("user:admin"))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"audit POST /authn/conjur/admin/authenticate",
+			"audit GET /secrets/conjur/variable/my-var",
+			"audit GET /authn/conjur/login",
+		}, seen)
+		assert.Equal(t, []string{"audit", "audit", "audit"}, headerValues(server, "X-Test"))
+	})
+
+	t.Run("Sees the Authorization header", func(t *testing.T) {
+		client := newTestServerClient(t, newTestServer(t))
+
+		var authorization string
+		client.Use(func(next RequestHandler) RequestHandler {
+			return func(req *http.Request) (*http.Response, error) {
+				authorization = req.Header.Get("Authorization")
+				return next(req)
+			}
+		})
+
+		_, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(authorization, "Token token="))
+	})
+
+	t.Run("Can respond without sending the request", func(t *testing.T) {
+		server := newTestServer(t)
+		client := newTestServerClient(t, server)
+		require.NoError(t, client.RefreshToken())
+		client.Use(func(next RequestHandler) RequestHandler {
+			return func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader("stubbed")),
+					Request:    req,
+				}, nil
+			}
+		})
+
+		secret, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "stubbed", string(secret))
+		assert.Zero(t, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Runs again for each retry", func(t *testing.T) {
+		client := newRetryTestClient(t, newTestServer(t), fastRetryPolicy())
+		require.NoError(t, client.RefreshToken())
+
+		// Inject a failure into the first attempt
+		var calls int32
+		client.Use(func(next RequestHandler) RequestHandler {
+			return func(req *http.Request) (*http.Response, error) {
+				if atomic.AddInt32(&calls, 1) == 1 {
+					return &http.Response{
+						StatusCode: http.StatusServiceUnavailable,
+						Status:     "503 Service Unavailable",
+						Body:       http.NoBody,
+						Request:    req,
+					}, nil
+				}
+				return next(req)
+			}
+		})
+
+		secret, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "my-secret", string(secret))
+		assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Uses the HTTP client set with SetHttpClient", func(t *testing.T) {
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: "http://conjur.example.com"}, sample_token)
+		require.NoError(t, err)
+		client.SetHttpClient(&http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("from transport")), Request: req}, nil
+		})})
+
+		var called bool
+		client.Use(func(next RequestHandler) RequestHandler {
+			return func(req *http.Request) (*http.Response, error) {
+				called = true
+				return next(req)
+			}
+		})
+
+		secret, err := client.RetrieveSecret("my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "from transport", string(secret))
+		assert.True(t, called)
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -1,12 +1,19 @@
 package conjurapi
 
 import (
+	"encoding/base64"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/stretchr/testify/require"
 )
 
 // Creates a Conjur client that points towards a mock Conjur server.
@@ -70,6 +77,37 @@ func createMockConjurClient(t *testing.T) (*httptest.Server, *Client) {
 	return mockConjurServer, client
 }
 
+// newTestServer starts a fake Conjur server for the conjur account, with a
+// variable my-var whose value is "my-secret". It is closed when the test ends.
+func newTestServer(t *testing.T, options ...conjurtest.Option) *conjurtest.Server {
+	server := conjurtest.NewServer("conjur", options...)
+	t.Cleanup(server.Close)
+	require.NoError(t, server.LoadPolicy("root", "- !variable my-var"))
+	require.NoError(t, server.AddSecret("variable:my-var", "my-secret"))
+	return server
+}
+
+// newTestServerClient returns a client which authenticates with server as
+// user:admin.
+func newTestServerClient(t *testing.T, server *conjurtest.Server) *Client {
+	client, err := NewClientFromKey(
+		Config{Account: server.Account(), ApplianceURL: server.URL, CredentialStorage: CredentialStorageNone},
+		authn.LoginPair{Login: "admin", APIKey: server.APIKey("user:admin")},
+	)
+	require.NoError(t, err)
+	return client
+}
+
+// makeTestToken returns a Conjur access token for alice, issued at issued and
+// valid for lifetime, whose signature is unique to n.
+func makeTestToken(n int, issued time.Time, lifetime time.Duration) string {
+	payload := fmt.Sprintf(`{"sub":"alice","iat":%d,"exp":%d}`, issued.Unix(), issued.Add(lifetime).Unix())
+	return fmt.Sprintf(
+		`{"protected":"e30=","payload":"%s","signature":"signature-%d"}`,
+		base64.StdEncoding.EncodeToString([]byte(payload)), n,
+	)
+}
+
 var mockEnterpriseInfo = `{
   "release": "13.5.0",
   "version": "5.19.0-9",
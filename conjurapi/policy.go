@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -70,7 +71,15 @@ type DryRunPolicyResponse struct {
 //
 // The required permission depends on the mode.
 func (c *Client) LoadPolicy(mode PolicyMode, policyID string, policy io.Reader) (*PolicyResponse, error) {
-	req, err := c.LoadPolicyRequest(mode, policyID, policy, false)
+	return c.LoadPolicyCtx(context.Background(), mode, policyID, policy)
+}
+
+// LoadPolicyCtx is like LoadPolicy but uses ctx for cancellation and deadlines.
+func (c *Client) LoadPolicyCtx(ctx context.Context, mode PolicyMode, policyID string, policy io.Reader) (_ *PolicyResponse, err error) {
+	ctx, span := c.startSpan(ctx, "LoadPolicy", "policy", policyID)
+	defer func() { span.end(err) }()
+
+	req, err := c.LoadPolicyRequestCtx(ctx, mode, policyID, policy, false)
 	if err != nil {
 		return nil, err
 	}
@@ -85,15 +94,23 @@ func (c *Client) LoadPolicy(mode PolicyMode, policyID string, policy io.Reader)
 }
 
 func (c *Client) DryRunPolicy(mode PolicyMode, policyID string, policy io.Reader) (*DryRunPolicyResponse, error) {
+	return c.DryRunPolicyCtx(context.Background(), mode, policyID, policy)
+}
+
+// DryRunPolicyCtx is like DryRunPolicy but uses ctx for cancellation and deadlines.
+func (c *Client) DryRunPolicyCtx(ctx context.Context, mode PolicyMode, policyID string, policy io.Reader) (_ *DryRunPolicyResponse, err error) {
+	ctx, span := c.startSpan(ctx, "DryRunPolicy", "policy", policyID)
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return nil, errors.New("Policy Dry Run is not supported in Conjur Cloud")
 	}
-	err := c.VerifyMinServerVersion("1.21.1")
+	err = c.VerifyMinServerVersionCtx(ctx, "1.21.1")
 	if err != nil {
 		return nil, fmt.Errorf("Policy Dry Run is not supported in Conjur versions older than 1.21.1")
 	}
 
-	req, err := c.LoadPolicyRequest(mode, policyID, policy, true)
+	req, err := c.LoadPolicyRequestCtx(ctx, mode, policyID, policy, true)
 	if err != nil {
 		return nil, err
 	}
@@ -109,15 +126,23 @@ func (c *Client) DryRunPolicy(mode PolicyMode, policyID string, policy io.Reader
 
 // FetchPolicy creates a request to fetch policy from the system
 func (c *Client) FetchPolicy(policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) ([]byte, error) {
+	return c.FetchPolicyCtx(context.Background(), policyID, returnJSON, policyTreeDepth, sizeLimit)
+}
+
+// FetchPolicyCtx is like FetchPolicy but uses ctx for cancellation and deadlines.
+func (c *Client) FetchPolicyCtx(ctx context.Context, policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "FetchPolicy", "policy", policyID)
+	defer func() { span.end(err) }()
+
 	if isConjurCloudURL(c.config.ApplianceURL) {
 		return nil, errors.New("Policy Fetch is not supported in Conjur Cloud")
 	}
-	err := c.VerifyMinServerVersion("1.21.1")
+	err = c.VerifyMinServerVersionCtx(ctx, "1.21.1")
 	if err != nil {
 		return nil, fmt.Errorf("Policy Fetch is not supported in Conjur versions older than 1.21.1")
 	}
 
-	req, err := c.fetchPolicyRequest(policyID, returnJSON, policyTreeDepth, sizeLimit)
+	req, err := c.fetchPolicyRequest(ctx, policyID, returnJSON, policyTreeDepth, sizeLimit)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,115 @@
+// Package prometheus exports the metrics of a conjurapi.Client to Prometheus.
+//
+//	metrics, err := prometheus.NewMetrics(prom.DefaultRegisterer)
+//	if err != nil {
+//		return err
+//	}
+//	client.SetMetrics(metrics)
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "conjur"
+
+// Metrics implements conjurapi.Metrics with Prometheus collectors:
+//
+//   - conjur_requests_total and conjur_request_duration_seconds, by operation
+//     and HTTP status code, "none" when no response was received
+//   - conjur_operation_errors_total, by operation and error code
+//   - conjur_token_refreshes_total, by result, "success" or "failure"
+//   - conjur_token_age_at_refresh_seconds
+//   - conjur_cache_lookups_total, by cache and result, "hit" or "miss"
+type Metrics struct {
+	requests     *prom.CounterVec
+	duration     *prom.HistogramVec
+	errors       *prom.CounterVec
+	tokenRefresh *prom.CounterVec
+	tokenAge     prom.Histogram
+	cacheLookups *prom.CounterVec
+}
+
+// NewMetrics creates the collectors and registers them with registerer.
+func NewMetrics(registerer prom.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "requests_total",
+			Help:      "Requests sent to Conjur.",
+		}, []string{"operation", "code"}),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the requests sent to Conjur.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"operation", "code"}),
+		errors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "operation_errors_total",
+			Help:      "Conjur client operations which failed.",
+		}, []string{"operation", "error_code"}),
+		tokenRefresh: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "token_refreshes_total",
+			Help:      "Attempts to obtain a new Conjur access token.",
+		}, []string{"result"}),
+		tokenAge: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "token_age_at_refresh_seconds",
+			Help:      "Age of the Conjur access token when it is replaced.",
+			Buckets:   prom.LinearBuckets(60, 60, 8),
+		}),
+		cacheLookups: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Lookups in the caches of the Conjur client.",
+		}, []string{"cache", "result"}),
+	}
+
+	for _, collector := range []prom.Collector{m.requests, m.duration, m.errors, m.tokenRefresh, m.tokenAge, m.cacheLookups} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// ObserveRequest implements conjurapi.Metrics.
+func (m *Metrics) ObserveRequest(operation string, statusCode int, duration time.Duration) {
+	code := "none"
+	if statusCode != 0 {
+		code = strconv.Itoa(statusCode)
+	}
+	m.requests.WithLabelValues(operation, code).Inc()
+	m.duration.WithLabelValues(operation, code).Observe(duration.Seconds())
+}
+
+// ObserveOperationError implements conjurapi.Metrics.
+func (m *Metrics) ObserveOperationError(operation string, errorCode string) {
+	m.errors.WithLabelValues(operation, errorCode).Inc()
+}
+
+// ObserveTokenRefresh implements conjurapi.Metrics.
+func (m *Metrics) ObserveTokenRefresh(tokenAge time.Duration, err error) {
+	if err != nil {
+		m.tokenRefresh.WithLabelValues("failure").Inc()
+		return
+	}
+	m.tokenRefresh.WithLabelValues("success").Inc()
+	if tokenAge > 0 {
+		m.tokenAge.Observe(tokenAge.Seconds())
+	}
+}
+
+// ObserveCacheLookup implements conjurapi.Metrics.
+func (m *Metrics) ObserveCacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
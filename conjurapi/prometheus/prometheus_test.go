@@ -0,0 +1,78 @@
+package prometheus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+var _ conjurapi.Metrics = (*Metrics)(nil)
+
+func TestMetrics(t *testing.T) {
+	newMetrics := func(t *testing.T) *Metrics {
+		metrics, err := NewMetrics(prom.NewRegistry())
+		require.NoError(t, err)
+		return metrics
+	}
+
+	t.Run("Records requests by operation and status code", func(t *testing.T) {
+		metrics := newMetrics(t)
+		metrics.ObserveRequest("RetrieveSecret", 200, 10*time.Millisecond)
+		metrics.ObserveRequest("RetrieveSecret", 200, 20*time.Millisecond)
+		metrics.ObserveRequest("RetrieveSecret", 0, time.Second)
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(metrics.requests.WithLabelValues("RetrieveSecret", "200")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.requests.WithLabelValues("RetrieveSecret", "none")))
+		assert.Equal(t, 2, testutil.CollectAndCount(metrics.duration))
+	})
+
+	t.Run("Records operation errors by code", func(t *testing.T) {
+		metrics := newMetrics(t)
+		metrics.ObserveOperationError("RetrieveSecret", "not_found")
+
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.errors.WithLabelValues("RetrieveSecret", "not_found")))
+	})
+
+	t.Run("Records token refreshes", func(t *testing.T) {
+		metrics := newMetrics(t)
+		metrics.ObserveTokenRefresh(0, nil)
+		metrics.ObserveTokenRefresh(7*time.Minute, nil)
+		metrics.ObserveTokenRefresh(8*time.Minute, errors.New("401 Unauthorized"))
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(metrics.tokenRefresh.WithLabelValues("success")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.tokenRefresh.WithLabelValues("failure")))
+
+		// Only the replaced token has an age
+		var tokenAge dto.Metric
+		require.NoError(t, metrics.tokenAge.Write(&tokenAge))
+		assert.EqualValues(t, 1, tokenAge.GetHistogram().GetSampleCount())
+		assert.Equal(t, 420.0, tokenAge.GetHistogram().GetSampleSum())
+	})
+
+	t.Run("Records cache lookups", func(t *testing.T) {
+		metrics := newMetrics(t)
+		metrics.ObserveCacheLookup("token", true)
+		metrics.ObserveCacheLookup("token", true)
+		metrics.ObserveCacheLookup("token", false)
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues("token", "hit")))
+		assert.Equal(t, 1.0, testutil.ToFloat64(metrics.cacheLookups.WithLabelValues("token", "miss")))
+	})
+
+	t.Run("Fails when the collectors are already registered", func(t *testing.T) {
+		registry := prom.NewRegistry()
+		_, err := NewMetrics(registry)
+		require.NoError(t, err)
+
+		_, err = NewMetrics(registry)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,169 @@
+package conjurapi
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var supportedProxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// newProxyFunc returns the function used by the HTTP transport to select the
+// proxy for each request. Without an explicit ProxyURL, the proxy is taken
+// from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+// HTTPS requests are tunneled through HTTP proxies with CONNECT.
+func newProxyFunc(config Config) (func(*http.Request) (*url.URL, error), error) {
+	noProxy := parseNoProxy(config.NoProxy)
+
+	if config.ProxyURL == "" {
+		return func(req *http.Request) (*url.URL, error) {
+			if noProxy.matches(req.URL) {
+				return nil, nil
+			}
+			proxyURL, err := http.ProxyFromEnvironment(req)
+			if proxyURL == nil || err != nil {
+				return proxyURL, err
+			}
+			return withProxyCredentials(proxyURL, config), nil
+		}, nil
+	}
+
+	proxyURL, err := parseProxyURL(config.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	proxyURL = withProxyCredentials(proxyURL, config)
+
+	return func(req *http.Request) (*url.URL, error) {
+		if noProxy.matches(req.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// parseProxyURL parses a proxy URL, which defaults to the http scheme when
+// none is given, e.g. "proxy.example.com:3128".
+func parseProxyURL(rawURL string) (*url.URL, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		// The error would quote the URL, along with any credentials in it
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("Invalid ProxyURL: %s", err)
+	}
+	if !contains(supportedProxySchemes, proxyURL.Scheme) {
+		return nil, fmt.Errorf("ProxyURL scheme must be one of %v", supportedProxySchemes)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("ProxyURL must include a host")
+	}
+	return proxyURL, nil
+}
+
+// withProxyCredentials returns a copy of proxyURL with the proxy credentials
+// from config, if any. Credentials embedded in the proxy URL are used otherwise.
+func withProxyCredentials(proxyURL *url.URL, config Config) *url.URL {
+	if config.ProxyUsername == "" {
+		return proxyURL
+	}
+
+	withCredentials := *proxyURL
+	withCredentials.User = url.UserPassword(config.ProxyUsername, config.ProxyPassword)
+	return &withCredentials
+}
+
+// noProxyList holds the hosts which are reached without going through the
+// proxy. It is parsed from a comma-separated list of host names, domain
+// suffixes, IP addresses and CIDR ranges, each with an optional port, or "*"
+// to bypass the proxy for all hosts.
+type noProxyList struct {
+	all     bool
+	entries []noProxyEntry
+}
+
+type noProxyEntry struct {
+	domain  string
+	network *net.IPNet
+	port    string
+}
+
+func parseNoProxy(value string) noProxyList {
+	list := noProxyList{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			list.all = true
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			list.entries = append(list.entries, noProxyEntry{network: network})
+			continue
+		}
+
+		parsed := noProxyEntry{}
+		if host, port, err := net.SplitHostPort(entry); err == nil {
+			entry, parsed.port = host, port
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			parsed.network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		} else {
+			parsed.domain = strings.TrimPrefix(strings.TrimPrefix(entry, "*"), ".")
+		}
+		list.entries = append(list.entries, parsed)
+	}
+
+	return list
+}
+
+func (l noProxyList) matches(target *url.URL) bool {
+	if l.all {
+		return true
+	}
+
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = defaultPort(target.Scheme)
+	}
+	ip := net.ParseIP(host)
+
+	for _, entry := range l.entries {
+		if entry.port != "" && entry.port != port {
+			continue
+		}
+		if entry.network != nil {
+			if ip != nil && entry.network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if host == entry.domain || strings.HasSuffix(host, "."+entry.domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func defaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -92,16 +93,29 @@ func unopinionatedParseID(id string) (account, kind, identifier string) {
 }
 
 func (c *Client) SubmitRequest(req *http.Request) (resp *http.Response, err error) {
-	err = c.createAuthRequest(req)
+	token, err := c.createAuthRequest(req)
 	if err != nil {
 		return
 	}
 
-	return c.submitRequestWithCustomAuth(req)
+	replay := !c.disableReauthentication && c.authenticator != nil
+	if replay {
+		// Make sure the body can be sent again if the token is rejected
+		if err = ensureRewindableBody(req); err != nil {
+			return
+		}
+	}
+
+	resp, err = c.submitRequestWithCustomAuth(req)
+	if err != nil || !replay || resp.StatusCode != http.StatusUnauthorized {
+		return
+	}
+
+	return c.replayWithNewToken(req, resp, token)
 }
 
 func (c *Client) submitRequestWithCustomAuth(req *http.Request) (resp *http.Response, err error) {
-	resp, err = c.httpClient.Do(req)
+	resp, err = c.doWithRetry(req)
 	if err != nil {
 		return
 	}
@@ -110,13 +124,23 @@ func (c *Client) submitRequestWithCustomAuth(req *http.Request) (resp *http.Resp
 }
 
 func (c *Client) WhoAmIRequest() (*http.Request, error) {
-	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "whoami").String(), nil)
+	return c.WhoAmIRequestCtx(context.Background())
+}
+
+// WhoAmIRequestCtx is like WhoAmIRequest but attaches ctx to the request.
+func (c *Client) WhoAmIRequestCtx(ctx context.Context) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, "GET", makeRouterURL(c.config.ApplianceURL, "whoami").String(), nil)
 }
 
 func (c *Client) LoginRequest(login string, password string) (*http.Request, error) {
+	return c.LoginRequestCtx(context.Background(), login, password)
+}
+
+// LoginRequestCtx is like LoginRequest but attaches ctx to the request.
+func (c *Client) LoginRequestCtx(ctx context.Context, login string, password string) (*http.Request, error) {
 	authenticateURL := makeRouterURL(c.authnURL(c.config.AuthnType, c.config.ServiceID), "login").String()
 
-	req, err := http.NewRequest("GET", authenticateURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", authenticateURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -127,9 +151,14 @@ func (c *Client) LoginRequest(login string, password string) (*http.Request, err
 }
 
 func (c *Client) AuthenticateRequest(loginPair authn.LoginPair) (*http.Request, error) {
+	return c.AuthenticateRequestCtx(context.Background(), loginPair)
+}
+
+// AuthenticateRequestCtx is like AuthenticateRequest but attaches ctx to the request.
+func (c *Client) AuthenticateRequestCtx(ctx context.Context, loginPair authn.LoginPair) (*http.Request, error) {
 	authenticateURL := makeRouterURL(c.authnURL(c.config.AuthnType, c.config.ServiceID), url.QueryEscape(loginPair.Login), "authenticate").String()
 
-	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(loginPair.APIKey))
+	req, err := http.NewRequestWithContext(ctx, "POST", authenticateURL, strings.NewReader(loginPair.APIKey))
 	if err != nil {
 		return nil, err
 	}
@@ -139,6 +168,11 @@ func (c *Client) AuthenticateRequest(loginPair authn.LoginPair) (*http.Request,
 }
 
 func (c *Client) JWTAuthenticateRequest(token, hostID string) (*http.Request, error) {
+	return c.JWTAuthenticateRequestCtx(context.Background(), token, hostID)
+}
+
+// JWTAuthenticateRequestCtx is like JWTAuthenticateRequest but attaches ctx to the request.
+func (c *Client) JWTAuthenticateRequestCtx(ctx context.Context, token, hostID string) (*http.Request, error) {
 	var authenticateURL string
 	if hostID != "" {
 		authenticateURL = makeRouterURL(c.authnURL(c.config.AuthnType, c.config.ServiceID), url.PathEscape(hostID), "authenticate").String()
@@ -147,7 +181,7 @@ func (c *Client) JWTAuthenticateRequest(token, hostID string) (*http.Request, er
 	}
 
 	token = fmt.Sprintf("jwt=%s", token)
-	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(token))
+	req, err := http.NewRequestWithContext(ctx, "POST", authenticateURL, strings.NewReader(token))
 	if err != nil {
 		return nil, err
 	}
@@ -157,13 +191,23 @@ func (c *Client) JWTAuthenticateRequest(token, hostID string) (*http.Request, er
 }
 
 func (c *Client) ListOidcProvidersRequest() (*http.Request, error) {
-	return http.NewRequest("GET", c.oidcProvidersUrl(), nil)
+	return c.ListOidcProvidersRequestCtx(context.Background())
+}
+
+// ListOidcProvidersRequestCtx is like ListOidcProvidersRequest but attaches ctx to the request.
+func (c *Client) ListOidcProvidersRequestCtx(ctx context.Context) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, "GET", c.oidcProvidersUrl(), nil)
 }
 
 // ServerInfoRequest crafts an HTTP request to Conjur's /info endpoint to retrieve
 // This is only available in Conjur Enterprise and will fail with a 404 error in Conjur OSS.
 func (c *Client) ServerInfoRequest() (*http.Request, error) {
-	return http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL, "info").String(), nil)
+	return c.ServerInfoRequestCtx(context.Background())
+}
+
+// ServerInfoRequestCtx is like ServerInfoRequest but attaches ctx to the request.
+func (c *Client) ServerInfoRequestCtx(ctx context.Context) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, "GET", makeRouterURL(c.config.ApplianceURL, "info").String(), nil)
 }
 
 // RootRequest crafts an HTTP request to Conjur's root endpoint.
@@ -171,7 +215,12 @@ func (c *Client) ServerInfoRequest() (*http.Request, error) {
 // some information about the server.
 // In newer versions of Conjur this will return a JSON object with information about the server.
 func (c *Client) RootRequest() (*http.Request, error) {
-	req, err := http.NewRequest("GET", makeRouterURL(c.config.ApplianceURL).String(), nil)
+	return c.RootRequestCtx(context.Background())
+}
+
+// RootRequestCtx is like RootRequest but attaches ctx to the request.
+func (c *Client) RootRequestCtx(ctx context.Context) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", makeRouterURL(c.config.ApplianceURL).String(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -183,9 +232,14 @@ func (c *Client) RootRequest() (*http.Request, error) {
 }
 
 func (c *Client) OidcAuthenticateRequest(code, nonce, code_verifier string) (*http.Request, error) {
+	return c.OidcAuthenticateRequestCtx(context.Background(), code, nonce, code_verifier)
+}
+
+// OidcAuthenticateRequestCtx is like OidcAuthenticateRequest but attaches ctx to the request.
+func (c *Client) OidcAuthenticateRequestCtx(ctx context.Context, code, nonce, code_verifier string) (*http.Request, error) {
 	authenticateURL := makeRouterURL(c.authnURL(c.config.AuthnType, c.config.ServiceID), "authenticate").withFormattedQuery("code=%s&nonce=%s&code_verifier=%s", code, nonce, code_verifier).String()
 
-	req, err := http.NewRequest("GET", authenticateURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", authenticateURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -194,10 +248,15 @@ func (c *Client) OidcAuthenticateRequest(code, nonce, code_verifier string) (*ht
 }
 
 func (c *Client) OidcTokenAuthenticateRequest(token string) (*http.Request, error) {
+	return c.OidcTokenAuthenticateRequestCtx(context.Background(), token)
+}
+
+// OidcTokenAuthenticateRequestCtx is like OidcTokenAuthenticateRequest but attaches ctx to the request.
+func (c *Client) OidcTokenAuthenticateRequestCtx(ctx context.Context, token string) (*http.Request, error) {
 	authenticateURL := makeRouterURL(c.authnURL(c.config.AuthnType, c.config.ServiceID), "authenticate").String()
 
 	token = fmt.Sprintf("id_token=%s", token)
-	req, err := http.NewRequest("POST", authenticateURL, strings.NewReader(token))
+	req, err := http.NewRequestWithContext(ctx, "POST", authenticateURL, strings.NewReader(token))
 	if err != nil {
 		return nil, err
 	}
@@ -209,6 +268,11 @@ func (c *Client) OidcTokenAuthenticateRequest(token string) (*http.Request, erro
 // RotateAPIKeyRequest requires roleID argument to be at least partially-qualified
 // ID of from [<account>:]<kind>:<identifier>.
 func (c *Client) RotateAPIKeyRequest(roleID string) (*http.Request, error) {
+	return c.RotateAPIKeyRequestCtx(context.Background(), roleID)
+}
+
+// RotateAPIKeyRequestCtx is like RotateAPIKeyRequest but attaches ctx to the request.
+func (c *Client) RotateAPIKeyRequestCtx(ctx context.Context, roleID string) (*http.Request, error) {
 	account, kind, identifier, err := c.parseID(roleID)
 	if err != nil {
 		return nil, err
@@ -217,7 +281,8 @@ func (c *Client) RotateAPIKeyRequest(roleID string) (*http.Request, error) {
 
 	rotateURL := makeRouterURL(c.authnURL(c.config.AuthnType, c.config.ServiceID), "api_key").withFormattedQuery("role=%s", roleID).String()
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"PUT",
 		rotateURL,
 		nil,
@@ -225,13 +290,24 @@ func (c *Client) RotateAPIKeyRequest(roleID string) (*http.Request, error) {
 }
 
 func (c *Client) RotateCurrentUserAPIKeyRequest(login string, password string) (*http.Request, error) {
-	return c.RotateCurrentRoleAPIKeyRequest(login, password)
+	return c.RotateCurrentUserAPIKeyRequestCtx(context.Background(), login, password)
+}
+
+// RotateCurrentUserAPIKeyRequestCtx is like RotateCurrentUserAPIKeyRequest but attaches ctx to the request.
+func (c *Client) RotateCurrentUserAPIKeyRequestCtx(ctx context.Context, login string, password string) (*http.Request, error) {
+	return c.RotateCurrentRoleAPIKeyRequestCtx(ctx, login, password)
 }
 
 func (c *Client) RotateCurrentRoleAPIKeyRequest(login string, password string) (*http.Request, error) {
+	return c.RotateCurrentRoleAPIKeyRequestCtx(context.Background(), login, password)
+}
+
+// RotateCurrentRoleAPIKeyRequestCtx is like RotateCurrentRoleAPIKeyRequest but attaches ctx to the request.
+func (c *Client) RotateCurrentRoleAPIKeyRequestCtx(ctx context.Context, login string, password string) (*http.Request, error) {
 	rotateUrl := makeRouterURL(c.authnURL(c.config.AuthnType, c.config.ServiceID), "api_key")
 
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"PUT",
 		rotateUrl.String(),
 		nil,
@@ -248,9 +324,15 @@ func (c *Client) RotateCurrentRoleAPIKeyRequest(login string, password string) (
 }
 
 func (c *Client) ChangeUserPasswordRequest(username string, password string, newPassword string) (*http.Request, error) {
+	return c.ChangeUserPasswordRequestCtx(context.Background(), username, password, newPassword)
+}
+
+// ChangeUserPasswordRequestCtx is like ChangeUserPasswordRequest but attaches ctx to the request.
+func (c *Client) ChangeUserPasswordRequestCtx(ctx context.Context, username string, password string, newPassword string) (*http.Request, error) {
 	passwordURL := makeRouterURL(c.config.ApplianceURL, "authn", c.config.Account, "password")
 
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		"PUT",
 		passwordURL.String(),
 		strings.NewReader(newPassword),
@@ -269,6 +351,11 @@ func (c *Client) ChangeUserPasswordRequest(username string, password string, new
 // CheckPermissionRequest crafts an HTTP request to Conjur's /resource endpoint
 // to check if the authenticated user has the given privilege on the given resourceID.
 func (c *Client) CheckPermissionRequest(resourceID, privilege string) (*http.Request, error) {
+	return c.CheckPermissionRequestCtx(context.Background(), resourceID, privilege)
+}
+
+// CheckPermissionRequestCtx is like CheckPermissionRequest but attaches ctx to the request.
+func (c *Client) CheckPermissionRequestCtx(ctx context.Context, resourceID, privilege string) (*http.Request, error) {
 	account, kind, id, err := c.parseID(resourceID)
 	if err != nil {
 		return nil, err
@@ -278,7 +365,8 @@ func (c *Client) CheckPermissionRequest(resourceID, privilege string) (*http.Req
 
 	checkURL := makeRouterURL(c.resourcesURL(account), kind, url.QueryEscape(id)).withQuery(query).String()
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		checkURL,
 		nil,
@@ -288,6 +376,11 @@ func (c *Client) CheckPermissionRequest(resourceID, privilege string) (*http.Req
 // CheckPermissionForRoleRequest crafts an HTTP request to Conjur's /resource endpoint
 // to check if a given role has the given privilege on the given resourceID.
 func (c *Client) CheckPermissionForRoleRequest(resourceID, roleID, privilege string) (*http.Request, error) {
+	return c.CheckPermissionForRoleRequestCtx(context.Background(), resourceID, roleID, privilege)
+}
+
+// CheckPermissionForRoleRequestCtx is like CheckPermissionForRoleRequest but attaches ctx to the request.
+func (c *Client) CheckPermissionForRoleRequestCtx(ctx context.Context, resourceID, roleID, privilege string) (*http.Request, error) {
 	account, kind, id, err := c.parseID(resourceID)
 	if err != nil {
 		return nil, err
@@ -303,7 +396,8 @@ func (c *Client) CheckPermissionForRoleRequest(resourceID, roleID, privilege str
 
 	checkURL := makeRouterURL(c.resourcesURL(account), kind, url.QueryEscape(id)).withQuery(query).String()
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		checkURL,
 		nil,
@@ -311,6 +405,11 @@ func (c *Client) CheckPermissionForRoleRequest(resourceID, roleID, privilege str
 }
 
 func (c *Client) ResourceRequest(resourceID string) (*http.Request, error) {
+	return c.ResourceRequestCtx(context.Background(), resourceID)
+}
+
+// ResourceRequestCtx is like ResourceRequest but attaches ctx to the request.
+func (c *Client) ResourceRequestCtx(ctx context.Context, resourceID string) (*http.Request, error) {
 	account, kind, id, err := c.parseID(resourceID)
 	if err != nil {
 		return nil, err
@@ -318,7 +417,8 @@ func (c *Client) ResourceRequest(resourceID string) (*http.Request, error) {
 
 	requestURL := makeRouterURL(c.resourcesURL(account), kind, url.QueryEscape(id))
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		requestURL.String(),
 		nil,
@@ -326,6 +426,11 @@ func (c *Client) ResourceRequest(resourceID string) (*http.Request, error) {
 }
 
 func (c *Client) ResourcesRequest(filter *ResourceFilter) (*http.Request, error) {
+	return c.ResourcesRequestCtx(context.Background(), filter)
+}
+
+// ResourcesRequestCtx is like ResourcesRequest but attaches ctx to the request.
+func (c *Client) ResourcesRequestCtx(ctx context.Context, filter *ResourceFilter) (*http.Request, error) {
 	query := url.Values{}
 
 	if filter != nil {
@@ -351,7 +456,8 @@ func (c *Client) ResourcesRequest(filter *ResourceFilter) (*http.Request, error)
 
 	requestURL := makeRouterURL(c.resourcesURL(c.config.Account)).withQuery(query.Encode())
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		requestURL.String(),
 		nil,
@@ -359,13 +465,19 @@ func (c *Client) ResourcesRequest(filter *ResourceFilter) (*http.Request, error)
 }
 
 func (c *Client) PermittedRolesRequest(resourceID string, privilege string) (*http.Request, error) {
+	return c.PermittedRolesRequestCtx(context.Background(), resourceID, privilege)
+}
+
+// PermittedRolesRequestCtx is like PermittedRolesRequest but attaches ctx to the request.
+func (c *Client) PermittedRolesRequestCtx(ctx context.Context, resourceID string, privilege string) (*http.Request, error) {
 	account, kind, id, err := c.parseID(resourceID)
 	if err != nil {
 		return nil, err
 	}
 	permittedRolesURL := makeRouterURL(c.resourcesURL(account), kind, url.QueryEscape(id)).withFormattedQuery("permitted_roles=true&privilege=%s", url.QueryEscape(privilege)).String()
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		permittedRolesURL,
 		nil,
@@ -373,13 +485,19 @@ func (c *Client) PermittedRolesRequest(resourceID string, privilege string) (*ht
 }
 
 func (c *Client) RoleRequest(roleID string) (*http.Request, error) {
+	return c.RoleRequestCtx(context.Background(), roleID)
+}
+
+// RoleRequestCtx is like RoleRequest but attaches ctx to the request.
+func (c *Client) RoleRequestCtx(ctx context.Context, roleID string) (*http.Request, error) {
 	account, kind, id, err := c.parseID(roleID)
 	if err != nil {
 		return nil, err
 	}
 	roleURL := makeRouterURL(c.rolesURL(account), kind, url.QueryEscape(id))
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		roleURL.String(),
 		nil,
@@ -387,13 +505,19 @@ func (c *Client) RoleRequest(roleID string) (*http.Request, error) {
 }
 
 func (c *Client) RoleMembersRequest(roleID string) (*http.Request, error) {
+	return c.RoleMembersRequestCtx(context.Background(), roleID)
+}
+
+// RoleMembersRequestCtx is like RoleMembersRequest but attaches ctx to the request.
+func (c *Client) RoleMembersRequestCtx(ctx context.Context, roleID string) (*http.Request, error) {
 	account, kind, id, err := c.parseID(roleID)
 	if err != nil {
 		return nil, err
 	}
 	roleMembersURL := makeRouterURL(c.rolesURL(account), kind, url.QueryEscape(id)).withFormattedQuery("members")
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		roleMembersURL.String(),
 		nil,
@@ -401,12 +525,22 @@ func (c *Client) RoleMembersRequest(roleID string) (*http.Request, error) {
 }
 
 func (c *Client) RoleMembershipsRequest(roleID string) (*http.Request, error) {
-	return c.RoleMembershipsRequestWithOptions(roleID, false)
+	return c.RoleMembershipsRequestCtx(context.Background(), roleID)
+}
+
+// RoleMembershipsRequestCtx is like RoleMembershipsRequest but attaches ctx to the request.
+func (c *Client) RoleMembershipsRequestCtx(ctx context.Context, roleID string) (*http.Request, error) {
+	return c.RoleMembershipsRequestWithOptionsCtx(ctx, roleID, false)
 }
 
 // RoleMembershipsRequestWithOptions crafts an HTTP request to Conjur's /role endpoint
 // allowing for either direct or all memberships to be returned.
 func (c *Client) RoleMembershipsRequestWithOptions(roleID string, includeAll bool) (*http.Request, error) {
+	return c.RoleMembershipsRequestWithOptionsCtx(context.Background(), roleID, includeAll)
+}
+
+// RoleMembershipsRequestWithOptionsCtx is like RoleMembershipsRequestWithOptions but attaches ctx to the request.
+func (c *Client) RoleMembershipsRequestWithOptionsCtx(ctx context.Context, roleID string, includeAll bool) (*http.Request, error) {
 	account, kind, id, err := c.parseID(roleID)
 	if err != nil {
 		return nil, err
@@ -419,7 +553,8 @@ func (c *Client) RoleMembershipsRequestWithOptions(roleID string, includeAll boo
 
 	roleMembershipsURL := makeRouterURL(c.rolesURL(account), kind, url.QueryEscape(id)).withFormattedQuery(query)
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		roleMembershipsURL.String(),
 		nil,
@@ -427,6 +562,11 @@ func (c *Client) RoleMembershipsRequestWithOptions(roleID string, includeAll boo
 }
 
 func (c *Client) LoadPolicyRequest(mode PolicyMode, policyID string, policy io.Reader, validate bool) (*http.Request, error) {
+	return c.LoadPolicyRequestCtx(context.Background(), mode, policyID, policy, validate)
+}
+
+// LoadPolicyRequestCtx is like LoadPolicyRequest but attaches ctx to the request.
+func (c *Client) LoadPolicyRequestCtx(ctx context.Context, mode PolicyMode, policyID string, policy io.Reader, validate bool) (*http.Request, error) {
 	fullPolicyID := makeFullID(c.config.Account, "policy", policyID)
 
 	account, kind, id, err := c.parseID(fullPolicyID)
@@ -442,6 +582,8 @@ func (c *Client) LoadPolicyRequest(mode PolicyMode, policyID string, policy io.R
 
 	if validate {
 		routerUrl = routerUrl.withQuery("dryRun=true")
+		// A dry-run doesn't change anything on the server, so it's safe to retry
+		ctx = WithRetryableRequest(ctx)
 	}
 
 	policyURL := routerUrl.String()
@@ -458,14 +600,15 @@ func (c *Client) LoadPolicyRequest(mode PolicyMode, policyID string, policy io.R
 		return nil, fmt.Errorf("Invalid PolicyMode: %d", mode)
 	}
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		method,
 		policyURL,
 		policy,
 	)
 }
 
-func (c *Client) fetchPolicyRequest(policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) (*http.Request, error) {
+func (c *Client) fetchPolicyRequest(ctx context.Context, policyID string, returnJSON bool, policyTreeDepth uint, sizeLimit uint) (*http.Request, error) {
 	fullPolicyID := makeFullID(c.config.Account, "policy", policyID)
 
 	account, kind, id, err := c.parseID(fullPolicyID)
@@ -486,7 +629,8 @@ func (c *Client) fetchPolicyRequest(policyID string, returnJSON bool, policyTree
 	)
 	policyURL := routerUrl.String()
 
-	req, err := http.NewRequest(
+	req, err := http.NewRequestWithContext(
+		ctx,
 		http.MethodGet,
 		policyURL,
 		nil,
@@ -506,13 +650,19 @@ func (c *Client) fetchPolicyRequest(policyID string, returnJSON bool, policyTree
 }
 
 func (c *Client) RetrieveBatchSecretsRequest(variableIDs []string, base64Flag bool) (*http.Request, error) {
+	return c.RetrieveBatchSecretsRequestCtx(context.Background(), variableIDs, base64Flag)
+}
+
+// RetrieveBatchSecretsRequestCtx is like RetrieveBatchSecretsRequest but attaches ctx to the request.
+func (c *Client) RetrieveBatchSecretsRequestCtx(ctx context.Context, variableIDs []string, base64Flag bool) (*http.Request, error) {
 	fullVariableIDs := []string{}
 	for _, variableID := range variableIDs {
 		fullVariableID := makeFullID(c.config.Account, "variable", variableID)
 		fullVariableIDs = append(fullVariableIDs, fullVariableID)
 	}
 
-	request, err := http.NewRequest(
+	request, err := http.NewRequestWithContext(
+		ctx,
 		"GET",
 		c.batchVariableURL(fullVariableIDs),
 		nil,
@@ -530,6 +680,11 @@ func (c *Client) RetrieveBatchSecretsRequest(variableIDs []string, base64Flag bo
 }
 
 func (c *Client) RetrieveSecretRequest(variableID string) (*http.Request, error) {
+	return c.RetrieveSecretRequestCtx(context.Background(), variableID)
+}
+
+// RetrieveSecretRequestCtx is like RetrieveSecretRequest but attaches ctx to the request.
+func (c *Client) RetrieveSecretRequestCtx(ctx context.Context, variableID string) (*http.Request, error) {
 	fullVariableID := makeFullID(c.config.Account, "variable", variableID)
 
 	variableURL, err := c.variableURL(fullVariableID)
@@ -537,7 +692,8 @@ func (c *Client) RetrieveSecretRequest(variableID string) (*http.Request, error)
 		return nil, err
 	}
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		variableURL,
 		nil,
@@ -545,6 +701,11 @@ func (c *Client) RetrieveSecretRequest(variableID string) (*http.Request, error)
 }
 
 func (c *Client) RetrieveSecretWithVersionRequest(variableID string, version int) (*http.Request, error) {
+	return c.RetrieveSecretWithVersionRequestCtx(context.Background(), variableID, version)
+}
+
+// RetrieveSecretWithVersionRequestCtx is like RetrieveSecretWithVersionRequest but attaches ctx to the request.
+func (c *Client) RetrieveSecretWithVersionRequestCtx(ctx context.Context, variableID string, version int) (*http.Request, error) {
 	fullVariableID := makeFullID(c.config.Account, "variable", variableID)
 
 	variableURL, err := c.variableWithVersionURL(fullVariableID, version)
@@ -552,7 +713,8 @@ func (c *Client) RetrieveSecretWithVersionRequest(variableID string, version int
 		return nil, err
 	}
 
-	return http.NewRequest(
+	return http.NewRequestWithContext(
+		ctx,
 		"GET",
 		variableURL,
 		nil,
@@ -560,6 +722,11 @@ func (c *Client) RetrieveSecretWithVersionRequest(variableID string, version int
 }
 
 func (c *Client) AddSecretRequest(variableID, secretValue string) (*http.Request, error) {
+	return c.AddSecretRequestCtx(context.Background(), variableID, secretValue)
+}
+
+// AddSecretRequestCtx is like AddSecretRequest but attaches ctx to the request.
+func (c *Client) AddSecretRequestCtx(ctx context.Context, variableID, secretValue string) (*http.Request, error) {
 	fullVariableID := makeFullID(c.config.Account, "variable", variableID)
 
 	variableURL, err := c.variableURL(fullVariableID)
@@ -567,7 +734,8 @@ func (c *Client) AddSecretRequest(variableID, secretValue string) (*http.Request
 		return nil, err
 	}
 
-	request, err := http.NewRequest(
+	request, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		variableURL,
 		strings.NewReader(secretValue),
@@ -581,9 +749,15 @@ func (c *Client) AddSecretRequest(variableID, secretValue string) (*http.Request
 }
 
 func (c *Client) CreateTokenRequest(body string) (*http.Request, error) {
+	return c.CreateTokenRequestCtx(context.Background(), body)
+}
+
+// CreateTokenRequestCtx is like CreateTokenRequest but attaches ctx to the request.
+func (c *Client) CreateTokenRequestCtx(ctx context.Context, body string) (*http.Request, error) {
 
 	tokenURL := c.createTokenURL()
-	request, err := http.NewRequest(
+	request, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		tokenURL,
 		strings.NewReader(body),
@@ -597,9 +771,15 @@ func (c *Client) CreateTokenRequest(body string) (*http.Request, error) {
 }
 
 func (c *Client) DeleteTokenRequest(token string) (*http.Request, error) {
+	return c.DeleteTokenRequestCtx(context.Background(), token)
+}
+
+// DeleteTokenRequestCtx is like DeleteTokenRequest but attaches ctx to the request.
+func (c *Client) DeleteTokenRequestCtx(ctx context.Context, token string) (*http.Request, error) {
 	tokenURL := c.createTokenURL() + "/" + token
 
-	request, err := http.NewRequest(
+	request, err := http.NewRequestWithContext(
+		ctx,
 		"DELETE",
 		tokenURL,
 		nil,
@@ -612,8 +792,14 @@ func (c *Client) DeleteTokenRequest(token string) (*http.Request, error) {
 }
 
 func (c *Client) CreateHostRequest(body string, token string) (*http.Request, error) {
+	return c.CreateHostRequestCtx(context.Background(), body, token)
+}
+
+// CreateHostRequestCtx is like CreateHostRequest but attaches ctx to the request.
+func (c *Client) CreateHostRequestCtx(ctx context.Context, body string, token string) (*http.Request, error) {
 	hostURL := c.createHostURL()
-	request, err := http.NewRequest(
+	request, err := http.NewRequestWithContext(
+		ctx,
 		"POST",
 		hostURL,
 		strings.NewReader(body),
@@ -628,15 +814,26 @@ func (c *Client) CreateHostRequest(body string, token string) (*http.Request, er
 }
 
 func (c *Client) PublicKeysRequest(kind string, identifier string) (*http.Request, error) {
+	return c.PublicKeysRequestCtx(context.Background(), kind, identifier)
+}
+
+// PublicKeysRequestCtx is like PublicKeysRequest but attaches ctx to the request.
+func (c *Client) PublicKeysRequestCtx(ctx context.Context, kind string, identifier string) (*http.Request, error) {
 	publicKeysURL := makeRouterURL(c.config.ApplianceURL, "public_keys", c.config.Account, kind, identifier)
-	return http.NewRequest("GET", publicKeysURL.String(), nil)
+	return http.NewRequestWithContext(ctx, "GET", publicKeysURL.String(), nil)
 }
 
 func (c *Client) EnableAuthenticatorRequest(authenticatorType string, serviceID string, enabled bool) (*http.Request, error) {
+	return c.EnableAuthenticatorRequestCtx(context.Background(), authenticatorType, serviceID, enabled)
+}
+
+// EnableAuthenticatorRequestCtx is like EnableAuthenticatorRequest but attaches ctx to the request.
+func (c *Client) EnableAuthenticatorRequestCtx(ctx context.Context, authenticatorType string, serviceID string, enabled bool) (*http.Request, error) {
 	body := url.Values{}
 	body.Set("enabled", strconv.FormatBool(enabled))
 
-	request, err := http.NewRequest(
+	request, err := http.NewRequestWithContext(
+		ctx,
 		"PATCH",
 		c.authnURL(authenticatorType, serviceID),
 		strings.NewReader(body.Encode()),
@@ -650,8 +847,13 @@ func (c *Client) EnableAuthenticatorRequest(authenticatorType string, serviceID
 }
 
 func (c *Client) AuthenticatorStatusRequest(authenticatorType string, serviceID string) (*http.Request, error) {
+	return c.AuthenticatorStatusRequestCtx(context.Background(), authenticatorType, serviceID)
+}
+
+// AuthenticatorStatusRequestCtx is like AuthenticatorStatusRequest but attaches ctx to the request.
+func (c *Client) AuthenticatorStatusRequestCtx(ctx context.Context, authenticatorType string, serviceID string) (*http.Request, error) {
 	statusURL := makeRouterURL(c.authnURL(authenticatorType, serviceID), "status").String()
-	return http.NewRequest("GET", statusURL, nil)
+	return http.NewRequestWithContext(ctx, "GET", statusURL, nil)
 }
 
 func (c *Client) createTokenURL() string {
@@ -1,9 +1,17 @@
 package conjurapi
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestUnopinionatedParseID(t *testing.T) {
@@ -148,3 +156,116 @@ func TestMakeFullID(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_RequestCtx(t *testing.T) {
+	t.Run("Request builders attach the context", func(t *testing.T) {
+		client, err := NewClientFromToken(Config{Account: "account", ApplianceURL: "http://appliance-url"}, sample_token)
+		require.NoError(t, err)
+
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+		req, err := client.RetrieveSecretRequestCtx(ctx, "my-var")
+		require.NoError(t, err)
+		assert.Equal(t, "value", req.Context().Value(ctxKey{}))
+
+		req, err = client.RetrieveSecretRequest("my-var")
+		require.NoError(t, err)
+		assert.Nil(t, req.Context().Value(ctxKey{}))
+	})
+
+	t.Run("Cancels an in-flight request when the deadline passes", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		client, err := NewClientFromToken(Config{Account: "account", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = client.RetrieveSecretCtx(ctx, "my-var")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("Token refresh respects the request context", func(t *testing.T) {
+		authnCalled := make(chan struct{}, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/authenticate") {
+				authnCalled <- struct{}{}
+				// Drain the body so the server notices the client going away
+				io.ReadAll(r.Body)
+				<-r.Context().Done()
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		client, err := NewClientFromKey(
+			Config{Account: "account", ApplianceURL: server.URL, CredentialStorage: "none"},
+			authn.LoginPair{Login: "alice", APIKey: "api-key"},
+		)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err = client.RetrieveSecretCtx(ctx, "my-var")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Len(t, authnCalled, 1)
+	})
+}
+
+func TestClient_SubmitRequest_Unauthorized(t *testing.T) {
+	t.Run("Re-authenticates and replays the request once", func(t *testing.T) {
+		server := newTestServer(t, failing("/policies", 1, http.StatusUnauthorized))
+		client := newTestServerClient(t, server)
+
+		// The body is not rewindable, so it must be buffered to be replayed
+		req, err := client.LoadPolicyRequest(PolicyModePost, "root", io.MultiReader(strings.NewReader("- !user bob")), false)
+		require.NoError(t, err)
+		resp, err := client.SubmitRequest(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp.Body.Close()
+
+		requests := server.Requests()
+		assert.Equal(t, "- !user bob", string(requests[len(requests)-1].Body))
+		assert.Equal(t, 2, server.CountRequests("/authn"))
+		assert.Equal(t, 2, server.CountRequests("/policies"))
+	})
+
+	t.Run("Does not loop when the new token is rejected too", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 10, http.StatusUnauthorized))
+		client := newTestServerClient(t, server)
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "401 Unauthorized")
+		assert.Equal(t, 2, server.CountRequests("/authn"))
+		assert.Equal(t, 2, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Can be disabled", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 1, http.StatusUnauthorized))
+		client := newTestServerClient(t, server)
+		client.SetReauthenticateOnUnauthorized(false)
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "401 Unauthorized")
+		assert.Equal(t, 1, server.CountRequests("/authn"))
+		assert.Equal(t, 1, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Does not replay with an unchanged token", func(t *testing.T) {
+		server := newTestServer(t)
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+
+		_, err = client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "Authorization missing or invalid")
+		assert.Equal(t, 1, server.CountRequests("/secrets"))
+	})
+}
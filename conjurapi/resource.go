@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -55,7 +56,15 @@ type ResourceFilter struct {
 // CheckPermission determines whether the authenticated user has a specified privilege
 // on a resource.
 func (c *Client) CheckPermission(resourceID string, privilege string) (bool, error) {
-	req, err := c.CheckPermissionRequest(resourceID, privilege)
+	return c.CheckPermissionCtx(context.Background(), resourceID, privilege)
+}
+
+// CheckPermissionCtx is like CheckPermission but uses ctx for cancellation and deadlines.
+func (c *Client) CheckPermissionCtx(ctx context.Context, resourceID string, privilege string) (_ bool, err error) {
+	ctx, span := c.startSpan(ctx, "CheckPermission", "", resourceID)
+	defer func() { span.end(err) }()
+
+	req, err := c.CheckPermissionRequestCtx(ctx, resourceID, privilege)
 	if err != nil {
 		return false, err
 	}
@@ -66,7 +75,15 @@ func (c *Client) CheckPermission(resourceID string, privilege string) (bool, err
 // CheckPermissionForRole determines whether the provided role has a specific
 // privilege on a resource.
 func (c *Client) CheckPermissionForRole(resourceID string, roleID string, privilege string) (bool, error) {
-	req, err := c.CheckPermissionForRoleRequest(resourceID, roleID, privilege)
+	return c.CheckPermissionForRoleCtx(context.Background(), resourceID, roleID, privilege)
+}
+
+// CheckPermissionForRoleCtx is like CheckPermissionForRole but uses ctx for cancellation and deadlines.
+func (c *Client) CheckPermissionForRoleCtx(ctx context.Context, resourceID string, roleID string, privilege string) (_ bool, err error) {
+	ctx, span := c.startSpan(ctx, "CheckPermissionForRole", "", resourceID)
+	defer func() { span.end(err) }()
+
+	req, err := c.CheckPermissionForRoleRequestCtx(ctx, resourceID, roleID, privilege)
 	if err != nil {
 		return false, err
 	}
@@ -91,7 +108,15 @@ func (c *Client) processPermissionCheck(req *http.Request) (bool, error) {
 
 // ResourceExists checks whether or not a resource exists
 func (c *Client) ResourceExists(resourceID string) (bool, error) {
-	req, err := c.ResourceRequest(resourceID)
+	return c.ResourceExistsCtx(context.Background(), resourceID)
+}
+
+// ResourceExistsCtx is like ResourceExists but uses ctx for cancellation and deadlines.
+func (c *Client) ResourceExistsCtx(ctx context.Context, resourceID string) (_ bool, err error) {
+	ctx, span := c.startSpan(ctx, "ResourceExists", "", resourceID)
+	defer func() { span.end(err) }()
+
+	req, err := c.ResourceRequestCtx(ctx, resourceID)
 	if err != nil {
 		return false, err
 	}
@@ -112,7 +137,15 @@ func (c *Client) ResourceExists(resourceID string) (bool, error) {
 
 // Resource fetches a single user-visible resource by id.
 func (c *Client) Resource(resourceID string) (resource map[string]interface{}, err error) {
-	req, err := c.ResourceRequest(resourceID)
+	return c.ResourceCtx(context.Background(), resourceID)
+}
+
+// ResourceCtx is like Resource but uses ctx for cancellation and deadlines.
+func (c *Client) ResourceCtx(ctx context.Context, resourceID string) (resource map[string]interface{}, err error) {
+	ctx, span := c.startSpan(ctx, "Resource", "", resourceID)
+	defer func() { span.end(err) }()
+
+	req, err := c.ResourceRequestCtx(ctx, resourceID)
 	if err != nil {
 		return
 	}
@@ -136,7 +169,15 @@ func (c *Client) Resource(resourceID string) (resource map[string]interface{}, e
 // be limited by the given ResourceFilter. If filter is non-nil, only
 // non-zero-valued members of the filter will be applied.
 func (c *Client) Resources(filter *ResourceFilter) (resources []map[string]interface{}, err error) {
-	req, err := c.ResourcesRequest(filter)
+	return c.ResourcesCtx(context.Background(), filter)
+}
+
+// ResourcesCtx is like Resources but uses ctx for cancellation and deadlines.
+func (c *Client) ResourcesCtx(ctx context.Context, filter *ResourceFilter) (resources []map[string]interface{}, err error) {
+	ctx, span := c.startSpan(ctx, "Resources", resourceFilterKind(filter), "")
+	defer func() { span.end(err) }()
+
+	req, err := c.ResourcesRequestCtx(ctx, filter)
 	if err != nil {
 		return
 	}
@@ -157,7 +198,12 @@ func (c *Client) Resources(filter *ResourceFilter) (resources []map[string]inter
 }
 
 func (c *Client) ResourceIDs(filter *ResourceFilter) ([]string, error) {
-	resources, err := c.Resources(filter)
+	return c.ResourceIDsCtx(context.Background(), filter)
+}
+
+// ResourceIDsCtx is like ResourceIDs but uses ctx for cancellation and deadlines.
+func (c *Client) ResourceIDsCtx(ctx context.Context, filter *ResourceFilter) ([]string, error) {
+	resources, err := c.ResourcesCtx(ctx, filter)
 
 	if err != nil {
 		return nil, err
@@ -174,7 +220,15 @@ func (c *Client) ResourceIDs(filter *ResourceFilter) ([]string, error) {
 
 // PermittedRoles lists the roles which have the named permission on a resource
 func (c *Client) PermittedRoles(resourceID, privilege string) ([]string, error) {
-	req, err := c.PermittedRolesRequest(resourceID, privilege)
+	return c.PermittedRolesCtx(context.Background(), resourceID, privilege)
+}
+
+// PermittedRolesCtx is like PermittedRoles but uses ctx for cancellation and deadlines.
+func (c *Client) PermittedRolesCtx(ctx context.Context, resourceID, privilege string) (_ []string, err error) {
+	ctx, span := c.startSpan(ctx, "PermittedRoles", "", resourceID)
+	defer func() { span.end(err) }()
+
+	req, err := c.PermittedRolesRequestCtx(ctx, resourceID, privilege)
 	if err != nil {
 		return nil, err
 	}
@@ -1,12 +1,11 @@
 package response
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"strings"
-
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
 )
 
 type ConjurError struct {
@@ -22,6 +21,24 @@ type ConjurErrorDetails struct {
 	Details map[string]interface{}
 }
 
+type errorObserverKey struct{}
+
+// WithErrorObserver returns a copy of ctx which makes the response helpers
+// pass the errors returned by Conjur to observe, when they check the response
+// to a request made with it. The client uses it to record them in its Metrics.
+func WithErrorObserver(ctx context.Context, observe func(*ConjurError)) context.Context {
+	return context.WithValue(ctx, errorObserverKey{}, observe)
+}
+
+func observeError(resp *http.Response, cerr *ConjurError) {
+	if resp.Request == nil {
+		return
+	}
+	if observe, ok := resp.Request.Context().Value(errorObserverKey{}).(func(*ConjurError)); ok {
+		observe(cerr)
+	}
+}
+
 func NewConjurError(resp *http.Response) error {
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
@@ -41,12 +58,12 @@ func NewConjurError(resp *http.Response) error {
 		cerr.Message = resp.Status
 	}
 
+	responseLogger(resp).Debug("Conjur returned an error", "code", cerr.Code, "message", cerr.Message, "details", cerr.Details)
+	observeError(resp, &cerr)
 	return &cerr
 }
 
 func (cerr *ConjurError) Error() string {
-	logging.ApiLog.Debugf("cerr.Details: %+v, cerr.Message: %+v\n", cerr.Details, cerr.Message)
-
 	var b strings.Builder
 
 	if cerr.Message != "" {
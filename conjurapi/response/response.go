@@ -1,8 +1,10 @@
 package response
 
 import (
+	"context"
 	"encoding/json"
 	"io"
+	"log/slog"
 	"net/http"
 
 	"github.com/cyberark/conjur-api-go/conjurapi/logging"
@@ -19,10 +21,27 @@ func readBody(resp *http.Response) ([]byte, error) {
 	return responseText, err
 }
 
+// responseLogger returns the logger of the client which sent the request of
+// resp, see logging.NewContext.
+func responseLogger(resp *http.Response) *slog.Logger {
+	if resp.Request == nil {
+		return logging.Default()
+	}
+	return logging.FromContext(resp.Request.Context())
+}
+
 func logResponse(resp *http.Response) {
+	logger := responseLogger(resp)
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
 	req := resp.Request
-	redactedHeaders := redactHeaders(req.Header)
-	logging.ApiLog.Debugf("%d %s %s %+v", resp.StatusCode, req.Method, req.URL, redactedHeaders)
+	logger.Debug("Received response",
+		"status", resp.StatusCode,
+		"method", req.Method,
+		"url", req.URL.String(),
+		"headers", redactHeaders(req.Header),
+	)
 }
 
 const redactedString = "[REDACTED]"
@@ -2,11 +2,14 @@ package response
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/cyberark/conjur-api-go/conjurapi/logging"
@@ -44,8 +47,10 @@ func TestLogResponse(t *testing.T) {
 				Request:    goodRequest(),
 			},
 			assert: func(t *testing.T, logOutput *bytes.Buffer) {
-				assert.Contains(t, logOutput.String(), "200 GET https://example.com map[Authorization:")
-				assert.Contains(t, logOutput.String(), "Content-Type:[application/json]]")
+				assert.Contains(t, logOutput.String(), "method=GET")
+				assert.Contains(t, logOutput.String(), "status=200")
+				assert.Contains(t, logOutput.String(), `url="https://example.com"`)
+				assert.Contains(t, logOutput.String(), "Content-Type:[application/json]")
 				// Make sure the authorization header is redacted
 				assert.NotContains(t, logOutput.String(), "super_secret_access_token")
 			},
@@ -66,7 +71,8 @@ func TestLogResponse(t *testing.T) {
 				},
 			},
 			assert: func(t *testing.T, logOutput *bytes.Buffer) {
-				assert.Contains(t, logOutput.String(), "401 POST https://example.com map[Authorization:")
+				assert.Contains(t, logOutput.String(), "method=POST")
+				assert.Contains(t, logOutput.String(), "status=401")
 				// Make sure the authorization header is redacted
 				assert.NotContains(t, logOutput.String(), "super_secret_access_token")
 			},
@@ -87,7 +93,7 @@ func TestLogResponse(t *testing.T) {
 				},
 			},
 			assert: func(t *testing.T, logOutput *bytes.Buffer) {
-				assert.Contains(t, logOutput.String(), "200 GET https://example.com map[Authorization:")
+				assert.Contains(t, logOutput.String(), "Authorization:[[REDACTED]]")
 				// Make sure the authorization headers are redacted
 				assert.NotContains(t, logOutput.String(), "super_secret_access_token")
 				assert.NotContains(t, logOutput.String(), "another_secret_token")
@@ -116,6 +122,51 @@ func TestLogResponse(t *testing.T) {
 	}
 }
 
+func TestLogResponse_ContextLogger(t *testing.T) {
+	var logOutput bytes.Buffer
+	logger := logging.NewLogger(&logOutput, slog.LevelDebug)
+	req := goodRequest().WithContext(logging.NewContext(context.Background(), logger))
+
+	_, err := DataResponse(&http.Response{
+		StatusCode: 404,
+		Status:     "404 Not Found",
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	})
+	require.Error(t, err)
+
+	assert.Contains(t, logOutput.String(), `"msg":"Received response"`)
+	assert.Contains(t, logOutput.String(), `"msg":"Conjur returned an error"`)
+	assert.NotContains(t, logOutput.String(), "super_secret_access_token")
+}
+
+func TestWithErrorObserver(t *testing.T) {
+	var observed []*ConjurError
+	ctx := WithErrorObserver(context.Background(), func(cerr *ConjurError) {
+		observed = append(observed, cerr)
+	})
+	req := goodRequest().WithContext(ctx)
+
+	err := EmptyResponse(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, observed)
+
+	_, err = DataResponse(&http.Response{
+		StatusCode: 404,
+		Status:     "404 Not Found",
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"code":"not_found","message":"Not found"}}`)),
+		Request:    req,
+	})
+	require.Error(t, err)
+	require.Len(t, observed, 1)
+	assert.Equal(t, err, observed[0])
+	assert.Equal(t, "not_found", observed[0].Details.Code)
+}
+
 func TestDataResponse(t *testing.T) {
 	testCases := []struct {
 		name          string
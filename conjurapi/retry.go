@@ -0,0 +1,272 @@
+package conjurapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// RetryMaxAttemptsDefaultValue is the default number of attempts, including the first one
+	RetryMaxAttemptsDefaultValue = 3
+	// RetryInitialBackoffDefaultValue is the default delay before the first retry
+	RetryInitialBackoffDefaultValue = 200 * time.Millisecond
+	// RetryMaxBackoffDefaultValue is the default upper bound of the delay between attempts
+	RetryMaxBackoffDefaultValue = 5 * time.Second
+)
+
+// RetryPolicy controls how the client retries requests which fail with a
+// transient error, such as a follower restarting or a load balancer briefly
+// returning 502 or 503.
+//
+// Only idempotent requests are retried by default: GET, HEAD and OPTIONS
+// requests, policy dry-runs, and requests built with a context returned by
+// WithRetryableRequest. Requests which change state on the server, such as
+// AddSecret, LoadPolicy and RotateAPIKey, are retried only when
+// RetryNonIdempotent is set.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// A value of 1 or less disables retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. The delay doubles
+	// with each attempt and is randomized to avoid synchronized retries.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts. A Retry-After header sent by
+	// the server takes precedence over the computed delay.
+	MaxBackoff time.Duration
+	// RetryableStatusCodes lists the HTTP status codes which are retried.
+	RetryableStatusCodes []int
+	// IsRetryableError reports whether an error returned by the HTTP client is
+	// retried. When nil, IsRetryableNetworkError is used.
+	IsRetryableError func(err error) bool
+	// RetryNonIdempotent enables retries for requests which are not idempotent.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy which retries idempotent requests
+// up to three times on network errors and on 429, 502, 503 and 504 responses.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    RetryMaxAttemptsDefaultValue,
+		InitialBackoff: RetryInitialBackoffDefaultValue,
+		MaxBackoff:     RetryMaxBackoffDefaultValue,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+type retryableRequestKey struct{}
+
+// WithRetryableRequest returns a copy of ctx which marks requests built with it
+// as safe to retry, even if their HTTP method is not idempotent.
+func WithRetryableRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryableRequestKey{}, true)
+}
+
+func isRetryableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	retryable, _ := req.Context().Value(retryableRequestKey{}).(bool)
+	return retryable
+}
+
+// IsRetryableNetworkError reports whether err is a transient network error,
+// such as a refused or reset connection or a timeout. Errors caused by the
+// request's context being cancelled or expiring are never retryable.
+func IsRetryableNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// SetRetryPolicy sets the policy used to retry failed requests. A nil policy
+// disables retries, which is the default.
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retryPolicy = policy
+}
+
+// GetRetryPolicy returns the policy used to retry failed requests.
+func (c *Client) GetRetryPolicy() *RetryPolicy {
+	return c.retryPolicy
+}
+
+func (p *RetryPolicy) allowsRetry(req *http.Request) bool {
+	if p == nil || p.MaxAttempts <= 1 {
+		return false
+	}
+	return p.RetryNonIdempotent || isRetryableRequest(req)
+}
+
+func (p *RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		if p.IsRetryableError != nil {
+			return p.IsRetryableError(err)
+		}
+		return IsRetryableNetworkError(err)
+	}
+
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry attempt, starting at 1.
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	// Use "equal jitter": keep half of the delay and randomize the other half
+	if delay > 1 {
+		delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	}
+
+	if retryAfter := parseRetryAfter(resp); retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
+
+// parseRetryAfter returns the delay requested by the Retry-After header of
+// resp, which is either a number of seconds or an HTTP date.
+func parseRetryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// doWithRetry sends req, retrying according to the client's RetryPolicy.
+func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
+	policy := c.retryPolicy
+	if !policy.allowsRetry(req) {
+		return c.do(req)
+	}
+
+	if err := ensureRewindableBody(req); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.do(req)
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt, resp)
+		if err != nil {
+			c.log().Debug("Retrying request after error", "method", req.Method, "url", req.URL.String(), "delay", delay, "error", err)
+		} else {
+			c.log().Debug("Retrying request after HTTP error", "method", req.Method, "url", req.URL.String(), "delay", delay, "status", resp.StatusCode)
+			discardResponse(resp)
+		}
+
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if err := rewindBody(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ensureRewindableBody makes sure the body of req can be sent more than once,
+// buffering it in memory if necessary.
+func ensureRewindableBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
+func rewindBody(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// discardResponse reads and closes the body of a response which won't be
+// returned to the caller, so that the underlying connection can be reused.
+func discardResponse(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
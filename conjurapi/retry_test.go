@@ -0,0 +1,215 @@
+package conjurapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fastRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+// failing returns an option which makes a test server fail the first
+// `times` requests to paths starting with pathPrefix with status.
+func failing(pathPrefix string, times int, status int) conjurtest.Option {
+	return conjurtest.WithFault(conjurtest.Fault{PathPrefix: pathPrefix, Status: status, Times: times})
+}
+
+func newRetryTestClient(t *testing.T, server *conjurtest.Server, policy *RetryPolicy) *Client {
+	client := newTestServerClient(t, server)
+	client.SetRetryPolicy(policy)
+	return client
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	t.Run("Does not retry without a policy", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 1, http.StatusServiceUnavailable))
+		client := newRetryTestClient(t, server, nil)
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "503 Service Unavailable")
+		assert.Equal(t, 1, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Retries GET requests on retryable status codes", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 2, http.StatusBadGateway))
+		client := newRetryTestClient(t, server, fastRetryPolicy())
+
+		value, err := client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "my-secret", string(value))
+		assert.Equal(t, 3, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Gives up after MaxAttempts", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 5, http.StatusServiceUnavailable))
+		client := newRetryTestClient(t, server, fastRetryPolicy())
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "503 Service Unavailable")
+		assert.Equal(t, RetryMaxAttemptsDefaultValue, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Does not retry non-retryable status codes", func(t *testing.T) {
+		server := newTestServer(t)
+		client := newRetryTestClient(t, server, fastRetryPolicy())
+
+		_, err := client.RetrieveSecret("missing")
+		assert.Error(t, err)
+		assert.Equal(t, 1, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Does not retry non-idempotent requests by default", func(t *testing.T) {
+		server := newTestServer(t,
+			failing("/secrets", 1, http.StatusServiceUnavailable),
+			failing("/policies", 1, http.StatusServiceUnavailable),
+		)
+		client := newRetryTestClient(t, server, fastRetryPolicy())
+
+		err := client.AddSecret("my-var", "value")
+		assert.ErrorContains(t, err, "503 Service Unavailable")
+		assert.Equal(t, 1, server.CountRequests("/secrets"))
+
+		_, err = client.LoadPolicy(PolicyModePost, "root", strings.NewReader("- !variable other-var"))
+		assert.ErrorContains(t, err, "503 Service Unavailable")
+		assert.Equal(t, 1, server.CountRequests("/policies"))
+	})
+
+	t.Run("Retries non-idempotent requests when opted in", func(t *testing.T) {
+		server := newTestServer(t, failing("/policies", 1, http.StatusServiceUnavailable))
+		policy := fastRetryPolicy()
+		policy.RetryNonIdempotent = true
+		client := newRetryTestClient(t, server, policy)
+
+		// The policy body isn't rewindable, so it must be buffered to be replayed
+		req, err := client.LoadPolicyRequest(PolicyModePost, "root", io.MultiReader(strings.NewReader("- !variable other-var")), false)
+		require.NoError(t, err)
+		resp, err := client.SubmitRequest(req)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp.Body.Close()
+
+		requests := server.Requests()
+		last := requests[len(requests)-1]
+		assert.Equal(t, "- !variable other-var", string(last.Body))
+		assert.Equal(t, 2, server.CountRequests("/policies"))
+	})
+
+	t.Run("Retries requests marked as retryable", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 1, http.StatusServiceUnavailable))
+		client := newRetryTestClient(t, server, fastRetryPolicy())
+
+		err := client.AddSecretCtx(WithRetryableRequest(context.Background()), "my-var", "value")
+		assert.NoError(t, err)
+		assert.Equal(t, 2, server.CountRequests("/secrets"))
+	})
+
+	t.Run("Marks policy dry-run requests as retryable", func(t *testing.T) {
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: "http://conjur"}, sample_token)
+		require.NoError(t, err)
+
+		req, err := client.LoadPolicyRequest(PolicyModePost, "root", strings.NewReader(""), true)
+		require.NoError(t, err)
+		assert.True(t, isRetryableRequest(req))
+
+		req, err = client.LoadPolicyRequest(PolicyModePost, "root", strings.NewReader(""), false)
+		require.NoError(t, err)
+		assert.False(t, isRetryableRequest(req))
+	})
+
+	t.Run("Retries network errors", func(t *testing.T) {
+		// Find a free port, then close the listener so connections are refused
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		url := "http://" + listener.Addr().String()
+		listener.Close()
+
+		var attempts int32
+		policy := fastRetryPolicy()
+		policy.IsRetryableError = func(err error) bool {
+			atomic.AddInt32(&attempts, 1)
+			return IsRetryableNetworkError(err)
+		}
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: url}, sample_token)
+		require.NoError(t, err)
+		client.SetRetryPolicy(policy)
+
+		_, err = client.RetrieveSecret("my-var")
+		assert.Error(t, err)
+		assert.EqualValues(t, RetryMaxAttemptsDefaultValue-1, atomic.LoadInt32(&attempts))
+	})
+
+	t.Run("Stops waiting when the context is done", func(t *testing.T) {
+		server := newTestServer(t, failing("/secrets", 5, http.StatusServiceUnavailable))
+		policy := fastRetryPolicy()
+		policy.InitialBackoff = time.Hour
+		policy.MaxBackoff = time.Hour
+		client := newRetryTestClient(t, server, policy)
+		require.NoError(t, client.RefreshToken())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.RetrieveSecretCtx(ctx, "my-var")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 1, server.CountRequests("/secrets"))
+	})
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	t.Run("Grows exponentially with jitter", func(t *testing.T) {
+		for attempt, max := range map[int]time.Duration{1: 100, 2: 200, 3: 400, 4: 800} {
+			max *= time.Millisecond
+			delay := policy.backoff(attempt, nil)
+			assert.GreaterOrEqual(t, delay, max/2)
+			assert.LessOrEqual(t, delay, max)
+		}
+	})
+
+	t.Run("Is capped by MaxBackoff", func(t *testing.T) {
+		delay := policy.backoff(20, nil)
+		assert.LessOrEqual(t, delay, time.Second)
+	})
+
+	t.Run("Honors Retry-After in seconds", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+		assert.Equal(t, 3*time.Second, policy.backoff(1, resp))
+	})
+
+	t.Run("Honors Retry-After as an HTTP date", func(t *testing.T) {
+		date := time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat)
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{date}}}
+		delay := policy.backoff(1, resp)
+		assert.Greater(t, delay, 5*time.Second)
+		assert.LessOrEqual(t, delay, 10*time.Second)
+	})
+
+	t.Run("Ignores an invalid Retry-After", func(t *testing.T) {
+		resp := &http.Response{Header: http.Header{"Retry-After": []string{"soon"}}}
+		assert.LessOrEqual(t, policy.backoff(1, resp), 100*time.Millisecond)
+	})
+}
+
+func TestIsRetryableNetworkError(t *testing.T) {
+	assert.False(t, IsRetryableNetworkError(nil))
+	assert.False(t, IsRetryableNetworkError(context.Canceled))
+	assert.False(t, IsRetryableNetworkError(context.DeadlineExceeded))
+	assert.True(t, IsRetryableNetworkError(io.ErrUnexpectedEOF))
+	assert.True(t, IsRetryableNetworkError(&net.OpError{Op: "dial", Err: io.ErrClosedPipe}))
+	assert.False(t, IsRetryableNetworkError(io.ErrClosedPipe))
+}
@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -9,7 +10,15 @@ import (
 
 // RoleExists checks whether or not a role exists
 func (c *Client) RoleExists(roleID string) (bool, error) {
-	req, err := c.RoleRequest(roleID)
+	return c.RoleExistsCtx(context.Background(), roleID)
+}
+
+// RoleExistsCtx is like RoleExists but uses ctx for cancellation and deadlines.
+func (c *Client) RoleExistsCtx(ctx context.Context, roleID string) (_ bool, err error) {
+	ctx, span := c.startSpan(ctx, "RoleExists", "", roleID)
+	defer func() { span.end(err) }()
+
+	req, err := c.RoleRequestCtx(ctx, roleID)
 	if err != nil {
 		return false, err
 	}
@@ -31,7 +40,15 @@ func (c *Client) RoleExists(roleID string) (bool, error) {
 // Role fetches detailed information about a specific role, including
 // the role members
 func (c *Client) Role(roleID string) (role map[string]interface{}, err error) {
-	req, err := c.RoleRequest(roleID)
+	return c.RoleCtx(context.Background(), roleID)
+}
+
+// RoleCtx is like Role but uses ctx for cancellation and deadlines.
+func (c *Client) RoleCtx(ctx context.Context, roleID string) (role map[string]interface{}, err error) {
+	ctx, span := c.startSpan(ctx, "Role", "", roleID)
+	defer func() { span.end(err) }()
+
+	req, err := c.RoleRequestCtx(ctx, roleID)
 	if err != nil {
 		return
 	}
@@ -53,7 +70,15 @@ func (c *Client) Role(roleID string) (role map[string]interface{}, err error) {
 
 // RoleMembers fetches members within a role
 func (c *Client) RoleMembers(roleID string) (members []map[string]interface{}, err error) {
-	req, err := c.RoleMembersRequest(roleID)
+	return c.RoleMembersCtx(context.Background(), roleID)
+}
+
+// RoleMembersCtx is like RoleMembers but uses ctx for cancellation and deadlines.
+func (c *Client) RoleMembersCtx(ctx context.Context, roleID string) (members []map[string]interface{}, err error) {
+	ctx, span := c.startSpan(ctx, "RoleMembers", "", roleID)
+	defer func() { span.end(err) }()
+
+	req, err := c.RoleMembersRequestCtx(ctx, roleID)
 	if err != nil {
 		return
 	}
@@ -76,7 +101,15 @@ func (c *Client) RoleMembers(roleID string) (members []map[string]interface{}, e
 // RoleMemberships fetches memberships of a role, including
 // only roles for which the given ID is a direct member
 func (c *Client) RoleMemberships(roleID string) (memberships []map[string]interface{}, err error) {
-	req, err := c.RoleMembershipsRequest(roleID)
+	return c.RoleMembershipsCtx(context.Background(), roleID)
+}
+
+// RoleMembershipsCtx is like RoleMemberships but uses ctx for cancellation and deadlines.
+func (c *Client) RoleMembershipsCtx(ctx context.Context, roleID string) (memberships []map[string]interface{}, err error) {
+	ctx, span := c.startSpan(ctx, "RoleMemberships", "", roleID)
+	defer func() { span.end(err) }()
+
+	req, err := c.RoleMembershipsRequestCtx(ctx, roleID)
 	if err != nil {
 		return
 	}
@@ -99,7 +132,15 @@ func (c *Client) RoleMemberships(roleID string) (memberships []map[string]interf
 // RoleMembershipsAll fetches all memberships of a role, including
 // inherited memberships, returning a list of member IDs
 func (c *Client) RoleMembershipsAll(roleID string) (memberships []string, err error) {
-	req, err := c.RoleMembershipsRequestWithOptions(roleID, true)
+	return c.RoleMembershipsAllCtx(context.Background(), roleID)
+}
+
+// RoleMembershipsAllCtx is like RoleMembershipsAll but uses ctx for cancellation and deadlines.
+func (c *Client) RoleMembershipsAllCtx(ctx context.Context, roleID string) (memberships []string, err error) {
+	ctx, span := c.startSpan(ctx, "RoleMembershipsAll", "", roleID)
+	defer func() { span.end(err) }()
+
+	req, err := c.RoleMembershipsRequestWithOptionsCtx(ctx, roleID, true)
 	if err != nil {
 		return
 	}
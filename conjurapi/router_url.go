@@ -4,8 +4,6 @@ import (
 	"fmt"
 	"path"
 	"strings"
-
-	"github.com/cyberark/conjur-api-go/conjurapi/logging"
 )
 
 var ConjurCloudSuffixes = []string{
@@ -39,13 +37,22 @@ func normalizeBaseURL(baseURL string) string {
 	url := strings.TrimSuffix(baseURL, "/")
 
 	if isConjurCloudURL(url) && !strings.HasSuffix(url, "/api") {
-		logging.ApiLog.Info("Detected Conjur Cloud URL, adding '/api' prefix")
 		return url + "/api"
 	}
 
 	return url
 }
 
+// logCloudURL logs, on the client's first request, that the '/api' prefix is
+// added to its Conjur Cloud URL.
+func (c *Client) logCloudURL() {
+	c.cloudURLLogged.Do(func() {
+		if normalizeBaseURL(c.config.ApplianceURL) != strings.TrimSuffix(c.config.ApplianceURL, "/") {
+			c.log().Info("Detected Conjur Cloud URL, adding '/api' prefix")
+		}
+	})
+}
+
 func isConjurCloudURL(baseURL string) bool {
 	url := strings.TrimSuffix(baseURL, "/")
 
@@ -57,3 +64,15 @@ func isConjurCloudURL(baseURL string) bool {
 
 	return false
 }
+
+// rebase returns u with the normalized base URL oldBase replaced by newBase,
+// so that a request built for one Conjur node can be sent to another. It
+// returns false when u isn't under oldBase.
+func (u routerURL) rebase(oldBase, newBase string) (routerURL, bool) {
+	oldBase = normalizeBaseURL(oldBase)
+	s := string(u)
+	if s != oldBase && !strings.HasPrefix(s, oldBase+"/") && !strings.HasPrefix(s, oldBase+"?") {
+		return u, false
+	}
+	return routerURL(normalizeBaseURL(newBase) + strings.TrimPrefix(s, oldBase)), true
+}
@@ -38,3 +38,30 @@ func Test_makeRouterURL(t *testing.T) {
 		})
 	})
 }
+
+func Test_routerURL_rebase(t *testing.T) {
+	t.Run("rebase replaces the base URL", func(t *testing.T) {
+		rebased, ok := makeRouterURL("https://leader", "secrets/account/variable/one").rebase("https://leader", "https://follower:8443")
+		assert.True(t, ok)
+		assert.Equal(t, routerURL("https://follower:8443/secrets/account/variable/one"), rebased)
+	})
+
+	t.Run("rebase keeps the query", func(t *testing.T) {
+		rebased, ok := makeRouterURL("https://leader", "resources/account").withQuery("kind=variable").rebase("https://leader", "https://follower")
+		assert.True(t, ok)
+		assert.Equal(t, routerURL("https://follower/resources/account?kind=variable"), rebased)
+	})
+
+	t.Run("rebase handles Conjur Cloud base URLs", func(t *testing.T) {
+		rebased, ok := makeRouterURL("https://leader.secretsmgr.cyberark.cloud", "some/path").rebase("https://leader.secretsmgr.cyberark.cloud", "https://follower.secretsmgr.cyberark.cloud/api")
+		assert.True(t, ok)
+		assert.Equal(t, routerURL("https://follower.secretsmgr.cyberark.cloud/api/some/path"), rebased)
+	})
+
+	t.Run("rebase ignores URLs under a different base", func(t *testing.T) {
+		u := routerURL("https://leader-2/some/path")
+		rebased, ok := u.rebase("https://leader", "https://follower")
+		assert.False(t, ok)
+		assert.Equal(t, u, rebased)
+	})
+}
@@ -0,0 +1,699 @@
+// Package secretcache provides an in-memory cache of secret values in front
+// of a conjurapi.SecretsReader, usually a *conjurapi.Client, for services
+// which retrieve secrets on hot paths:
+//
+//	cache, err := secretcache.New(client, secretcache.Options{
+//		TTL:                  time.Minute,
+//		StaleWhileRevalidate: 30 * time.Second,
+//		MaxStale:             time.Hour,
+//	})
+//	...
+//	defer cache.Close()
+//	value, err := cache.RetrieveSecret("db/password")
+//
+// Values are served from the cache for their TTL. During the
+// StaleWhileRevalidate window which follows, they are still served while
+// they are retrieved again in the background. Later on they are retrieved
+// before being served, unless Conjur can't be reached, in which case they are
+// served for up to MaxStale past their TTL.
+//
+// Values are only ever kept in memory. Callers get copies of them, and the
+// cached copies are zeroed when they are replaced, invalidated or evicted,
+// and when the cache is closed.
+package secretcache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+)
+
+const (
+	// DefaultTTL is how long values are cached unless Options.TTL is set.
+	DefaultTTL = 5 * time.Minute
+	// DefaultMaxEntries is how many values are cached unless
+	// Options.MaxEntries is set.
+	DefaultMaxEntries = 1000
+)
+
+// Options configures a Cache.
+type Options struct {
+	// Account is the account of variables whose ID isn't fully qualified.
+	// It defaults to that of the client's Config when the cache wraps a
+	// *conjurapi.Client.
+	Account string
+	// TTL is how long values are served from the cache, DefaultTTL if zero.
+	TTL time.Duration
+	// VariableTTLs overrides TTL for some variables, by ID, e.g.
+	// "db/password" or "myorg:variable:db/password".
+	VariableTTLs map[string]time.Duration
+	// StaleWhileRevalidate is how long past their TTL values are still
+	// served while they are retrieved again in the background.
+	StaleWhileRevalidate time.Duration
+	// MaxStale is how long past their TTL values are served when Conjur
+	// can't be reached: on network errors, such as failures to connect and
+	// timeouts, and 5xx responses.
+	MaxStale time.Duration
+	// MaxEntries is how many values the cache holds, DefaultMaxEntries if
+	// zero. The least recently used values are evicted first.
+	MaxEntries int
+	// MaxBytes is how many bytes of values the cache holds, unlimited if
+	// zero.
+	MaxBytes int
+	// Metrics records the cache's lookups under the "secrets" cache, when
+	// set.
+	Metrics conjurapi.Metrics
+}
+
+// Cache is a conjurapi.SecretsReader and conjurapi.PartialSecretsReader which
+// caches the values of variables retrieved with RetrieveSecret,
+// RetrieveBatchSecrets and RetrieveBatchSecretsPartial, and their Ctx, Reader
+// and Safe variants. Values of specific versions aren't cached.
+type Cache struct {
+	secrets conjurapi.SecretsReader
+	options Options
+	ttls    map[string]time.Duration
+	now     func() time.Time
+
+	mutex    sync.Mutex
+	entries  map[string]*list.Element
+	lru      *list.List
+	size     int
+	inflight map[string]*call
+	closed   bool
+
+	background sync.WaitGroup
+}
+
+type entry struct {
+	key        string
+	value      []byte
+	fetchedAt  time.Time
+	ttl        time.Duration
+	refreshing bool
+}
+
+// call is a retrieval of a single value, shared by concurrent lookups.
+type call struct {
+	done  chan struct{}
+	value []byte
+	err   error
+}
+
+var (
+	_ conjurapi.SecretsReader        = (*Cache)(nil)
+	_ conjurapi.PartialSecretsReader = (*Cache)(nil)
+)
+
+// New returns a Cache of the secrets retrieved with secrets.
+func New(secrets conjurapi.SecretsReader, options Options) (*Cache, error) {
+	if options.Account == "" {
+		if client, ok := secrets.(interface{ GetConfig() conjurapi.Config }); ok {
+			options.Account = client.GetConfig().Account
+		}
+	}
+	if options.Account == "" {
+		return nil, fmt.Errorf("Must specify the account of the cached variables")
+	}
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = DefaultMaxEntries
+	}
+
+	c := &Cache{
+		secrets:  secrets,
+		options:  options,
+		ttls:     map[string]time.Duration{},
+		now:      time.Now,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		inflight: map[string]*call{},
+	}
+	for id, ttl := range options.VariableTTLs {
+		c.ttls[c.key(id)] = ttl
+	}
+	return c, nil
+}
+
+// key returns the fully-qualified ID of a variable, which may be given by its
+// identifier or a partially-qualified ID. IDs are qualified like the client
+// qualifies them: one whose first parts aren't the account and "variable" is
+// an identifier containing ':', e.g. "foo:bar:baz".
+func (c *Cache) key(variableID string) string {
+	parts := strings.SplitN(variableID, ":", 3)
+	switch len(parts) {
+	case 1:
+		return c.options.Account + ":variable:" + variableID
+	case 2:
+		parts = []string{c.options.Account, parts[0], parts[1]}
+	}
+	if parts[0] != c.options.Account || parts[1] != "variable" {
+		return c.options.Account + ":variable:" + variableID
+	}
+	return strings.Join(parts, ":")
+}
+
+func (c *Cache) ttl(key string) time.Duration {
+	if ttl, ok := c.ttls[key]; ok {
+		return ttl
+	}
+	return c.options.TTL
+}
+
+func (c *Cache) observe(hit bool) {
+	if c.options.Metrics != nil {
+		c.options.Metrics.ObserveCacheLookup("secrets", hit)
+	}
+}
+
+// Invalidate removes the values of variables from the cache.
+func (c *Cache) Invalidate(variableIDs ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, id := range variableIDs {
+		c.remove(c.key(id))
+	}
+}
+
+// InvalidateAll removes all the values from the cache.
+func (c *Cache) InvalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key := range c.entries {
+		c.remove(key)
+	}
+}
+
+// Len returns the number of values in the cache.
+func (c *Cache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// Close waits for background retrievals and wipes the cache. Retrievals
+// after Close bypass the cache.
+func (c *Cache) Close() error {
+	c.mutex.Lock()
+	c.closed = true
+	c.mutex.Unlock()
+
+	c.background.Wait()
+	c.InvalidateAll()
+	return nil
+}
+
+// lookup returns the entry for key, if any, as the most recently used.
+// c.mutex must be held.
+func (c *Cache) lookup(key string) *entry {
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*entry)
+}
+
+// store caches a copy of value and evicts the least recently used values
+// beyond the cache's bounds. c.mutex must be held.
+func (c *Cache) store(key string, value []byte, fetchedAt time.Time) {
+	if c.closed {
+		return
+	}
+	c.remove(key)
+	e := &entry{key: key, value: append([]byte{}, value...), fetchedAt: fetchedAt, ttl: c.ttl(key)}
+	c.entries[key] = c.lru.PushFront(e)
+	c.size += len(e.value)
+
+	for c.lru.Len() > 1 && (c.lru.Len() > c.options.MaxEntries || (c.options.MaxBytes > 0 && c.size > c.options.MaxBytes)) {
+		c.remove(c.lru.Back().Value.(*entry).key)
+	}
+}
+
+// remove removes and wipes the entry for key. c.mutex must be held.
+func (c *Cache) remove(key string) {
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	e := element.Value.(*entry)
+	c.lru.Remove(element)
+	delete(c.entries, key)
+	c.size -= len(e.value)
+	wipe(e.value)
+}
+
+// cached returns a copy of the value for key when it can be served without
+// retrieving it, and whether it should be revalidated in the background.
+// c.mutex must be held.
+func (c *Cache) cached(key string, now time.Time) (value []byte, revalidate bool, ok bool) {
+	e := c.lookup(key)
+	if e == nil || c.closed {
+		return nil, false, false
+	}
+	age := now.Sub(e.fetchedAt)
+	switch {
+	case age < e.ttl:
+		return append([]byte{}, e.value...), false, true
+	case age < e.ttl+c.options.StaleWhileRevalidate:
+		revalidate = !e.refreshing
+		e.refreshing = true
+		return append([]byte{}, e.value...), revalidate, true
+	default:
+		return nil, false, false
+	}
+}
+
+// stale returns a copy of the value for key if it may be served because
+// Conjur can't be reached. c.mutex must be held.
+func (c *Cache) stale(key string, now time.Time) ([]byte, bool) {
+	e := c.lookup(key)
+	if e == nil || now.Sub(e.fetchedAt) >= e.ttl+c.options.MaxStale {
+		return nil, false
+	}
+	return append([]byte{}, e.value...), true
+}
+
+// failed handles the failure to retrieve the value for key: values of
+// variables which are gone or no longer permitted are removed. c.mutex must
+// be held.
+func (c *Cache) failed(key string, err error) {
+	var cerr *response.ConjurError
+	if errors.As(err, &cerr) && cerr.Code >= 400 && cerr.Code < 500 {
+		c.remove(key)
+	} else if e := c.lookup(key); e != nil {
+		e.refreshing = false
+	}
+}
+
+func (c *Cache) RetrieveSecret(variableID string) ([]byte, error) {
+	return c.RetrieveSecretCtx(context.Background(), variableID)
+}
+
+func (c *Cache) RetrieveSecretCtx(ctx context.Context, variableID string) ([]byte, error) {
+	key := c.key(variableID)
+
+	c.mutex.Lock()
+	value, revalidate, ok := c.cached(key, c.now())
+	if revalidate {
+		c.background.Add(1)
+		go func() {
+			defer c.background.Done()
+			c.fetch(context.WithoutCancel(ctx), key, variableID)
+		}()
+	}
+	c.mutex.Unlock()
+	c.observe(ok)
+	if ok {
+		return value, nil
+	}
+
+	value, err := c.fetch(ctx, key, variableID)
+	if err != nil && unreachable(ctx, err) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		if value, ok := c.stale(key, c.now()); ok {
+			return value, nil
+		}
+	}
+	return value, err
+}
+
+// fetch retrieves the value for key and caches it. Concurrent retrievals of
+// the same value are sent once.
+func (c *Cache) fetch(ctx context.Context, key string, variableID string) ([]byte, error) {
+	c.mutex.Lock()
+	if pending, ok := c.inflight[key]; ok {
+		c.mutex.Unlock()
+		select {
+		case <-pending.done:
+			if pending.err != nil {
+				return nil, pending.err
+			}
+			return append([]byte{}, pending.value...), nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	pending := &call{done: make(chan struct{})}
+	c.inflight[key] = pending
+	c.mutex.Unlock()
+
+	fetchedAt := c.now()
+	value, err := c.secrets.RetrieveSecretCtx(ctx, variableID)
+
+	c.mutex.Lock()
+	delete(c.inflight, key)
+	if err != nil {
+		c.failed(key, err)
+	} else {
+		c.store(key, value, fetchedAt)
+	}
+	c.mutex.Unlock()
+
+	if err == nil {
+		pending.value = append([]byte{}, value...)
+	}
+	pending.err = err
+	close(pending.done)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func (c *Cache) RetrieveSecretReader(variableID string) (io.ReadCloser, error) {
+	return c.RetrieveSecretReaderCtx(context.Background(), variableID)
+}
+
+func (c *Cache) RetrieveSecretReaderCtx(ctx context.Context, variableID string) (io.ReadCloser, error) {
+	value, err := c.RetrieveSecretCtx(ctx, variableID)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(string(value))), nil
+}
+
+func (c *Cache) RetrieveSecretWithVersion(variableID string, version int) ([]byte, error) {
+	return c.secrets.RetrieveSecretWithVersion(variableID, version)
+}
+
+func (c *Cache) RetrieveSecretWithVersionCtx(ctx context.Context, variableID string, version int) ([]byte, error) {
+	return c.secrets.RetrieveSecretWithVersionCtx(ctx, variableID, version)
+}
+
+func (c *Cache) RetrieveSecretWithVersionReader(variableID string, version int) (io.ReadCloser, error) {
+	return c.secrets.RetrieveSecretWithVersionReader(variableID, version)
+}
+
+func (c *Cache) RetrieveSecretWithVersionReaderCtx(ctx context.Context, variableID string, version int) (io.ReadCloser, error) {
+	return c.secrets.RetrieveSecretWithVersionReaderCtx(ctx, variableID, version)
+}
+
+func (c *Cache) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error) {
+	return c.RetrieveBatchSecretsCtx(context.Background(), variableIDs)
+}
+
+func (c *Cache) RetrieveBatchSecretsCtx(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+	return c.retrieveBatch(ctx, variableIDs, false)
+}
+
+func (c *Cache) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error) {
+	return c.RetrieveBatchSecretsSafeCtx(context.Background(), variableIDs)
+}
+
+func (c *Cache) RetrieveBatchSecretsSafeCtx(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+	return c.retrieveBatch(ctx, variableIDs, true)
+}
+
+// retrieveBatch serves the values of variableIDs from the cache, and
+// retrieves those which can't be in a single batch. The values are keyed by
+// fully-qualified ID, as they are by conjurapi.Client.
+//
+// Batches are retrieved with RetrieveBatchSecretsSafeCtx when safe is true,
+// and RetrieveBatchSecretsCtx otherwise, so that the Conjur versions which
+// don't base64-encode batches are still supported. Both return the raw values
+// which RetrieveSecret returns too, so they are cached alike.
+func (c *Cache) retrieveBatch(ctx context.Context, variableIDs []string, safe bool) (map[string][]byte, error) {
+	values := map[string][]byte{}
+	seen := map[string]bool{}
+	var missing, revalidate []string
+
+	c.mutex.Lock()
+	now := c.now()
+	for _, id := range variableIDs {
+		key := c.key(id)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		value, stale, ok := c.cached(key, now)
+		c.observe(ok)
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		values[key] = value
+		if stale {
+			revalidate = append(revalidate, id)
+		}
+	}
+	if len(revalidate) > 0 {
+		c.background.Add(1)
+		go func() {
+			defer c.background.Done()
+			c.fetchBatch(context.WithoutCancel(ctx), revalidate, safe)
+		}()
+	}
+	c.mutex.Unlock()
+
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	fetched, err := c.fetchBatch(ctx, missing, safe)
+	if err != nil {
+		if !unreachable(ctx, err) {
+			wipeAll(values)
+			return nil, err
+		}
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for _, id := range missing {
+			value, ok := c.stale(c.key(id), c.now())
+			if !ok {
+				wipeAll(values)
+				return nil, err
+			}
+			values[c.key(id)] = value
+		}
+		return values, nil
+	}
+	for key, value := range fetched {
+		values[key] = value
+	}
+	return values, nil
+}
+
+// fetchBatch retrieves the values of variableIDs and caches them.
+func (c *Cache) fetchBatch(ctx context.Context, variableIDs []string, safe bool) (map[string][]byte, error) {
+	fetchedAt := c.now()
+	var fetched map[string][]byte
+	var err error
+	if safe {
+		fetched, err = c.secrets.RetrieveBatchSecretsSafeCtx(ctx, variableIDs)
+	} else {
+		fetched, err = c.secrets.RetrieveBatchSecretsCtx(ctx, variableIDs)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err != nil {
+		for _, id := range variableIDs {
+			if e := c.lookup(c.key(id)); e != nil {
+				e.refreshing = false
+			}
+		}
+		return nil, err
+	}
+
+	values := map[string][]byte{}
+	for id, value := range fetched {
+		key := c.key(id)
+		c.store(key, value, fetchedAt)
+		values[key] = value
+	}
+	return values, nil
+}
+
+func (c *Cache) RetrieveBatchSecretsPartial(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return c.RetrieveBatchSecretsPartialCtx(context.Background(), variableIDs)
+}
+
+func (c *Cache) RetrieveBatchSecretsPartialCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return c.retrievePartial(ctx, variableIDs, false)
+}
+
+func (c *Cache) RetrieveBatchSecretsPartialSafe(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return c.RetrieveBatchSecretsPartialSafeCtx(context.Background(), variableIDs)
+}
+
+func (c *Cache) RetrieveBatchSecretsPartialSafeCtx(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return c.retrievePartial(ctx, variableIDs, true)
+}
+
+// retrievePartial is like retrieveBatch, but the variables which can't be
+// retrieved are reported with their errors rather than failing the call.
+// When the cached reader isn't a conjurapi.PartialSecretsReader, they are
+// retrieved one by one.
+func (c *Cache) retrievePartial(ctx context.Context, variableIDs []string, safe bool) (map[string][]byte, map[string]error, error) {
+	values := map[string][]byte{}
+	seen := map[string]bool{}
+	var missing, revalidate []string
+
+	c.mutex.Lock()
+	now := c.now()
+	for _, id := range variableIDs {
+		key := c.key(id)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		value, stale, ok := c.cached(key, now)
+		c.observe(ok)
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		values[key] = value
+		if stale {
+			revalidate = append(revalidate, id)
+		}
+	}
+	if len(revalidate) > 0 {
+		c.background.Add(1)
+		go func() {
+			defer c.background.Done()
+			c.fetchPartial(context.WithoutCancel(ctx), revalidate, safe)
+		}()
+	}
+	c.mutex.Unlock()
+
+	variableErrors := map[string]error{}
+	if len(missing) == 0 {
+		return values, variableErrors, nil
+	}
+
+	fetched, fetchErrors, err := c.fetchPartial(ctx, missing, safe)
+	if err != nil {
+		if !unreachable(ctx, err) {
+			wipeAll(values)
+			return nil, nil, err
+		}
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for _, id := range missing {
+			value, ok := c.stale(c.key(id), c.now())
+			if !ok {
+				wipeAll(values)
+				return nil, nil, err
+			}
+			values[c.key(id)] = value
+		}
+		return values, variableErrors, nil
+	}
+	for key, value := range fetched {
+		values[key] = value
+	}
+	for key, err := range fetchErrors {
+		variableErrors[key] = err
+	}
+	return values, variableErrors, nil
+}
+
+// fetchPartial retrieves the values of variableIDs, caches those which could
+// be retrieved and handles the failures of the others.
+func (c *Cache) fetchPartial(ctx context.Context, variableIDs []string, safe bool) (map[string][]byte, map[string]error, error) {
+	partial, ok := c.secrets.(conjurapi.PartialSecretsReader)
+	if !ok {
+		values := map[string][]byte{}
+		variableErrors := map[string]error{}
+		for _, id := range variableIDs {
+			value, err := c.fetch(ctx, c.key(id), id)
+			var cerr *response.ConjurError
+			switch {
+			case err == nil:
+				values[c.key(id)] = value
+			case errors.As(err, &cerr) && cerr.Code < 500:
+				variableErrors[c.key(id)] = err
+			default:
+				wipeAll(values)
+				return nil, nil, err
+			}
+		}
+		return values, variableErrors, nil
+	}
+
+	fetchedAt := c.now()
+	var fetched map[string][]byte
+	var fetchErrors map[string]error
+	var err error
+	if safe {
+		fetched, fetchErrors, err = partial.RetrieveBatchSecretsPartialSafeCtx(ctx, variableIDs)
+	} else {
+		fetched, fetchErrors, err = partial.RetrieveBatchSecretsPartialCtx(ctx, variableIDs)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err != nil {
+		for _, id := range variableIDs {
+			if e := c.lookup(c.key(id)); e != nil {
+				e.refreshing = false
+			}
+		}
+		return nil, nil, err
+	}
+
+	values := map[string][]byte{}
+	for id, value := range fetched {
+		key := c.key(id)
+		c.store(key, value, fetchedAt)
+		values[key] = value
+	}
+	variableErrors := map[string]error{}
+	for id, err := range fetchErrors {
+		key := c.key(id)
+		c.failed(key, err)
+		variableErrors[key] = err
+	}
+	return values, variableErrors, nil
+}
+
+// unreachable reports whether err means that Conjur couldn't be reached: a
+// network error or a 5xx response. Other errors, such as those of TLS
+// handshakes and of responses which can't be parsed, and the caller giving
+// up, aren't solved by serving stale values.
+func unreachable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var cerr *response.ConjurError
+	if errors.As(err, &cerr) {
+		return cerr.Code >= 500
+	}
+	// *url.Error is a net.Error whatever it wraps, so only its timeouts and
+	// the failures to dial, read or write count.
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		var operr *net.OpError
+		var dnserr *net.DNSError
+		return uerr.Timeout() || errors.As(uerr.Err, &operr) || errors.As(uerr.Err, &dnserr)
+	}
+	var nerr net.Error
+	return errors.As(err, &nerr)
+}
+
+func wipe(value []byte) {
+	for i := range value {
+		value[i] = 0
+	}
+}
+
+func wipeAll(values map[string][]byte) {
+	for _, value := range values {
+		wipe(value)
+	}
+}
@@ -0,0 +1,562 @@
+package secretcache
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurfake"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeConjur serves the values of variables, which tests change, and counts
+// the requests.
+type fakeConjur struct {
+	mutex    sync.Mutex
+	values   map[string]string
+	err      error
+	requests int
+	batches  [][]string
+}
+
+func (f *fakeConjur) reader() *conjurfake.SecretsReader {
+	return &conjurfake.SecretsReader{
+		RetrieveSecretFunc: func(ctx context.Context, variableID string) ([]byte, error) {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			f.requests++
+			if f.err != nil {
+				return nil, f.err
+			}
+			value, ok := f.values[qualify(variableID)]
+			if !ok {
+				return nil, &response.ConjurError{Code: 404, Message: "Not Found"}
+			}
+			return []byte(value), nil
+		},
+		RetrieveBatchSecretsFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			f.requests++
+			f.batches = append(f.batches, variableIDs)
+			if f.err != nil {
+				return nil, f.err
+			}
+			values := map[string][]byte{}
+			for _, id := range variableIDs {
+				values[qualify(id)] = []byte(f.values[qualify(id)])
+			}
+			return values, nil
+		},
+		RetrieveBatchSecretsPartialFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+			f.mutex.Lock()
+			defer f.mutex.Unlock()
+			f.requests++
+			f.batches = append(f.batches, variableIDs)
+			if f.err != nil {
+				return nil, nil, f.err
+			}
+			values := map[string][]byte{}
+			variableErrors := map[string]error{}
+			for _, id := range variableIDs {
+				if value, ok := f.values[qualify(id)]; ok {
+					values[qualify(id)] = []byte(value)
+				} else {
+					variableErrors[qualify(id)] = &response.ConjurError{Code: 404, Message: "Not Found"}
+				}
+			}
+			return values, variableErrors, nil
+		},
+	}
+}
+
+var errConnectionRefused = &url.Error{
+	Op:  "Get",
+	URL: "https://conjur/secrets/conjur/variable/db%2Fpassword",
+	Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+}
+
+// qualify returns the fully-qualified ID of a variable in the "conjur"
+// account, as conjurapi.Client does.
+func qualify(variableID string) string {
+	switch {
+	case strings.HasPrefix(variableID, "conjur:variable:"):
+		return variableID
+	case strings.Count(variableID, ":") == 1 && strings.HasPrefix(variableID, "variable:"):
+		return "conjur:" + variableID
+	default:
+		return "conjur:variable:" + variableID
+	}
+}
+
+func (f *fakeConjur) set(id string, value string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.values["conjur:variable:"+id] = value
+}
+
+func (f *fakeConjur) fail(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.err = err
+}
+
+func (f *fakeConjur) count() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.requests
+}
+
+type clock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type cacheMetrics struct {
+	conjurapi.Metrics
+	hits, misses int
+}
+
+func (m *cacheMetrics) ObserveCacheLookup(cache string, hit bool) {
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func newTestCache(t *testing.T, options Options) (*Cache, *fakeConjur, *clock) {
+	conjur := &fakeConjur{values: map[string]string{
+		"conjur:variable:db/password": "first",
+		"conjur:variable:db/username": "app",
+	}}
+	options.Account = "conjur"
+	cache, err := New(conjur.reader(), options)
+	require.NoError(t, err)
+	t.Cleanup(func() { cache.Close() })
+
+	clock := &clock{now: time.Now()}
+	cache.now = clock.Now
+	return cache, conjur, clock
+}
+
+func TestCache_RetrieveSecret(t *testing.T) {
+	t.Run("Serves values for their TTL", func(t *testing.T) {
+		metrics := &cacheMetrics{}
+		cache, conjur, clock := newTestCache(t, Options{
+			TTL:          time.Minute,
+			VariableTTLs: map[string]time.Duration{"variable:db/username": time.Hour},
+			Metrics:      metrics,
+		})
+
+		value, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(value))
+		conjur.set("db/password", "second")
+
+		value, err = cache.RetrieveSecret("conjur:variable:db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(value))
+		assert.Equal(t, 1, conjur.count())
+		assert.Equal(t, 1, metrics.hits)
+		assert.Equal(t, 1, metrics.misses)
+
+		_, err = cache.RetrieveSecret("db/username")
+		require.NoError(t, err)
+		clock.Advance(2 * time.Minute)
+
+		value, err = cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(value))
+		_, err = cache.RetrieveSecret("db/username")
+		require.NoError(t, err)
+		assert.Equal(t, 3, conjur.count())
+	})
+
+	t.Run("Returns copies of the values", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, Options{})
+
+		value, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		copy(value, "XXXXX")
+
+		reader, err := cache.RetrieveSecretReader("db/password")
+		require.NoError(t, err)
+		value, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(value))
+	})
+
+	t.Run("Revalidates stale values in the background", func(t *testing.T) {
+		cache, conjur, clock := newTestCache(t, Options{TTL: time.Minute, StaleWhileRevalidate: time.Minute})
+
+		_, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		conjur.set("db/password", "second")
+		clock.Advance(90 * time.Second)
+
+		value, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(value))
+		cache.background.Wait()
+		assert.Equal(t, 2, conjur.count())
+
+		value, err = cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "second", string(value))
+		assert.Equal(t, 2, conjur.count())
+	})
+
+	t.Run("Serves stale values when Conjur is unreachable", func(t *testing.T) {
+		cache, conjur, clock := newTestCache(t, Options{TTL: time.Minute, MaxStale: time.Hour})
+
+		_, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		clock.Advance(30 * time.Minute)
+
+		conjur.fail(errConnectionRefused)
+		value, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(value))
+
+		conjur.fail(&response.ConjurError{Code: 503, Message: "Service Unavailable"})
+		_, err = cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+
+		clock.Advance(time.Hour)
+		_, err = cache.RetrieveSecret("db/password")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "Service Unavailable")
+	})
+
+	t.Run("Drops values which are no longer permitted", func(t *testing.T) {
+		cache, conjur, clock := newTestCache(t, Options{TTL: time.Minute, MaxStale: time.Hour})
+
+		_, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		clock.Advance(2 * time.Minute)
+
+		conjur.fail(&response.ConjurError{Code: 403, Message: "Forbidden"})
+		_, err = cache.RetrieveSecret("db/password")
+		require.Error(t, err)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("Shares concurrent retrievals", func(t *testing.T) {
+		cache, conjur, _ := newTestCache(t, Options{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				value, err := cache.RetrieveSecret("db/password")
+				assert.NoError(t, err)
+				assert.Equal(t, "first", string(value))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, conjur.count())
+		assert.Equal(t, 1, cache.Len())
+	})
+}
+
+func TestCache_RetrieveBatchSecrets_Safe(t *testing.T) {
+	t.Run("Caches binary values retrieved with the Safe methods", func(t *testing.T) {
+		var safe int
+		cache, err := New(&conjurfake.SecretsReader{
+			RetrieveBatchSecretsFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+				return nil, &response.ConjurError{Code: 406, Message: "Not Acceptable"}
+			},
+			RetrieveBatchSecretsSafeFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+				safe++
+				return map[string][]byte{"conjur:variable:certificate": []byte("\xff\xfe")}, nil
+			},
+		}, Options{Account: "conjur"})
+		require.NoError(t, err)
+		defer cache.Close()
+
+		_, err = cache.RetrieveBatchSecrets([]string{"certificate"})
+		assert.EqualError(t, err, "Not Acceptable. ")
+
+		values, err := cache.RetrieveBatchSecretsSafe([]string{"certificate"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\xff\xfe"), values["conjur:variable:certificate"])
+
+		values, err = cache.RetrieveBatchSecrets([]string{"certificate"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\xff\xfe"), values["conjur:variable:certificate"])
+		value, err := cache.RetrieveSecret("certificate")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("\xff\xfe"), value)
+		assert.Equal(t, 1, safe)
+	})
+
+	t.Run("Doesn't need base64 batches for RetrieveBatchSecrets", func(t *testing.T) {
+		notBase64 := errors.New("Conjur response is not Base64-encoded.")
+		cache, err := New(&conjurfake.SecretsReader{
+			RetrieveBatchSecretsFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+				return map[string][]byte{"conjur:variable:db/password": []byte("secret")}, nil
+			},
+			RetrieveBatchSecretsSafeFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+				return nil, notBase64
+			},
+			RetrieveBatchSecretsPartialFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+				return map[string][]byte{"conjur:variable:db/username": []byte("app")}, nil, nil
+			},
+			RetrieveBatchSecretsPartialSafeFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, map[string]error, error) {
+				return nil, nil, notBase64
+			},
+		}, Options{Account: "conjur"})
+		require.NoError(t, err)
+		defer cache.Close()
+
+		values, err := cache.RetrieveBatchSecrets([]string{"db/password"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("secret"), values["conjur:variable:db/password"])
+
+		values, _, err = cache.RetrieveBatchSecretsPartial([]string{"db/username"})
+		require.NoError(t, err)
+		assert.Equal(t, []byte("app"), values["conjur:variable:db/username"])
+
+		_, err = cache.RetrieveBatchSecretsSafe([]string{"other"})
+		assert.Equal(t, notBase64, err)
+	})
+}
+
+func TestCache_RetrieveBatchSecrets(t *testing.T) {
+	cache, conjur, _ := newTestCache(t, Options{})
+
+	_, err := cache.RetrieveSecret("db/password")
+	require.NoError(t, err)
+
+	values, err := cache.RetrieveBatchSecrets([]string{"db/password", "variable:db/username", "db/password"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"conjur:variable:db/password": []byte("first"),
+		"conjur:variable:db/username": []byte("app"),
+	}, values)
+	assert.Equal(t, [][]string{{"variable:db/username"}}, conjur.batches)
+
+	values, err = cache.RetrieveBatchSecretsSafe([]string{"db/password", "db/username"})
+	require.NoError(t, err)
+	assert.Len(t, values, 2)
+	assert.Equal(t, 2, conjur.count())
+}
+
+func TestCache_RetrieveBatchSecretsPartial(t *testing.T) {
+	errorCodes := func(variableErrors map[string]error) map[string]int {
+		codes := map[string]int{}
+		for id, err := range variableErrors {
+			codes[id] = err.(*response.ConjurError).Code
+		}
+		return codes
+	}
+
+	t.Run("Reports the variables which can't be retrieved", func(t *testing.T) {
+		cache, conjur, _ := newTestCache(t, Options{})
+
+		_, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+
+		values, variableErrors, err := cache.RetrieveBatchSecretsPartial([]string{"db/password", "db/username", "missing"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{
+			"conjur:variable:db/password": []byte("first"),
+			"conjur:variable:db/username": []byte("app"),
+		}, values)
+		assert.Equal(t, map[string]int{"conjur:variable:missing": 404}, errorCodes(variableErrors))
+		assert.Equal(t, [][]string{{"db/username", "missing"}}, conjur.batches)
+
+		_, variableErrors, err = cache.RetrieveBatchSecretsPartialSafe([]string{"db/username", "missing"})
+		require.NoError(t, err)
+		assert.Len(t, variableErrors, 1)
+		assert.Equal(t, [][]string{{"db/username", "missing"}, {"missing"}}, conjur.batches)
+		assert.Equal(t, 2, cache.Len())
+	})
+
+	t.Run("Serves stale values when Conjur is unreachable", func(t *testing.T) {
+		cache, conjur, clock := newTestCache(t, Options{TTL: time.Minute, MaxStale: time.Hour})
+
+		_, _, err := cache.RetrieveBatchSecretsPartial([]string{"db/password"})
+		require.NoError(t, err)
+		clock.Advance(2 * time.Minute)
+
+		conjur.fail(errConnectionRefused)
+		values, _, err := cache.RetrieveBatchSecretsPartial([]string{"db/password"})
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(values["conjur:variable:db/password"]))
+
+		_, _, err = cache.RetrieveBatchSecretsPartial([]string{"db/password", "db/username"})
+		assert.ErrorIs(t, err, errConnectionRefused)
+	})
+
+	t.Run("Retrieves values one by one from other readers", func(t *testing.T) {
+		conjur := &fakeConjur{values: map[string]string{"conjur:variable:db/password": "first"}}
+		cache, err := New(struct{ conjurapi.SecretsReader }{conjur.reader()}, Options{Account: "conjur"})
+		require.NoError(t, err)
+		defer cache.Close()
+
+		values, variableErrors, err := cache.RetrieveBatchSecretsPartial([]string{"db/password", "missing"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"conjur:variable:db/password": []byte("first")}, values)
+		assert.Equal(t, map[string]int{"conjur:variable:missing": 404}, errorCodes(variableErrors))
+		assert.Empty(t, conjur.batches)
+		assert.Equal(t, 2, conjur.count())
+	})
+}
+
+func TestCache_Eviction(t *testing.T) {
+	t.Run("Invalidates values", func(t *testing.T) {
+		cache, conjur, _ := newTestCache(t, Options{})
+
+		_, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		cached := cache.lookup("conjur:variable:db/password").value
+
+		cache.Invalidate("db/password")
+		assert.Equal(t, 0, cache.Len())
+		assert.Equal(t, []byte{0, 0, 0, 0, 0}, cached)
+
+		_, err = cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, 2, conjur.count())
+	})
+
+	t.Run("Evicts the least recently used values", func(t *testing.T) {
+		cache, conjur, _ := newTestCache(t, Options{MaxEntries: 2})
+		for i := 0; i < 3; i++ {
+			conjur.set(fmt.Sprintf("var%d", i), "value")
+		}
+
+		_, err := cache.RetrieveSecret("var0")
+		require.NoError(t, err)
+		cached := cache.lookup("conjur:variable:var0").value
+		_, err = cache.RetrieveSecret("var1")
+		require.NoError(t, err)
+		_, err = cache.RetrieveSecret("var0")
+		require.NoError(t, err)
+		_, err = cache.RetrieveSecret("var2")
+		require.NoError(t, err)
+
+		assert.Equal(t, 2, cache.Len())
+		assert.NotNil(t, cache.lookup("conjur:variable:var0"))
+		assert.Nil(t, cache.lookup("conjur:variable:var1"))
+		assert.Equal(t, "value", string(cached))
+	})
+
+	t.Run("Bounds the size of the values", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, Options{MaxBytes: 6})
+
+		_, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		_, err = cache.RetrieveSecret("db/username")
+		require.NoError(t, err)
+		assert.Equal(t, 1, cache.Len())
+		assert.Nil(t, cache.lookup("conjur:variable:db/password"))
+	})
+
+	t.Run("Wipes values when closed", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, Options{})
+
+		_, err := cache.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		cached := cache.lookup("conjur:variable:db/password").value
+
+		require.NoError(t, cache.Close())
+		assert.Equal(t, 0, cache.Len())
+		assert.Equal(t, []byte{0, 0, 0, 0, 0}, cached)
+	})
+}
+
+func Test_unreachable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tc := range []struct {
+		description string
+		ctx         context.Context
+		err         error
+		expected    bool
+	}{
+		{"Connection refused", context.Background(), errConnectionRefused, true},
+		{"DNS failure", context.Background(), &url.Error{Op: "Get", URL: "https://conjur", Err: &net.DNSError{Err: "no such host", Name: "conjur"}}, true},
+		{"Timeout", context.Background(), &url.Error{Op: "Get", URL: "https://conjur", Err: context.DeadlineExceeded}, true},
+		{"Server error", context.Background(), &response.ConjurError{Code: 502, Message: "Bad Gateway"}, true},
+		{"Client error", context.Background(), &response.ConjurError{Code: 404, Message: "Not Found"}, false},
+		{"TLS failure", context.Background(), &url.Error{Op: "Get", URL: "https://conjur", Err: x509.UnknownAuthorityError{}}, false},
+		{"Parse error", context.Background(), errors.New("invalid character '<' looking for beginning of value"), false},
+		{"Canceled", canceled, &url.Error{Op: "Get", URL: "https://conjur", Err: context.Canceled}, false},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, unreachable(tc.ctx, tc.err))
+		})
+	}
+}
+
+func TestCache_key(t *testing.T) {
+	cache, _, _ := newTestCache(t, Options{})
+
+	for _, tc := range []struct {
+		variableID string
+		expected   string
+	}{
+		{"db/password", "conjur:variable:db/password"},
+		{"variable:db/password", "conjur:variable:db/password"},
+		{"conjur:variable:db/password", "conjur:variable:db/password"},
+		{"foo:bar", "conjur:variable:foo:bar"},
+		{"foo:bar:baz", "conjur:variable:foo:bar:baz"},
+		{"variable:foo:bar", "conjur:variable:variable:foo:bar"},
+		{"other:variable:db/password", "conjur:variable:other:variable:db/password"},
+	} {
+		t.Run(tc.variableID, func(t *testing.T) {
+			assert.Equal(t, tc.expected, cache.key(tc.variableID))
+		})
+	}
+
+	t.Run("Serves identifiers containing ':' from the cache", func(t *testing.T) {
+		cache, conjur, _ := newTestCache(t, Options{})
+		conjur.set("foo:bar:baz", "value")
+
+		_, err := cache.RetrieveSecret("foo:bar:baz")
+		require.NoError(t, err)
+		values, err := cache.RetrieveBatchSecrets([]string{"foo:bar:baz"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"conjur:variable:foo:bar:baz": []byte("value")}, values)
+		assert.Equal(t, 1, conjur.count())
+	})
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(&conjurfake.SecretsReader{}, Options{})
+	assert.EqualError(t, err, "Must specify the account of the cached variables")
+
+	client, err := conjurapi.NewClientFromToken(conjurapi.Config{Account: "myorg", ApplianceURL: "https://conjur"}, "token")
+	require.NoError(t, err)
+	cache, err := New(client, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "myorg:variable:db/password", cache.key("db/password"))
+}
@@ -0,0 +1,151 @@
+package secretsyml
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cyberark/conjur-api-go/conjurapi"
+)
+
+// Environment is a set of resolved entries.
+type Environment struct {
+	// Variables are the environment variables, as "NAME=value" strings.
+	Variables []string
+	// Dir is the temporary directory of the :file values, or empty if there
+	// are none.
+	Dir string
+}
+
+// Resolve fetches the values of the variables of entries in a single batch
+// request, with RetrieveBatchSecretsSafe so that binary values are
+// supported, and writes the :file values to temporary files. Call Cleanup to
+// remove them.
+func Resolve(ctx context.Context, secrets conjurapi.SecretsReader, entries []Entry) (_ *Environment, err error) {
+	values, err := retrieveVariables(ctx, secrets, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Environment{Variables: []string{}}
+	defer func() {
+		if err != nil {
+			env.Cleanup()
+		}
+	}()
+	for _, entry := range entries {
+		value := []byte(entry.Value)
+		if entry.Variable {
+			value = values[entry.Value]
+		}
+		if entry.File {
+			path, err := env.writeFile(entry.Name, value)
+			if err != nil {
+				return nil, err
+			}
+			value = []byte(path)
+		}
+		env.Variables = append(env.Variables, entry.Name+"="+string(value))
+	}
+	return env, nil
+}
+
+// retrieveVariables returns the values of the variables of entries, keyed by
+// their IDs as written in the entries.
+func retrieveVariables(ctx context.Context, secrets conjurapi.SecretsReader, entries []Entry) (map[string][]byte, error) {
+	variableIDs := []string{}
+	for _, entry := range entries {
+		if entry.Variable {
+			variableIDs = append(variableIDs, entry.Value)
+		}
+	}
+	if len(variableIDs) == 0 {
+		return nil, nil
+	}
+
+	batch, err := secrets.RetrieveBatchSecretsSafeCtx(ctx, variableIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The values are keyed by fully-qualified ID, so they are also indexed
+	// without the account, which the entries usually omit.
+	byID := map[string][]byte{}
+	for id, value := range batch {
+		byID[id] = value
+		if _, unqualified, ok := strings.Cut(id, ":"); ok {
+			byID[unqualified] = value
+		}
+	}
+
+	values := map[string][]byte{}
+	for _, id := range variableIDs {
+		value, ok := byID[id]
+		if !ok {
+			value, ok = byID["variable:"+id]
+		}
+		if !ok {
+			return nil, fmt.Errorf("No value returned for variable '%s'", id)
+		}
+		values[id] = value
+	}
+	return values, nil
+}
+
+func (e *Environment) writeFile(name string, value []byte) (string, error) {
+	if e.Dir == "" {
+		dir, err := os.MkdirTemp(tempDir(), "secretsyml")
+		if err != nil {
+			return "", err
+		}
+		e.Dir = dir
+	}
+
+	file, err := os.CreateTemp(e.Dir, name+"-")
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(value)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return file.Name(), err
+}
+
+// tempDir returns the directory of the temporary files: /dev/shm where it
+// exists, so that secrets aren't written to disk, or else the default one.
+func tempDir() string {
+	if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+		return "/dev/shm"
+	}
+	return os.TempDir()
+}
+
+// Cleanup removes the temporary files of the environment.
+func (e *Environment) Cleanup() error {
+	if e.Dir == "" {
+		return nil
+	}
+	err := os.RemoveAll(e.Dir)
+	e.Dir = ""
+	return err
+}
+
+// Exec resolves entries and runs cmd with them added to its environment, which
+// is the current process' if cmd.Env is nil. The temporary files are removed
+// when cmd exits. Use exec.CommandContext to stop cmd when a context is done.
+func Exec(ctx context.Context, secrets conjurapi.SecretsReader, entries []Entry, cmd *exec.Cmd) error {
+	env, err := Resolve(ctx, secrets, entries)
+	if err != nil {
+		return err
+	}
+	defer env.Cleanup()
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, env.Variables...)
+	return cmd.Run()
+}
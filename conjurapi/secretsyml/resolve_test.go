@@ -0,0 +1,125 @@
+package secretsyml
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurfake"
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecretsReader(batches *[][]string) *conjurfake.SecretsReader {
+	values := map[string][]byte{
+		"conjur:variable:db/password":    []byte("secret"),
+		"conjur:variable:db/certificate": []byte("-----BEGIN CERTIFICATE-----\n\xff"),
+	}
+	return &conjurfake.SecretsReader{
+		RetrieveBatchSecretsSafeFunc: func(ctx context.Context, variableIDs []string) (map[string][]byte, error) {
+			*batches = append(*batches, variableIDs)
+			batch := map[string][]byte{}
+			for _, id := range variableIDs {
+				fullID := id
+				switch strings.Count(id, ":") {
+				case 0:
+					fullID = "conjur:variable:" + id
+				case 1:
+					fullID = "conjur:" + id
+				}
+				value, ok := values[fullID]
+				if !ok {
+					return nil, &response.ConjurError{Code: 404, Message: "Variable '" + id + "' not found"}
+				}
+				batch[fullID] = value
+			}
+			return batch, nil
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Run("Resolves entries in a single batch", func(t *testing.T) {
+		var batches [][]string
+		env, err := Resolve(context.Background(), newSecretsReader(&batches), []Entry{
+			{Name: "DB_USER", Value: "app"},
+			{Name: "DB_PASS", Value: "db/password", Variable: true},
+			{Name: "DB_PASS_QUALIFIED", Value: "conjur:variable:db/password", Variable: true},
+			{Name: "SSL_CERT", Value: "variable:db/certificate", Variable: true, File: true},
+			{Name: "CONFIG", Value: "verbose: true", File: true},
+		})
+		require.NoError(t, err)
+		defer env.Cleanup()
+
+		assert.Equal(t, [][]string{{"db/password", "conjur:variable:db/password", "variable:db/certificate"}}, batches)
+		require.Len(t, env.Variables, 5)
+		assert.Equal(t, []string{"DB_USER=app", "DB_PASS=secret", "DB_PASS_QUALIFIED=secret"}, env.Variables[:3])
+
+		certPath := strings.TrimPrefix(env.Variables[3], "SSL_CERT=")
+		cert, err := os.ReadFile(certPath)
+		require.NoError(t, err)
+		assert.Equal(t, "-----BEGIN CERTIFICATE-----\n\xff", string(cert))
+		info, err := os.Stat(certPath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		config, err := os.ReadFile(strings.TrimPrefix(env.Variables[4], "CONFIG="))
+		require.NoError(t, err)
+		assert.Equal(t, "verbose: true", string(config))
+
+		require.NoError(t, env.Cleanup())
+		_, err = os.Stat(certPath)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Doesn't send a request without variables", func(t *testing.T) {
+		var batches [][]string
+		env, err := Resolve(context.Background(), newSecretsReader(&batches), []Entry{{Name: "DB_USER", Value: "app"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"DB_USER=app"}, env.Variables)
+		assert.Empty(t, env.Dir)
+		assert.Empty(t, batches)
+	})
+
+	t.Run("Fails when a variable can't be retrieved", func(t *testing.T) {
+		var batches [][]string
+		_, err := Resolve(context.Background(), newSecretsReader(&batches), []Entry{
+			{Name: "DB_PASS", Value: "db/missing", Variable: true},
+		})
+		assert.ErrorContains(t, err, "Variable 'db/missing' not found")
+	})
+}
+
+func TestExec(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	var batches [][]string
+	cmd := exec.Command("sh", "-c", `echo "$DB_USER:$DB_PASS:$(cat "$CONFIG")"; echo "$CONFIG"`)
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	var output strings.Builder
+	cmd.Stdout = &output
+
+	err := Exec(context.Background(), newSecretsReader(&batches), []Entry{
+		{Name: "DB_USER", Value: "app"},
+		{Name: "DB_PASS", Value: "db/password", Variable: true},
+		{Name: "CONFIG", Value: "verbose", File: true},
+	}, cmd)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "app:secret:verbose", lines[0])
+	_, err = os.Stat(lines[1])
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	cmd = exec.Command("sh", "-c", "exit 3")
+	err = Exec(context.Background(), newSecretsReader(&batches), nil, cmd)
+	var exitErr *exec.ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 3, exitErr.ExitCode())
+}
@@ -0,0 +1,173 @@
+// Package secretsyml reads summon-style secrets.yml files, which map
+// environment variables to Conjur variables or literal values, and resolves
+// them with a conjurapi.SecretsReader:
+//
+//	DB_USER: app
+//	DB_PASS: !var prod/db/password
+//	SSL_CERT: !var:file prod/db/certificate
+//
+// Resolving the entries fetches all the variables in a single batch request.
+// Values tagged with :file are written to temporary files, and the variables
+// are set to their paths:
+//
+//	entries, err := secretsyml.ParseFile("secrets.yml", secretsyml.ParseOptions{})
+//	...
+//	err = secretsyml.Exec(ctx, client, entries, exec.Command("./server"))
+package secretsyml
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CommonSection is the section of a file with environments whose entries
+// apply to all of them.
+const CommonSection = "common"
+
+// Entry is an environment variable defined in a secrets.yml file.
+type Entry struct {
+	// Name is the name of the environment variable.
+	Name string
+	// Value is the ID of a Conjur variable if Variable is set, or else the
+	// literal value.
+	Value string
+	// Variable is set for values tagged with !var.
+	Variable bool
+	// File is set for values tagged with :file, which are written to a
+	// temporary file whose path is the value of the environment variable.
+	File bool
+}
+
+// ParseOptions configures Parse.
+type ParseOptions struct {
+	// Environment selects the section of the file to read, merged over the
+	// common section. When empty, the file has no sections.
+	Environment string
+	// Defines are substituted for $name and ${name} in the values. Undefined
+	// names are left as $name.
+	Defines map[string]string
+}
+
+// ParseFile parses the secrets.yml file at path.
+func ParseFile(path string, options ParseOptions) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, options)
+}
+
+// Parse parses the contents of a secrets.yml file. The entries are returned in
+// the order of the file, the common ones first.
+func Parse(data []byte, options ParseOptions) ([]Entry, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return []Entry{}, nil
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("secrets.yml must be a mapping of names to values")
+	}
+
+	if options.Environment == "" {
+		return parseSection(root, options.Defines)
+	}
+
+	environment := sectionNode(root, options.Environment)
+	if environment == nil {
+		return nil, fmt.Errorf("Environment '%s' not found in secrets.yml", options.Environment)
+	}
+	entries := []Entry{}
+	for _, node := range []*yaml.Node{sectionNode(root, CommonSection), environment} {
+		if node == nil {
+			continue
+		}
+		if node.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("Section at line %d must be a mapping of names to values", node.Line)
+		}
+		section, err := parseSection(node, options.Defines)
+		if err != nil {
+			return nil, err
+		}
+		entries = merge(entries, section)
+	}
+	return entries, nil
+}
+
+func sectionNode(root *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == name {
+			return root.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// merge adds entries to base, replacing those with the same name.
+func merge(base []Entry, entries []Entry) []Entry {
+	for _, entry := range entries {
+		replaced := false
+		for i := range base {
+			if base[i].Name == entry.Name {
+				base[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			base = append(base, entry)
+		}
+	}
+	return base
+}
+
+func parseSection(node *yaml.Node, defines map[string]string) ([]Entry, error) {
+	entries := []Entry{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i].Value, node.Content[i+1]
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("Value of %s at line %d must be a string", name, value.Line)
+		}
+
+		entry := Entry{Name: name, Value: interpolate(value.Value, defines)}
+		switch value.Tag {
+		case "!var":
+			entry.Variable = true
+		case "!var:file":
+			entry.Variable = true
+			entry.File = true
+		case "!str:file", "!file":
+			entry.File = true
+		case "!str":
+		default:
+			if strings.HasPrefix(value.Tag, "!") && !strings.HasPrefix(value.Tag, "!!") {
+				return nil, fmt.Errorf("Unknown tag %s of %s at line %d", value.Tag, name, value.Line)
+			}
+		}
+		if entry.Variable && entry.Value == "" {
+			return nil, fmt.Errorf("Variable of %s at line %d must not be empty", name, value.Line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// interpolate substitutes defines for $name and ${name} in value.
+func interpolate(value string, defines map[string]string) string {
+	if len(defines) == 0 {
+		return value
+	}
+	return os.Expand(value, func(name string) string {
+		if define, ok := defines[name]; ok {
+			return define
+		}
+		return "$" + name
+	})
+}
@@ -0,0 +1,92 @@
+package secretsyml
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Parses literals and variables", func(t *testing.T) {
+		entries, err := Parse([]byte(`
+DB_USER: app
+DB_PORT: 5432
+DB_PASS: !var prod/db/password
+SSL_CERT: !var:file prod/db/certificate
+GREETING: !str hello
+CONFIG: !str:file |
+  verbose: true
+`), ParseOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []Entry{
+			{Name: "DB_USER", Value: "app"},
+			{Name: "DB_PORT", Value: "5432"},
+			{Name: "DB_PASS", Value: "prod/db/password", Variable: true},
+			{Name: "SSL_CERT", Value: "prod/db/certificate", Variable: true, File: true},
+			{Name: "GREETING", Value: "hello"},
+			{Name: "CONFIG", Value: "verbose: true\n", File: true},
+		}, entries)
+	})
+
+	t.Run("Merges the environment over the common section", func(t *testing.T) {
+		data := []byte(`
+common:
+  LOG_LEVEL: info
+  DB_USER: app
+production:
+  DB_PASS: !var $env/db/password
+  LOG_LEVEL: warn
+staging:
+  DB_PASS: !var staging/db/password
+`)
+		entries, err := Parse(data, ParseOptions{Environment: "production", Defines: map[string]string{"env": "prod"}})
+		require.NoError(t, err)
+		assert.Equal(t, []Entry{
+			{Name: "LOG_LEVEL", Value: "warn"},
+			{Name: "DB_USER", Value: "app"},
+			{Name: "DB_PASS", Value: "prod/db/password", Variable: true},
+		}, entries)
+
+		_, err = Parse(data, ParseOptions{Environment: "development"})
+		assert.EqualError(t, err, "Environment 'development' not found in secrets.yml")
+	})
+
+	t.Run("Interpolates defines", func(t *testing.T) {
+		entries, err := Parse([]byte(`
+DB_PASS: !var ${env}/db/password
+PRICE: $cost
+`), ParseOptions{Defines: map[string]string{"env": "prod"}})
+		require.NoError(t, err)
+		assert.Equal(t, "prod/db/password", entries[0].Value)
+		assert.Equal(t, "$cost", entries[1].Value)
+	})
+
+	t.Run("Rejects invalid files", func(t *testing.T) {
+		_, err := Parse([]byte(`- DB_PASS`), ParseOptions{})
+		assert.EqualError(t, err, "secrets.yml must be a mapping of names to values")
+
+		_, err = Parse([]byte(`DB_PASS: !secret prod/db/password`), ParseOptions{})
+		assert.EqualError(t, err, "Unknown tag !secret of DB_PASS at line 1")
+
+		_, err = Parse([]byte(`DB_PASS: [a, b]`), ParseOptions{})
+		assert.EqualError(t, err, "Value of DB_PASS at line 1 must be a string")
+
+		_, err = Parse([]byte(`DB_PASS: !var ""`), ParseOptions{})
+		assert.EqualError(t, err, "Variable of DB_PASS at line 1 must not be empty")
+	})
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yml")
+	require.NoError(t, os.WriteFile(path, []byte("DB_PASS: !var db/password\n"), 0600))
+
+	entries, err := ParseFile(path, ParseOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{{Name: "DB_PASS", Value: "db/password", Variable: true}}, entries)
+
+	_, err = ParseFile(filepath.Join(t.TempDir(), "missing.yml"), ParseOptions{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,357 @@
+package conjurapi
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+)
+
+// newCertPool returns the pool of CA certificates trusted to verify the Conjur
+// server: the Conjur cert, the extra certs from SSLExtraCertPaths and, with
+// SSLUseSystemCertPool, the system roots. It returns nil, meaning the system
+// roots, when none of them is configured.
+func newCertPool(cert []byte, config Config) (*x509.CertPool, error) {
+	if len(cert) == 0 && len(config.SSLExtraCertPaths) == 0 && !config.SSLUseSystemCertPool {
+		return nil, nil
+	}
+
+	pool := x509.NewCertPool()
+	if config.SSLUseSystemCertPool {
+		systemPool, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("Can't load system cert pool: %s", err)
+		}
+		pool = systemPool
+	}
+
+	if len(cert) > 0 && !pool.AppendCertsFromPEM(cert) {
+		return nil, fmt.Errorf("Can't append Conjur SSL cert")
+	}
+
+	for _, path := range config.SSLExtraCertPaths {
+		extraCert, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Can't read extra SSL cert: %s", err)
+		}
+		if !pool.AppendCertsFromPEM(extraCert) {
+			return nil, fmt.Errorf("Can't append extra SSL cert from %s", path)
+		}
+	}
+
+	return pool, nil
+}
+
+// newTLSConfig returns the TLS configuration used to connect to Conjur. It
+// trusts the certificates in pool, or the system roots when pool is nil, and
+// presents the client certificate from config, if any.
+func newTLSConfig(pool *x509.CertPool, config Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{RootCAs: pool}
+
+	if len(config.PinnedFingerprints) > 0 {
+		pins, err := newFingerprintPins(config.PinnedFingerprints)
+		if err != nil {
+			return nil, err
+		}
+		if config.PinnedFingerprintsOnly {
+			// The pins replace CA verification entirely, so that servers with
+			// self-signed certificates can be trusted without a CA bundle
+			tlsConfig.InsecureSkipVerify = true
+			tlsConfig.VerifyPeerCertificate = pins.verifyLeaf
+		} else {
+			tlsConfig.VerifyPeerCertificate = pins.verifyChains
+		}
+	}
+
+	if config.HasClientCert() {
+		clientCert, err := newClientCertificate(config)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = clientCert.GetClientCertificate
+	}
+
+	return tlsConfig, nil
+}
+
+// SPKIFingerprint returns the SHA-256 fingerprint of the public key of cert,
+// in the format used by Config.PinnedFingerprints.
+func SPKIFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// parseFingerprint decodes a hex-encoded SHA-256 fingerprint. Colons and a
+// "sha256:" prefix are allowed, e.g. "sha256:AB:CD:..." or "abcd...".
+func parseFingerprint(fingerprint string) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+
+	normalized := strings.ToLower(strings.TrimSpace(fingerprint))
+	normalized = strings.TrimPrefix(normalized, "sha256:")
+	normalized = strings.ReplaceAll(normalized, ":", "")
+
+	decoded, err := hex.DecodeString(normalized)
+	if err != nil || len(decoded) != sha256.Size {
+		return sum, fmt.Errorf("Invalid pinned fingerprint %q: must be a hex-encoded SHA-256 hash", fingerprint)
+	}
+	copy(sum[:], decoded)
+	return sum, nil
+}
+
+// fingerprintPins holds the SPKI fingerprints which the server certificates
+// are pinned to.
+type fingerprintPins map[[sha256.Size]byte]bool
+
+func newFingerprintPins(fingerprints []string) (fingerprintPins, error) {
+	pins := fingerprintPins{}
+	for _, fingerprint := range fingerprints {
+		sum, err := parseFingerprint(fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		pins[sum] = true
+	}
+	return pins, nil
+}
+
+func (p fingerprintPins) matches(cert *x509.Certificate) bool {
+	return p[sha256.Sum256(cert.RawSubjectPublicKeyInfo)]
+}
+
+// verifyChains implements tls.Config.VerifyPeerCertificate after the chain
+// has been verified against the trusted CAs. It succeeds when any certificate
+// of a verified chain, whether leaf, intermediate or root, is pinned.
+func (p fingerprintPins) verifyChains(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	for _, chain := range verifiedChains {
+		for _, cert := range chain {
+			if p.matches(cert) {
+				return nil
+			}
+		}
+	}
+
+	if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+		return errors.New("Conjur server certificate doesn't match any pinned fingerprint: no verified certificate chain")
+	}
+	return pinMismatchError(verifiedChains[0][0])
+}
+
+// verifyLeaf implements tls.Config.VerifyPeerCertificate when CA verification
+// is disabled. It succeeds only when the server's own certificate is pinned
+// and currently valid.
+func (p fingerprintPins) verifyLeaf(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("Conjur server didn't present a certificate")
+	}
+
+	leaf, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return fmt.Errorf("Can't parse Conjur server certificate: %s", err)
+	}
+	if !p.matches(leaf) {
+		return pinMismatchError(leaf)
+	}
+
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("Conjur server certificate %q is not valid at %s", leaf.Subject, now.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func pinMismatchError(leaf *x509.Certificate) error {
+	return fmt.Errorf(
+		"Conjur server certificate %q doesn't match any pinned fingerprint: observed %s",
+		leaf.Subject,
+		SPKIFingerprint(leaf),
+	)
+}
+
+// clientCertificate holds the client certificate presented for mutual TLS.
+// When the certificate or key is read from a file, it is reloaded on the next
+// TLS handshake after the file changes, so that rotated certificates are
+// picked up without restarting the client.
+type clientCertificate struct {
+	config  Config
+	mutex   sync.Mutex
+	cert    *tls.Certificate
+	modTime [2]time.Time
+}
+
+func newClientCertificate(config Config) (*clientCertificate, error) {
+	c := &clientCertificate{config: config}
+	modTime := c.filesModTime()
+
+	cert, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.cert = cert
+	c.modTime = modTime
+	return c, nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+func (c *clientCertificate) GetClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	modTime := c.filesModTime()
+	if modTime == c.modTime {
+		return c.cert, nil
+	}
+
+	cert, err := c.load()
+	if err != nil {
+		// The files may be in the middle of being rotated, so keep using the
+		// current certificate and try again on the next handshake
+		logging.FromContext(info.Context()).Error("Failed to reload client certificate, using the previous one", "error", err)
+		return c.cert, nil
+	}
+
+	logging.FromContext(info.Context()).Debug("Reloaded client certificate")
+	c.cert = cert
+	c.modTime = modTime
+	return c.cert, nil
+}
+
+func (c *clientCertificate) load() (*tls.Certificate, error) {
+	certPEM, keyPEM, err := c.config.ReadClientCert()
+	if err != nil {
+		return nil, fmt.Errorf("Can't read client certificate: %s", err)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("Can't load client certificate: %s", err)
+	}
+	return &cert, nil
+}
+
+// filesModTime returns the modification times of the certificate and key
+// files. Content set directly in the config never changes.
+func (c *clientCertificate) filesModTime() [2]time.Time {
+	var modTime [2]time.Time
+	for i, path := range []string{c.config.ClientCertPath, c.config.ClientKeyPath} {
+		if path == "" {
+			continue
+		}
+		if fi, err := os.Stat(path); err == nil {
+			modTime[i] = fi.ModTime()
+		}
+	}
+	return modTime
+}
+
+// sslCertReloadingTransport sends requests through an http.Transport which
+// trusts the CA certificates in the file at SSLCertPath. The file is checked
+// for changes at most once per interval, and when it has changed the transport
+// is replaced by one trusting the new certificates, so that a rotated CA is
+// picked up without recreating the Client.
+type sslCertReloadingTransport struct {
+	config    Config
+	certPath  string
+	interval  time.Duration
+	mutex     sync.Mutex
+	transport *http.Transport
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func newSSLCertReloadingTransport(transport *http.Transport, config Config) *sslCertReloadingTransport {
+	t := &sslCertReloadingTransport{
+		config:    config,
+		certPath:  config.SSLCertPath,
+		interval:  config.GetSSLCertReloadInterval(),
+		transport: transport,
+		lastCheck: time.Now(),
+	}
+	if fi, err := os.Stat(t.certPath); err == nil {
+		t.modTime = fi.ModTime()
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *sslCertReloadingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.current(logging.FromContext(req.Context())).RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *sslCertReloadingTransport) CloseIdleConnections() {
+	t.current(logging.Default()).CloseIdleConnections()
+}
+
+// current returns the transport, after reloading the certificates if the file
+// has changed. Reloads are logged with logger.
+func (t *sslCertReloadingTransport) current(logger *slog.Logger) *http.Transport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.interval <= 0 || time.Since(t.lastCheck) < t.interval {
+		return t.transport
+	}
+	t.lastCheck = time.Now()
+
+	fi, err := os.Stat(t.certPath)
+	if err != nil {
+		logger.Error("Can't check Conjur SSL cert for changes", "path", t.certPath, "error", err)
+		return t.transport
+	}
+	if fi.ModTime().Equal(t.modTime) {
+		return t.transport
+	}
+
+	if err := t.reload(logger); err != nil {
+		// Don't retry until the file changes again
+		t.modTime = fi.ModTime()
+		logger.Error("Can't reload Conjur SSL cert, keeping the previous one", "error", err)
+	}
+	return t.transport
+}
+
+// Reload replaces the transport with one trusting the certificates currently
+// in the file, and logs the reload with logger.
+func (t *sslCertReloadingTransport) Reload(logger *slog.Logger) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.reload(logger)
+}
+
+func (t *sslCertReloadingTransport) reload(logger *slog.Logger) error {
+	var modTime time.Time
+	if fi, err := os.Stat(t.certPath); err == nil {
+		modTime = fi.ModTime()
+	}
+
+	cert, err := os.ReadFile(t.certPath)
+	if err != nil {
+		return fmt.Errorf("Can't reload Conjur SSL cert: %s", err)
+	}
+	pool, err := newCertPool(cert, t.config)
+	if err != nil {
+		return fmt.Errorf("Can't reload Conjur SSL cert from %s: %s", t.certPath, err)
+	}
+
+	transport := t.transport.Clone()
+	transport.TLSClientConfig.RootCAs = pool
+
+	// Connections established with the previous transport stay open until
+	// they become idle
+	t.transport.CloseIdleConnections()
+	t.transport = transport
+	t.modTime = modTime
+	logger.Info("Reloaded Conjur SSL cert", "path", t.certPath)
+	return nil
+}
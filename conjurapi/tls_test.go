@@ -0,0 +1,437 @@
+package conjurapi
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"log"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCertificate returns a PEM-encoded self-signed certificate and key.
+func newTestCertificate(t *testing.T, commonName string) (certPEM string, keyPEM string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return
+}
+
+// newMutualTLSServer returns a server which requires a client certificate and
+// responds with its common name, along with the PEM-encoded server certificate.
+func newMutualTLSServer() (*httptest.Server, string) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+
+	serverCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	return server, string(serverCert)
+}
+
+func writeFileWithModTime(t *testing.T, name string, content string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(name, []byte(content), 0600))
+	require.NoError(t, os.Chtimes(name, modTime, modTime))
+}
+
+func TestClient_MutualTLS(t *testing.T) {
+	server, serverCert := newMutualTLSServer()
+	defer server.Close()
+
+	newClient := func(t *testing.T, config Config) *Client {
+		config.Account = "conjur"
+		config.ApplianceURL = server.URL
+		config.SSLCert = serverCert
+		client, err := NewClientFromToken(config, sample_token)
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("Presents the client certificate", func(t *testing.T) {
+		cert, key := newTestCertificate(t, "alice")
+		client := newClient(t, Config{ClientCert: cert, ClientKey: key})
+
+		commonName, err := client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", string(commonName))
+	})
+
+	t.Run("Fails without a client certificate", func(t *testing.T) {
+		client := newClient(t, Config{})
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.Error(t, err)
+	})
+
+	t.Run("Returns error for an invalid client certificate", func(t *testing.T) {
+		_, key := newTestCertificate(t, "alice")
+		_, err := NewClientFromToken(Config{
+			Account:      "conjur",
+			ApplianceURL: server.URL,
+			SSLCert:      serverCert,
+			ClientCert:   "invalid",
+			ClientKey:    key,
+		}, sample_token)
+		assert.ErrorContains(t, err, "Can't load client certificate")
+	})
+
+	t.Run("Reloads the client certificate when the files change", func(t *testing.T) {
+		dir := t.TempDir()
+		certPath := filepath.Join(dir, "client.pem")
+		keyPath := filepath.Join(dir, "client-key.pem")
+		modTime := time.Now().Add(-time.Minute)
+
+		cert, key := newTestCertificate(t, "alice")
+		writeFileWithModTime(t, certPath, cert, modTime)
+		writeFileWithModTime(t, keyPath, key, modTime)
+		client := newClient(t, Config{ClientCertPath: certPath, ClientKeyPath: keyPath})
+
+		commonName, err := client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", string(commonName))
+
+		// A broken key is ignored and the previous certificate kept
+		modTime = modTime.Add(time.Second)
+		writeFileWithModTime(t, keyPath, "invalid", modTime)
+		client.httpClient.CloseIdleConnections()
+
+		commonName, err = client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", string(commonName))
+
+		// Rotated files are picked up on the next handshake
+		modTime = modTime.Add(time.Second)
+		cert, key = newTestCertificate(t, "bob")
+		writeFileWithModTime(t, certPath, cert, modTime)
+		writeFileWithModTime(t, keyPath, key, modTime)
+		client.httpClient.CloseIdleConnections()
+
+		commonName, err = client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", string(commonName))
+	})
+
+	t.Run("Presents the client certificate without a custom CA", func(t *testing.T) {
+		cert, key := newTestCertificate(t, "alice")
+		config := Config{
+			Account:      "conjur",
+			ApplianceURL: "https://conjur",
+			ClientCert:   cert,
+			ClientKey:    key,
+		}
+		httpClient, err := createHttpClient(config)
+		require.NoError(t, err)
+
+		tlsConfig := httpClient.Transport.(*http.Transport).TLSClientConfig
+		require.NotNil(t, tlsConfig)
+		assert.Nil(t, tlsConfig.RootCAs)
+		clientCert, err := tlsConfig.GetClientCertificate(nil)
+		assert.NoError(t, err)
+		assert.NotNil(t, clientCert)
+	})
+}
+
+func TestClient_ReloadSSLCert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+	serverCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	otherCert, _ := newTestCertificate(t, "other-ca")
+
+	newClient := func(t *testing.T, certPath string) *Client {
+		client, err := NewClientFromToken(Config{
+			Account:      "conjur",
+			ApplianceURL: server.URL,
+			SSLCertPath:  certPath,
+		}, sample_token)
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("Reloads the CA certificate on demand", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "conjur.pem")
+		writeFileWithModTime(t, certPath, otherCert, time.Now().Add(-time.Minute))
+		client := newClient(t, certPath)
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "certificate signed by unknown authority")
+
+		writeFileWithModTime(t, certPath, serverCert, time.Now())
+		assert.NoError(t, client.ReloadSSLCert())
+
+		secret, err := client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+	})
+
+	t.Run("Reloads the CA certificate when the file changes", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "conjur.pem")
+		writeFileWithModTime(t, certPath, otherCert, time.Now().Add(-time.Minute))
+		client := newClient(t, certPath)
+		client.httpClient.Transport.(*sslCertReloadingTransport).interval = time.Millisecond
+
+		_, err := client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "certificate signed by unknown authority")
+
+		writeFileWithModTime(t, certPath, serverCert, time.Now())
+		time.Sleep(2 * time.Millisecond)
+
+		var logs bytes.Buffer
+		client.SetLogger(logging.NewLogger(&logs, slog.LevelInfo))
+		secret, err := client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+		assert.Contains(t, logs.String(), `"msg":"Reloaded Conjur SSL cert"`)
+	})
+
+	t.Run("Keeps the previous CA certificate when the new one doesn't parse", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "conjur.pem")
+		writeFileWithModTime(t, certPath, serverCert, time.Now().Add(-time.Minute))
+		client := newClient(t, certPath)
+		client.httpClient.Transport.(*sslCertReloadingTransport).interval = time.Millisecond
+
+		writeFileWithModTime(t, certPath, "invalid", time.Now())
+		err := client.ReloadSSLCert()
+		assert.EqualError(t, err, "Can't reload Conjur SSL cert from "+certPath+": Can't append Conjur SSL cert")
+
+		time.Sleep(2 * time.Millisecond)
+		client.httpClient.CloseIdleConnections()
+		secret, err := client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+	})
+
+	t.Run("Doesn't check the file when reloading is disabled", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "conjur.pem")
+		writeFileWithModTime(t, certPath, otherCert, time.Now().Add(-time.Minute))
+		client, err := NewClientFromToken(Config{
+			Account:               "conjur",
+			ApplianceURL:          server.URL,
+			SSLCertPath:           certPath,
+			SSLCertReloadInterval: -1,
+		}, sample_token)
+		require.NoError(t, err)
+
+		_, ok := client.GetHttpClient().Transport.(*http.Transport)
+		assert.True(t, ok)
+
+		writeFileWithModTime(t, certPath, serverCert, time.Now())
+		_, err = client.RetrieveSecret("my-var")
+		assert.ErrorContains(t, err, "certificate signed by unknown authority")
+		assert.EqualError(t, client.ReloadSSLCert(), "Conjur SSL cert reloading is disabled")
+	})
+
+	t.Run("Returns error when the CA certificate isn't loaded from a file", func(t *testing.T) {
+		client, err := NewClientFromToken(Config{
+			Account:      "conjur",
+			ApplianceURL: server.URL,
+			SSLCert:      serverCert,
+		}, sample_token)
+		require.NoError(t, err)
+
+		assert.EqualError(t, client.ReloadSSLCert(), "Conjur SSL cert is not loaded from a file")
+	})
+}
+
+func TestClient_PinnedFingerprints(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+	serverCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	fingerprint := SPKIFingerprint(server.Certificate())
+	otherFingerprint := "sha256:" + strings.Repeat("ab", 32)
+
+	retrieveSecret := func(config Config) ([]byte, error) {
+		config.Account = "conjur"
+		config.ApplianceURL = server.URL
+		client, err := NewClientFromToken(config, sample_token)
+		if err != nil {
+			return nil, err
+		}
+		return client.RetrieveSecret("my-var")
+	}
+
+	t.Run("Accepts a pinned certificate", func(t *testing.T) {
+		secret, err := retrieveSecret(Config{SSLCert: serverCert, PinnedFingerprints: []string{otherFingerprint, fingerprint}})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+	})
+
+	t.Run("Accepts fingerprints with colons in upper case", func(t *testing.T) {
+		sum := sha256.Sum256(server.Certificate().RawSubjectPublicKeyInfo)
+		hexSum := strings.ToUpper(hex.EncodeToString(sum[:]))
+		var pairs []string
+		for i := 0; i < len(hexSum); i += 2 {
+			pairs = append(pairs, hexSum[i:i+2])
+		}
+
+		_, err := retrieveSecret(Config{SSLCert: serverCert, PinnedFingerprints: []string{strings.Join(pairs, ":")}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rejects an unpinned certificate and reports its fingerprint", func(t *testing.T) {
+		_, err := retrieveSecret(Config{SSLCert: serverCert, PinnedFingerprints: []string{otherFingerprint}})
+		assert.ErrorContains(t, err, "doesn't match any pinned fingerprint: observed "+fingerprint)
+	})
+
+	t.Run("Still verifies the certificate against the CAs", func(t *testing.T) {
+		_, err := retrieveSecret(Config{PinnedFingerprints: []string{fingerprint}})
+		assert.ErrorContains(t, err, "certificate signed by unknown authority")
+	})
+
+	t.Run("Trusts a pinned certificate without a CA with PinnedFingerprintsOnly", func(t *testing.T) {
+		secret, err := retrieveSecret(Config{PinnedFingerprints: []string{fingerprint}, PinnedFingerprintsOnly: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+	})
+
+	t.Run("Rejects an unpinned certificate with PinnedFingerprintsOnly", func(t *testing.T) {
+		_, err := retrieveSecret(Config{PinnedFingerprints: []string{otherFingerprint}, PinnedFingerprintsOnly: true})
+		assert.ErrorContains(t, err, "doesn't match any pinned fingerprint: observed "+fingerprint)
+	})
+}
+
+func TestParseFingerprint(t *testing.T) {
+	hexSum := strings.Repeat("0a", 32)
+
+	for _, fingerprint := range []string{
+		hexSum,
+		strings.ToUpper(hexSum),
+		"sha256:" + hexSum,
+		"SHA256:" + strings.TrimSuffix(strings.Repeat("0A:", 32), ":"),
+	} {
+		sum, err := parseFingerprint(fingerprint)
+		assert.NoError(t, err, fingerprint)
+		assert.Equal(t, byte(0x0a), sum[0])
+	}
+
+	for _, fingerprint := range []string{"", "not-hex", strings.Repeat("0a", 20), "sha1:" + hexSum} {
+		_, err := parseFingerprint(fingerprint)
+		assert.ErrorContains(t, err, "must be a hex-encoded SHA-256 hash", fingerprint)
+	}
+}
+
+func TestClient_TrustedCerts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer server.Close()
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+
+	serverCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	otherCert, _ := newTestCertificate(t, "other-ca")
+	serverCertPath, err := TempFileForTesting("TestClientTrustedCerts", serverCert, t)
+	require.NoError(t, err)
+
+	retrieveSecret := func(config Config) ([]byte, error) {
+		config.Account = "conjur"
+		if config.ApplianceURL == "" {
+			config.ApplianceURL = server.URL
+		}
+		client, err := NewClientFromToken(config, sample_token)
+		if err != nil {
+			return nil, err
+		}
+		return client.RetrieveSecret("my-var")
+	}
+
+	t.Run("Uses HTTPS with the system roots for an https ApplianceURL", func(t *testing.T) {
+		_, err := retrieveSecret(Config{})
+		assert.ErrorContains(t, err, "certificate signed by unknown authority")
+
+		httpClient, err := createHttpClient(Config{ApplianceURL: "https://conjur"})
+		require.NoError(t, err)
+		assert.Nil(t, httpClient.Transport.(*http.Transport).TLSClientConfig.RootCAs)
+	})
+
+	t.Run("Trusts extra certs", func(t *testing.T) {
+		secret, err := retrieveSecret(Config{SSLExtraCertPaths: []string{serverCertPath}})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+	})
+
+	t.Run("Trusts extra certs along with the Conjur cert and system roots", func(t *testing.T) {
+		secret, err := retrieveSecret(Config{
+			SSLCert:              otherCert,
+			SSLUseSystemCertPool: true,
+			SSLExtraCertPaths:    []string{serverCertPath},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(secret))
+	})
+
+	t.Run("Keeps trusting extra certs when the Conjur cert is reloaded", func(t *testing.T) {
+		certPath := filepath.Join(t.TempDir(), "conjur.pem")
+		writeFileWithModTime(t, certPath, otherCert, time.Now().Add(-time.Minute))
+		client, err := NewClientFromToken(Config{
+			Account:           "conjur",
+			ApplianceURL:      server.URL,
+			SSLCertPath:       certPath,
+			SSLExtraCertPaths: []string{serverCertPath},
+		}, sample_token)
+		require.NoError(t, err)
+
+		otherCert2, _ := newTestCertificate(t, "other-ca-2")
+		writeFileWithModTime(t, certPath, otherCert2, time.Now())
+		require.NoError(t, client.ReloadSSLCert())
+		client.httpClient.CloseIdleConnections()
+
+		_, err = client.RetrieveSecret("my-var")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Returns error for a missing extra cert", func(t *testing.T) {
+		_, err := retrieveSecret(Config{SSLExtraCertPaths: []string{"not-found"}})
+		assert.ErrorContains(t, err, "Can't read extra SSL cert")
+	})
+
+	t.Run("Returns error for an invalid extra cert", func(t *testing.T) {
+		invalidPath, err := TempFileForTesting("TestClientTrustedCertsInvalid", "invalid", t)
+		require.NoError(t, err)
+
+		_, err = retrieveSecret(Config{SSLExtraCertPaths: []string{invalidPath}})
+		assert.EqualError(t, err, "Can't append extra SSL cert from "+invalidPath)
+	})
+}
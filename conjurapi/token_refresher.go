@@ -0,0 +1,113 @@
+package conjurapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+)
+
+const (
+	// tokenRefresherLead is the fraction of the time until a token is due for
+	// refresh after which the background refresher renews it, so that requests
+	// made meanwhile never have to wait for authentication.
+	tokenRefresherLead = 0.9
+	// tokenRefresherMinInterval is the minimum delay between two refreshes, so
+	// that a token which is already due doesn't cause a busy loop.
+	tokenRefresherMinInterval = time.Second
+	// tokenRefresherMaxBackoff caps the delay between retries after failures.
+	tokenRefresherMaxBackoff = 30 * time.Second
+)
+
+// StartTokenRefresher starts a goroutine which refreshes the client's access
+// token ahead of its expiration, based on the token's `iat` and `exp` claims,
+// so that requests don't have to wait for authentication, and so that a brief
+// authentication outage goes unnoticed as long as the current token is valid.
+//
+// Failed refreshes are retried with exponential backoff and reported on the
+// returned channel. Errors are dropped when the channel is full, so callers
+// don't have to read from it. Refreshes which return a token with the same
+// expiration, e.g. from an authenticator which caches tokens, are backed off
+// the same way. The refresher stops, and the channel is closed, when ctx is
+// done, or once the current token has expired and can't be renewed, after
+// which requests authenticate on demand.
+//
+// A client created from a fixed token can't renew it, so the refresher isn't
+// started and the returned channel is already closed.
+func (c *Client) StartTokenRefresher(ctx context.Context) <-chan error {
+	errs := make(chan error, 1)
+	if _, ok := c.authenticator.(*authn.TokenAuthenticator); ok {
+		close(errs)
+		return errs
+	}
+	go c.runTokenRefresher(ctx, errs)
+	return errs
+}
+
+func (c *Client) runTokenRefresher(ctx context.Context, errs chan<- error) {
+	defer close(errs)
+
+	var backoff time.Duration
+	for {
+		token := c.currentToken()
+
+		delay := backoff
+		if delay == 0 {
+			delay = tokenRefreshDelay(token, time.Now())
+		}
+		if err := sleepContext(ctx, delay); err != nil || ctx.Err() != nil {
+			return
+		}
+
+		err := c.refreshToken(ctx, token)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			if refreshed := c.currentToken(); token != nil && refreshed != nil && refreshed.ExpiresAt().Equal(token.ExpiresAt()) {
+				backoff = nextTokenRefresherBackoff(backoff)
+				c.log().Debug("Access token was not renewed", "retry_in", backoff)
+			} else {
+				backoff = 0
+			}
+			continue
+		}
+
+		select {
+		case errs <- err:
+		default:
+		}
+		if token != nil && !time.Now().Before(token.ExpiresAt()) {
+			c.log().Warn("Failed to refresh expired access token, stopping token refresher", "error", err)
+			return
+		}
+		backoff = nextTokenRefresherBackoff(backoff)
+		c.log().Warn("Failed to refresh access token", "retry_in", backoff, "error", err)
+	}
+}
+
+// tokenRefreshDelay returns how long the background refresher waits before
+// renewing token.
+func tokenRefreshDelay(token *authn.AuthnToken, now time.Time) time.Duration {
+	if token == nil {
+		return 0
+	}
+
+	due := token.RefreshAt().Sub(token.IssuedAt())
+	delay := token.IssuedAt().Add(time.Duration(float64(due) * tokenRefresherLead)).Sub(now)
+	if delay < tokenRefresherMinInterval {
+		delay = tokenRefresherMinInterval
+	}
+	return delay
+}
+
+func nextTokenRefresherBackoff(backoff time.Duration) time.Duration {
+	if backoff == 0 {
+		return tokenRefresherMinInterval
+	}
+	backoff *= 2
+	if backoff > tokenRefresherMaxBackoff {
+		backoff = tokenRefresherMaxBackoff
+	}
+	return backoff
+}
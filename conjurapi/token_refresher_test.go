@@ -0,0 +1,168 @@
+package conjurapi
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func waitForClose(t *testing.T, errs <-chan error) {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-errs:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("token refresher did not stop")
+		}
+	}
+}
+
+// cachingAuthenticator returns the same token every time, as an
+// authenticator which caches tokens would.
+type cachingAuthenticator struct {
+	token []byte
+	calls int32
+}
+
+func (a *cachingAuthenticator) RefreshToken() ([]byte, error) {
+	atomic.AddInt32(&a.calls, 1)
+	return a.token, nil
+}
+
+func (a *cachingAuthenticator) NeedsTokenRefresh() bool {
+	return false
+}
+
+func TestClient_StartTokenRefresher(t *testing.T) {
+	t.Run("Refreshes the token ahead of expiration", func(t *testing.T) {
+		server := newTestServer(t, conjurtest.WithTokenTTL(2*time.Second))
+		client := newTestServerClient(t, server)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := client.StartTokenRefresher(ctx)
+
+		// The first token is obtained right away, and renewed before it expires
+		assert.Eventually(t, func() bool { return server.CountRequests("/authn") == 1 }, time.Second, 10*time.Millisecond)
+		first := client.currentToken()
+		assert.Eventually(t, func() bool { return server.CountRequests("/authn") == 2 }, 3*time.Second, 10*time.Millisecond)
+		assert.True(t, time.Now().Before(first.ExpiresAt()))
+		assert.NotEqual(t, first.Raw(), client.currentToken().Raw())
+
+		cancel()
+		waitForClose(t, errs)
+	})
+
+	t.Run("Reports failures and retries", func(t *testing.T) {
+		server := newTestServer(t, failing("/authn", 1, http.StatusServiceUnavailable))
+		client := newTestServerClient(t, server)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := client.StartTokenRefresher(ctx)
+
+		select {
+		case err := <-errs:
+			assert.ErrorContains(t, err, "503 Service Unavailable")
+		case <-time.After(time.Second):
+			t.Fatal("expected a refresh error")
+		}
+
+		assert.Eventually(t, func() bool { return client.currentToken() != nil }, 3*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 2, server.CountRequests("/authn"))
+
+		cancel()
+		waitForClose(t, errs)
+	})
+
+	t.Run("Backs off when the token isn't renewed", func(t *testing.T) {
+		client := newTestServerClient(t, newTestServer(t))
+		authenticator := &cachingAuthenticator{token: []byte(makeTestToken(0, time.Now().Add(-time.Hour), time.Hour+time.Minute))}
+		client.authenticator = authenticator
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := client.StartTokenRefresher(ctx)
+
+		// Without backoff, the token would be refreshed every second
+		time.Sleep(3500 * time.Millisecond)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&authenticator.calls))
+
+		cancel()
+		waitForClose(t, errs)
+	})
+
+	t.Run("Stops once the token has expired", func(t *testing.T) {
+		server := newTestServer(t, conjurtest.WithTokenTTL(2*time.Second), conjurtest.WithFault(conjurtest.Fault{
+			PathPrefix: "/authn",
+			Status:     http.StatusServiceUnavailable,
+			Skip:       1,
+		}))
+		client := newTestServerClient(t, server)
+		require.NoError(t, client.RefreshToken())
+		expiresAt := client.currentToken().ExpiresAt()
+
+		waitForClose(t, client.StartTokenRefresher(context.Background()))
+		assert.False(t, time.Now().Before(expiresAt))
+		authns := server.CountRequests("/authn")
+		time.Sleep(1500 * time.Millisecond)
+		assert.Equal(t, authns, server.CountRequests("/authn"))
+	})
+
+	t.Run("Isn't started for a fixed token", func(t *testing.T) {
+		server := newTestServer(t)
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+
+		waitForClose(t, client.StartTokenRefresher(context.Background()))
+		assert.Empty(t, server.Requests())
+	})
+
+	t.Run("Stops when the context is done", func(t *testing.T) {
+		server := newTestServer(t)
+		client := newTestServerClient(t, server)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		waitForClose(t, client.StartTokenRefresher(ctx))
+		assert.Equal(t, 0, server.CountRequests("/authn"))
+	})
+}
+
+func TestTokenRefreshDelay(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	newToken := func(issued time.Time, lifetime time.Duration) *authn.AuthnToken {
+		token, err := authn.NewToken([]byte(makeTestToken(0, issued, lifetime)))
+		require.NoError(t, err)
+		return token
+	}
+
+	t.Run("Refreshes right away without a token", func(t *testing.T) {
+		assert.Equal(t, time.Duration(0), tokenRefreshDelay(nil, now))
+	})
+
+	t.Run("Refreshes before the token is due", func(t *testing.T) {
+		token := newToken(now, 100*time.Second)
+		delay := tokenRefreshDelay(token, now)
+		assert.InDelta(t, float64(76500*time.Millisecond), float64(delay), float64(time.Millisecond))
+		assert.True(t, now.Add(delay).Before(token.RefreshAt()))
+	})
+
+	t.Run("Waits at least the minimum interval", func(t *testing.T) {
+		token := newToken(now.Add(-time.Hour), time.Minute)
+		assert.Equal(t, tokenRefresherMinInterval, tokenRefreshDelay(token, now))
+	})
+}
+
+func TestNextTokenRefresherBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, nextTokenRefresherBackoff(0))
+	assert.Equal(t, 2*time.Second, nextTokenRefresherBackoff(time.Second))
+	assert.Equal(t, tokenRefresherMaxBackoff, nextTokenRefresherBackoff(20*time.Second))
+}
@@ -0,0 +1,133 @@
+package conjurapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/response"
+)
+
+const tracerName = "github.com/cyberark/conjur-api-go/conjurapi"
+
+// SetTracerProvider enables OpenTelemetry tracing of the client's operations.
+// Each operation, such as RetrieveSecret or LoadPolicy, produces a span named
+// after it, and the W3C trace context is propagated to Conjur on its
+// requests. Spans carry the operation, account, resource kind, HTTP status
+// and Conjur error code, but never secret values, credentials or tokens.
+//
+// Tracing is disabled by default, and a nil provider disables it again.
+func (c *Client) SetTracerProvider(provider trace.TracerProvider) {
+	if provider == nil {
+		c.tracer = nil
+		return
+	}
+	c.tracer = provider.Tracer(tracerName)
+}
+
+// operationSpan is the span of a high-level client operation.
+type operationSpan struct {
+	trace.Span
+	operation string
+	metrics   Metrics
+}
+
+// startSpan starts the span of an operation on the resource with the given
+// ID, which may be partially-qualified, and defaultKind. Requests made with
+// the returned context are attributed to the operation. When tracing is
+// disabled, the returned span does nothing.
+func (c *Client) startSpan(ctx context.Context, operation string, defaultKind string, resourceID string) (context.Context, operationSpan) {
+	ctx = withOperation(ctx, operation)
+	if c.tracer == nil {
+		return ctx, operationSpan{trace.SpanFromContext(context.Background()), operation, c.metrics}
+	}
+
+	account, kind, _ := unopinionatedParseID(resourceID)
+	if account == "" {
+		account = c.config.Account
+	}
+	if kind == "" {
+		kind = defaultKind
+	}
+
+	attributes := []attribute.KeyValue{
+		attribute.String("conjur.operation", operation),
+		attribute.String("conjur.account", account),
+	}
+	if kind != "" {
+		attributes = append(attributes, attribute.String("conjur.resource.kind", kind))
+	}
+
+	ctx, span := c.tracer.Start(
+		ctx,
+		"conjur."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	return ctx, operationSpan{span, operation, c.metrics}
+}
+
+// end ends the span, recording err as its status. The error message is
+// recorded, but it never contains secret values. Errors returned by Conjur are
+// recorded in the metrics by the response helpers, so only the others, such
+// as network errors, are recorded here.
+func (s operationSpan) end(err error) {
+	var cerr *response.ConjurError
+	isConjurError := errors.As(err, &cerr)
+	if err != nil && !isConjurError && s.metrics != nil {
+		s.metrics.ObserveOperationError(s.operation, ErrorCode(err))
+	}
+	if err != nil && s.IsRecording() {
+		if isConjurError {
+			s.SetAttributes(attribute.Int("http.response.status_code", cerr.Code))
+			if cerr.Details != nil && cerr.Details.Code != "" {
+				s.SetAttributes(attribute.String("conjur.error.code", cerr.Details.Code))
+			}
+		}
+		s.RecordError(err)
+		s.SetStatus(codes.Error, err.Error())
+	}
+	s.End()
+}
+
+// traceRequest propagates the trace context of req to Conjur.
+func (c *Client) traceRequest(req *http.Request) {
+	if c.tracer == nil {
+		return
+	}
+	propagation.TraceContext{}.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
+}
+
+// traceResponse records the HTTP status of a response on the span of the
+// operation which sent the request.
+func (c *Client) traceResponse(req *http.Request, resp *http.Response) {
+	if c.tracer == nil || resp == nil {
+		return
+	}
+	trace.SpanFromContext(req.Context()).SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+}
+
+// loginResourceID returns the ID of the role which logs in as login, e.g.
+// "host:myapp" for "host/myapp".
+func loginResourceID(login string) string {
+	if login == "" {
+		return ""
+	}
+	if identifier, ok := strings.CutPrefix(login, "host/"); ok {
+		return "host:" + identifier
+	}
+	return "user:" + login
+}
+
+func resourceFilterKind(filter *ResourceFilter) string {
+	if filter == nil {
+		return ""
+	}
+	return filter.Kind
+}
@@ -0,0 +1,163 @@
+package conjurapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+)
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attributes[kv.Key] = kv.Value
+	}
+	return attributes
+}
+
+func TestClient_Tracing(t *testing.T) {
+	var traceparents []string
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		traceparents = append(traceparents, r.Header.Get("traceparent"))
+		mutex.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/authenticate"):
+			w.Write([]byte(sample_token))
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"not_found","message":"Variable 'missing' not found"}}`))
+		default:
+			w.Write([]byte("s3cr3t"))
+		}
+	}))
+	defer server.Close()
+
+	reset := func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		traceparents = nil
+	}
+
+	newTracedClient := func(t *testing.T) (*Client, *tracetest.SpanRecorder) {
+		recorder := tracetest.NewSpanRecorder()
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+		client.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		return client, recorder
+	}
+
+	t.Run("Records a span for each operation", func(t *testing.T) {
+		reset()
+		client, recorder := newTracedClient(t)
+
+		_, err := client.RetrieveSecret("prod:variable:db/password")
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "conjur.RetrieveSecret", spans[0].Name())
+		assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+		attributes := spanAttributes(spans[0])
+		assert.Equal(t, "RetrieveSecret", attributes["conjur.operation"].AsString())
+		assert.Equal(t, "prod", attributes["conjur.account"].AsString())
+		assert.Equal(t, "variable", attributes["conjur.resource.kind"].AsString())
+		assert.EqualValues(t, http.StatusOK, attributes["http.response.status_code"].AsInt64())
+	})
+
+	t.Run("Propagates the trace context to Conjur", func(t *testing.T) {
+		reset()
+		client, recorder := newTracedClient(t)
+
+		_, err := client.RetrieveSecret("db/password")
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		require.Len(t, traceparents, 1)
+		assert.Contains(t, traceparents[0], spans[0].SpanContext().TraceID().String())
+		assert.Contains(t, traceparents[0], spans[0].SpanContext().SpanID().String())
+	})
+
+	t.Run("Records the Conjur error", func(t *testing.T) {
+		client, recorder := newTracedClient(t)
+
+		_, err := client.RetrieveSecret("missing")
+		require.Error(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+		attributes := spanAttributes(spans[0])
+		assert.EqualValues(t, http.StatusNotFound, attributes["http.response.status_code"].AsInt64())
+		assert.Equal(t, "not_found", attributes["conjur.error.code"].AsString())
+	})
+
+	t.Run("Records authentication as a child span", func(t *testing.T) {
+		recorder := tracetest.NewSpanRecorder()
+		client, err := NewClientFromKey(
+			Config{Account: "conjur", ApplianceURL: server.URL},
+			authn.LoginPair{Login: "host/myapp", APIKey: "api-key"},
+		)
+		require.NoError(t, err)
+		client.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		_, err = client.RetrieveSecret("db/password")
+		require.NoError(t, err)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "conjur.Authenticate", spans[0].Name())
+		assert.Equal(t, "conjur.RetrieveSecret", spans[1].Name())
+		assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Equal(t, "host", spanAttributes(spans[0])["conjur.resource.kind"].AsString())
+	})
+
+	t.Run("Never records secret values or tokens", func(t *testing.T) {
+		client, recorder := newTracedClient(t)
+
+		_, err := client.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		require.NoError(t, client.AddSecret("db/password", "n3w-s3cr3t"))
+
+		for _, span := range recorder.Ended() {
+			for _, kv := range span.Attributes() {
+				value := kv.Value.Emit()
+				assert.NotContains(t, value, "s3cr3t")
+				assert.NotContains(t, value, "Token")
+			}
+		}
+	})
+
+	t.Run("Is disabled by default", func(t *testing.T) {
+		reset()
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+
+		// A span from the application's own tracer isn't propagated either
+		provider := sdktrace.NewTracerProvider()
+		ctx, span := provider.Tracer("app").Start(context.Background(), "app")
+		defer span.End()
+
+		_, err = client.RetrieveSecretCtx(ctx, "db/password")
+		require.NoError(t, err)
+		assert.Equal(t, []string{""}, traceparents)
+	})
+}
@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -11,11 +12,20 @@ import (
 )
 
 // RetrieveBatchSecrets fetches values for all variables in a slice using a
-// single API call
+// single API call, or several if their IDs don't fit in the URL of a single
+// one (see SetBatchOptions).
 //
 // The authenticated user must have execute privilege on all variables.
 func (c *Client) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte, error) {
-	jsonResponse, err := c.retrieveBatchSecrets(variableIDs, false)
+	return c.RetrieveBatchSecretsCtx(context.Background(), variableIDs)
+}
+
+// RetrieveBatchSecretsCtx is like RetrieveBatchSecrets but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveBatchSecretsCtx(ctx context.Context, variableIDs []string) (_ map[string][]byte, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveBatchSecrets", "variable", "")
+	defer func() { span.end(err) }()
+
+	jsonResponse, err := c.retrieveBatchChunks(ctx, variableIDs, false)
 	if err != nil {
 		return nil, err
 	}
@@ -29,13 +39,22 @@ func (c *Client) RetrieveBatchSecrets(variableIDs []string) (map[string][]byte,
 }
 
 // RetrieveBatchSecretsSafe fetches values for all variables in a slice using a
-// single API call. This version of the method will automatically base64-encode
+// single API call, or several if their IDs don't fit in the URL of a single
+// one (see SetBatchOptions). This version of the method will automatically base64-encode
 // the secrets on the server side allowing the retrieval of binary values in
 // batch requests. Secrets are NOT base64 encoded in the returned map.
 //
 // The authenticated user must have execute privilege on all variables.
 func (c *Client) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]byte, error) {
-	jsonResponse, err := c.retrieveBatchSecrets(variableIDs, true)
+	return c.RetrieveBatchSecretsSafeCtx(context.Background(), variableIDs)
+}
+
+// RetrieveBatchSecretsSafeCtx is like RetrieveBatchSecretsSafe but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveBatchSecretsSafeCtx(ctx context.Context, variableIDs []string) (_ map[string][]byte, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveBatchSecretsSafe", "variable", "")
+	defer func() { span.end(err) }()
+
+	jsonResponse, err := c.retrieveBatchChunks(ctx, variableIDs, true)
 	if err != nil {
 		return nil, err
 	}
@@ -43,11 +62,75 @@ func (c *Client) RetrieveBatchSecretsSafe(variableIDs []string) (map[string][]by
 	return decodeBase64Values(jsonResponse)
 }
 
+// RetrieveBatchSecretsPartial is like RetrieveBatchSecrets, but a variable
+// which is missing, has no value or isn't permitted doesn't fail the whole
+// call. The values which could be fetched are returned along with the error
+// of each variable which couldn't, both keyed by fully-qualified ID. The
+// errors are *response.ConjurError. Other errors, such as network errors or
+// an expired token, fail the call.
+//
+// Batches which fail are split in halves until the failing variables are
+// found, so each of them costs a few more requests.
+func (c *Client) RetrieveBatchSecretsPartial(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return c.RetrieveBatchSecretsPartialCtx(context.Background(), variableIDs)
+}
+
+// RetrieveBatchSecretsPartialCtx is like RetrieveBatchSecretsPartial but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveBatchSecretsPartialCtx(ctx context.Context, variableIDs []string) (_ map[string][]byte, _ map[string]error, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveBatchSecretsPartial", "variable", "")
+	defer func() { span.end(err) }()
+
+	jsonResponse, variableErrors, err := c.retrieveBatchPartial(ctx, variableIDs, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvedVariables := map[string][]byte{}
+	for id, value := range jsonResponse {
+		resolvedVariables[id] = []byte(value)
+	}
+
+	return resolvedVariables, variableErrors, nil
+}
+
+// RetrieveBatchSecretsPartialSafe is like RetrieveBatchSecretsPartial, but
+// retrieves the values base64-encoded like RetrieveBatchSecretsSafe, so that
+// binary values can be fetched.
+func (c *Client) RetrieveBatchSecretsPartialSafe(variableIDs []string) (map[string][]byte, map[string]error, error) {
+	return c.RetrieveBatchSecretsPartialSafeCtx(context.Background(), variableIDs)
+}
+
+// RetrieveBatchSecretsPartialSafeCtx is like RetrieveBatchSecretsPartialSafe but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveBatchSecretsPartialSafeCtx(ctx context.Context, variableIDs []string) (_ map[string][]byte, _ map[string]error, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveBatchSecretsPartialSafe", "variable", "")
+	defer func() { span.end(err) }()
+
+	jsonResponse, variableErrors, err := c.retrieveBatchPartial(ctx, variableIDs, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolvedVariables, err := decodeBase64Values(jsonResponse)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resolvedVariables, variableErrors, nil
+}
+
 // RetrieveSecret fetches a secret from a variable.
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecret(variableID string) ([]byte, error) {
-	resp, err := c.retrieveSecret(variableID)
+	return c.RetrieveSecretCtx(context.Background(), variableID)
+}
+
+// RetrieveSecretCtx is like RetrieveSecret but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveSecretCtx(ctx context.Context, variableID string) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveSecret", "variable", variableID)
+	defer func() { span.end(err) }()
+
+	resp, err := c.retrieveSecret(ctx, variableID)
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +143,15 @@ func (c *Client) RetrieveSecret(variableID string) ([]byte, error) {
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecretReader(variableID string) (io.ReadCloser, error) {
-	resp, err := c.retrieveSecret(variableID)
+	return c.RetrieveSecretReaderCtx(context.Background(), variableID)
+}
+
+// RetrieveSecretReaderCtx is like RetrieveSecretReader but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveSecretReaderCtx(ctx context.Context, variableID string) (_ io.ReadCloser, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveSecretReader", "variable", variableID)
+	defer func() { span.end(err) }()
+
+	resp, err := c.retrieveSecret(ctx, variableID)
 	if err != nil {
 		return nil, err
 	}
@@ -73,7 +164,15 @@ func (c *Client) RetrieveSecretReader(variableID string) (io.ReadCloser, error)
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecretWithVersion(variableID string, version int) ([]byte, error) {
-	resp, err := c.retrieveSecretWithVersion(variableID, version)
+	return c.RetrieveSecretWithVersionCtx(context.Background(), variableID, version)
+}
+
+// RetrieveSecretWithVersionCtx is like RetrieveSecretWithVersion but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveSecretWithVersionCtx(ctx context.Context, variableID string, version int) (_ []byte, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveSecretWithVersion", "variable", variableID)
+	defer func() { span.end(err) }()
+
+	resp, err := c.retrieveSecretWithVersion(ctx, variableID, version)
 	if err != nil {
 		return nil, err
 	}
@@ -86,7 +185,15 @@ func (c *Client) RetrieveSecretWithVersion(variableID string, version int) ([]by
 //
 // The authenticated user must have execute privilege on the variable.
 func (c *Client) RetrieveSecretWithVersionReader(variableID string, version int) (io.ReadCloser, error) {
-	resp, err := c.retrieveSecretWithVersion(variableID, version)
+	return c.RetrieveSecretWithVersionReaderCtx(context.Background(), variableID, version)
+}
+
+// RetrieveSecretWithVersionReaderCtx is like RetrieveSecretWithVersionReader but uses ctx for cancellation and deadlines.
+func (c *Client) RetrieveSecretWithVersionReaderCtx(ctx context.Context, variableID string, version int) (_ io.ReadCloser, err error) {
+	ctx, span := c.startSpan(ctx, "RetrieveSecretWithVersionReader", "variable", variableID)
+	defer func() { span.end(err) }()
+
+	resp, err := c.retrieveSecretWithVersion(ctx, variableID, version)
 	if err != nil {
 		return nil, err
 	}
@@ -94,8 +201,8 @@ func (c *Client) RetrieveSecretWithVersionReader(variableID string, version int)
 	return response.SecretDataResponse(resp)
 }
 
-func (c *Client) retrieveBatchSecrets(variableIDs []string, base64Flag bool) (map[string]string, error) {
-	req, err := c.RetrieveBatchSecretsRequest(variableIDs, base64Flag)
+func (c *Client) retrieveBatchSecrets(ctx context.Context, variableIDs []string, base64Flag bool) (map[string]string, error) {
+	req, err := c.RetrieveBatchSecretsRequestCtx(ctx, variableIDs, base64Flag)
 	if err != nil {
 		return nil, err
 	}
@@ -126,8 +233,8 @@ func (c *Client) retrieveBatchSecrets(variableIDs []string, base64Flag bool) (ma
 	return jsonResponse, nil
 }
 
-func (c *Client) retrieveSecret(variableID string) (*http.Response, error) {
-	req, err := c.RetrieveSecretRequest(variableID)
+func (c *Client) retrieveSecret(ctx context.Context, variableID string) (*http.Response, error) {
+	req, err := c.RetrieveSecretRequestCtx(ctx, variableID)
 	if err != nil {
 		return nil, err
 	}
@@ -135,8 +242,8 @@ func (c *Client) retrieveSecret(variableID string) (*http.Response, error) {
 	return c.SubmitRequest(req)
 }
 
-func (c *Client) retrieveSecretWithVersion(variableID string, version int) (*http.Response, error) {
-	req, err := c.RetrieveSecretWithVersionRequest(variableID, version)
+func (c *Client) retrieveSecretWithVersion(ctx context.Context, variableID string, version int) (*http.Response, error) {
+	req, err := c.RetrieveSecretWithVersionRequestCtx(ctx, variableID, version)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +255,15 @@ func (c *Client) retrieveSecretWithVersion(variableID string, version int) (*htt
 //
 // The authenticated user must have update privilege on the variable.
 func (c *Client) AddSecret(variableID string, secretValue string) error {
-	req, err := c.AddSecretRequest(variableID, secretValue)
+	return c.AddSecretCtx(context.Background(), variableID, secretValue)
+}
+
+// AddSecretCtx is like AddSecret but uses ctx for cancellation and deadlines.
+func (c *Client) AddSecretCtx(ctx context.Context, variableID string, secretValue string) (err error) {
+	ctx, span := c.startSpan(ctx, "AddSecret", "variable", variableID)
+	defer func() { span.end(err) }()
+
+	req, err := c.AddSecretRequestCtx(ctx, variableID, secretValue)
 	if err != nil {
 		return err
 	}
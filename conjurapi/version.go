@@ -1,6 +1,7 @@
 package conjurapi
 
 import (
+	"context"
 	"fmt"
 
 	semver "github.com/Masterminds/semver/v3"
@@ -8,7 +9,12 @@ import (
 
 // VerifyMinServerVersion checks if the server version is at least a certain version, using semantic versioning.
 func (c *Client) VerifyMinServerVersion(minVersion string) error {
-	serverVersion, err := c.ServerVersion()
+	return c.VerifyMinServerVersionCtx(context.Background(), minVersion)
+}
+
+// VerifyMinServerVersionCtx is like VerifyMinServerVersion but uses ctx for cancellation and deadlines.
+func (c *Client) VerifyMinServerVersionCtx(ctx context.Context, minVersion string) error {
+	serverVersion, err := c.ServerVersionCtx(ctx)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,263 @@
+package conjurapi
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// WatcherIntervalDefaultValue is the default delay between two checks
+	// of the watched variables.
+	WatcherIntervalDefaultValue = 30 * time.Second
+	// WatcherMaxBackoffDefaultValue is the default cap of the delay between
+	// checks after errors.
+	WatcherMaxBackoffDefaultValue = 5 * time.Minute
+	// watcherJitter is the fraction of the delay between checks which is
+	// randomized, so that watchers started together don't poll together.
+	watcherJitter = 0.2
+)
+
+// SecretChange is a new version of a watched variable.
+type SecretChange struct {
+	// VariableID is the ID of the variable, as given to the Watcher.
+	VariableID string
+	// OldVersion is the version the variable had when last checked, or 0 if
+	// it had no value.
+	OldVersion int
+	// NewVersion is the latest version of the variable.
+	NewVersion int
+}
+
+// WatcherOptions configures a Watcher.
+type WatcherOptions struct {
+	// Interval is the delay between two checks, WatcherIntervalDefaultValue
+	// if zero. It is randomized by up to 10% either way.
+	Interval time.Duration
+	// MaxBackoff caps the delay between checks after errors,
+	// WatcherMaxBackoffDefaultValue if zero.
+	MaxBackoff time.Duration
+	// OnChange, if set, is called with each change.
+	OnChange func(change SecretChange)
+	// OnError, if set, is called when a variable can't be checked.
+	OnError func(variableID string, err error)
+	// SendChanges makes the watcher send each change on the channel returned
+	// by Changes, which must then be read from.
+	SendChanges bool
+}
+
+// Watcher detects new versions of variables, e.g. so that an application can
+// reconnect to its database when its password rotates. It checks the version
+// metadata of the variables' resources rather than retrieving their values,
+// so it needs the read privilege on them, not execute.
+//
+// A check in which any variable fails doubles the delay before the next one,
+// up to MaxBackoff.
+type Watcher struct {
+	client  *Client
+	options WatcherOptions
+
+	mutex    sync.Mutex
+	ids      []string
+	versions map[string]int
+	changes  chan SecretChange
+	started  bool
+}
+
+// NewWatcher returns a Watcher of the variables with the given IDs, which may
+// be partially- or fully-qualified. Start it with Run.
+func (c *Client) NewWatcher(options WatcherOptions, variableIDs ...string) *Watcher {
+	if options.Interval <= 0 {
+		options.Interval = WatcherIntervalDefaultValue
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = WatcherMaxBackoffDefaultValue
+	}
+	w := &Watcher{
+		client:   c,
+		options:  options,
+		versions: map[string]int{},
+	}
+	if options.SendChanges {
+		w.changes = make(chan SecretChange)
+	}
+	w.Add(variableIDs...)
+	return w
+}
+
+// Add starts watching variables. Their first check only records their
+// version.
+func (w *Watcher) Add(variableIDs ...string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, id := range variableIDs {
+		if !w.watching(id) {
+			w.ids = append(w.ids, id)
+		}
+	}
+}
+
+// Remove stops watching variables.
+func (w *Watcher) Remove(variableIDs ...string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for _, id := range variableIDs {
+		for i, watched := range w.ids {
+			if watched == id {
+				w.ids = append(w.ids[:i], w.ids[i+1:]...)
+				break
+			}
+		}
+		delete(w.versions, id)
+	}
+}
+
+func (w *Watcher) watching(variableID string) bool {
+	for _, id := range w.ids {
+		if id == variableID {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the version of a variable when it was last checked, and
+// whether it has been.
+func (w *Watcher) Version(variableID string) (int, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	version, ok := w.versions[variableID]
+	return version, ok
+}
+
+// Changes returns the channel on which changes are sent, along with calls to
+// OnChange, when WatcherOptions.SendChanges is set, and nil otherwise. Keep
+// reading from it, as the watcher waits for changes to be received. It is
+// closed when Run returns.
+func (w *Watcher) Changes() <-chan SecretChange {
+	return w.changes
+}
+
+// Run checks the variables right away and then periodically, until ctx is
+// done, and returns ctx's error. A Watcher can only be run once.
+func (w *Watcher) Run(ctx context.Context) error {
+	w.mutex.Lock()
+	started := w.started
+	w.started = true
+	w.mutex.Unlock()
+	if started {
+		return fmt.Errorf("Watcher has already been run")
+	}
+	changes := w.changes
+	if changes != nil {
+		defer close(changes)
+	}
+
+	var backoff time.Duration
+	for {
+		if w.check(ctx, changes) {
+			backoff = 0
+		} else if ctx.Err() == nil {
+			backoff = w.nextBackoff(backoff)
+			w.client.log().Warn("Failed to check watched variables", "retry_in", backoff)
+		}
+
+		delay := backoff
+		if delay == 0 {
+			delay = w.options.Interval
+		}
+		if err := sleepContext(ctx, jitter(delay)); err != nil {
+			return err
+		}
+	}
+}
+
+// check checks each variable once, and reports whether all of them could be.
+func (w *Watcher) check(ctx context.Context, changes chan<- SecretChange) bool {
+	w.mutex.Lock()
+	ids := append([]string{}, w.ids...)
+	w.mutex.Unlock()
+
+	ok := true
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return false
+		}
+		version, err := w.latestVersion(ctx, id)
+		if err != nil {
+			ok = false
+			if ctx.Err() == nil && w.options.OnError != nil {
+				w.options.OnError(id, err)
+			}
+			continue
+		}
+
+		w.mutex.Lock()
+		old, checked := w.versions[id]
+		if w.watching(id) {
+			w.versions[id] = version
+		}
+		w.mutex.Unlock()
+		if !checked || old == version {
+			continue
+		}
+
+		change := SecretChange{VariableID: id, OldVersion: old, NewVersion: version}
+		if w.options.OnChange != nil {
+			w.options.OnChange(change)
+		}
+		if changes != nil {
+			select {
+			case changes <- change:
+			case <-ctx.Done():
+				return false
+			}
+		}
+	}
+	return ok
+}
+
+// latestVersion returns the latest version of a variable, from the secrets
+// of its resource, or 0 if it has no value.
+func (w *Watcher) latestVersion(ctx context.Context, variableID string) (int, error) {
+	account, kind, identifier, err := w.client.parseIDandEnforceKind(variableID, "variable")
+	if err != nil {
+		return 0, err
+	}
+	resource, err := w.client.ResourceCtx(ctx, account+":"+kind+":"+identifier)
+	if err != nil {
+		return 0, err
+	}
+
+	latest := 0
+	secrets, _ := resource["secrets"].([]interface{})
+	for _, secret := range secrets {
+		fields, _ := secret.(map[string]interface{})
+		if version, ok := fields["version"].(float64); ok && int(version) > latest {
+			latest = int(version)
+		}
+	}
+	return latest, nil
+}
+
+func (w *Watcher) nextBackoff(backoff time.Duration) time.Duration {
+	if backoff == 0 {
+		backoff = w.options.Interval
+	}
+	backoff *= 2
+	if backoff > w.options.MaxBackoff {
+		backoff = w.options.MaxBackoff
+	}
+	return backoff
+}
+
+// jitter randomizes delay by up to half of watcherJitter either way.
+func jitter(delay time.Duration) time.Duration {
+	spread := int64(float64(delay) * watcherJitter)
+	if spread <= 0 {
+		return delay
+	}
+	return delay - time.Duration(spread/2) + time.Duration(rand.Int63n(spread+1))
+}
@@ -0,0 +1,113 @@
+package conjurapi
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/cyberark/conjur-api-go/conjurapi/conjurtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	server := conjurtest.NewServer("conjur")
+	defer server.Close()
+	require.NoError(t, server.LoadPolicy("root", `
+- !host app
+- !variable db/password
+- !variable api/key
+- !permit
+  role: !host app
+  privileges: [ read ]
+  resources: [ !variable db/password, !variable api/key ]
+`))
+	require.NoError(t, server.AddSecret("variable:db/password", "first"))
+
+	client, err := NewClientFromKey(
+		Config{Account: "conjur", ApplianceURL: server.URL, CredentialStorage: CredentialStorageNone},
+		authn.LoginPair{Login: "host/app", APIKey: server.APIKey("host:app")},
+	)
+	require.NoError(t, err)
+
+	t.Run("Reports new versions", func(t *testing.T) {
+		var mutex sync.Mutex
+		var called []SecretChange
+		watcher := client.NewWatcher(WatcherOptions{
+			Interval: 10 * time.Millisecond,
+			OnChange: func(change SecretChange) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				called = append(called, change)
+			},
+			SendChanges: true,
+		}, "db/password", "conjur:variable:api/key")
+		changes := watcher.Changes()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- watcher.Run(ctx) }()
+
+		require.Eventually(t, func() bool {
+			_, ok := watcher.Version("conjur:variable:api/key")
+			return ok
+		}, 5*time.Second, 5*time.Millisecond)
+		version, _ := watcher.Version("db/password")
+		assert.Equal(t, 1, version)
+		assert.Equal(t, changes, watcher.Changes())
+
+		require.NoError(t, server.AddSecret("variable:db/password", "second"))
+		assert.Equal(t, SecretChange{VariableID: "db/password", OldVersion: 1, NewVersion: 2}, <-changes)
+
+		require.NoError(t, server.AddSecret("variable:api/key", "key"))
+		assert.Equal(t, SecretChange{VariableID: "conjur:variable:api/key", OldVersion: 0, NewVersion: 1}, <-changes)
+
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+		_, open := <-changes
+		assert.False(t, open)
+		assert.EqualError(t, watcher.Run(context.Background()), "Watcher has already been run")
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.Len(t, called, 2)
+	})
+
+	t.Run("Reports errors and backs off", func(t *testing.T) {
+		errs := make(chan string, 10)
+		watcher := client.NewWatcher(WatcherOptions{
+			Interval: 10 * time.Millisecond,
+			OnError: func(variableID string, err error) {
+				select {
+				case errs <- variableID:
+				default:
+				}
+			},
+		}, "missing")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go watcher.Run(ctx)
+
+		assert.Equal(t, "missing", <-errs)
+		assert.Nil(t, watcher.Changes())
+		assert.EqualError(t, watcher.Run(ctx), "Watcher has already been run")
+		watcher.Remove("missing")
+		_, ok := watcher.Version("missing")
+		assert.False(t, ok)
+
+		assert.Equal(t, 20*time.Millisecond, watcher.nextBackoff(0))
+		assert.Equal(t, 40*time.Millisecond, watcher.nextBackoff(20*time.Millisecond))
+		assert.Equal(t, WatcherMaxBackoffDefaultValue, watcher.nextBackoff(WatcherMaxBackoffDefaultValue))
+	})
+}
+
+func Test_jitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		delay := jitter(time.Second)
+		assert.GreaterOrEqual(t, delay, 900*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1100*time.Millisecond)
+	}
+}
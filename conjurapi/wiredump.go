@@ -0,0 +1,126 @@
+package conjurapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/internal/redact"
+)
+
+// wireDumpMaxBody is the number of bytes of a body which are dumped.
+const wireDumpMaxBody = 64 * 1024
+
+// WireDump returns middleware which writes every request sent to Conjur and
+// its response, headers and bodies included, to w along with how long the
+// response took. It is meant for troubleshooting and is not enabled by
+// default:
+//
+//	client.Use(conjurapi.WireDump(os.Stderr))
+//
+// Credentials and secrets are redacted from the dump: the Authorization
+// header, and with it Basic auth credentials and access tokens, API keys and
+// passwords sent to or returned by the authenticators, JWTs and OIDC tokens
+// and codes, the values of secrets sent with AddSecret or returned from
+// /secrets, and API keys and host factory tokens in JSON responses.
+//
+// Bodies are buffered in memory and dumps longer than 64 KiB are truncated.
+func WireDump(w io.Writer) Middleware {
+	var mutex sync.Mutex
+	return func(next RequestHandler) RequestHandler {
+		return func(req *http.Request) (*http.Response, error) {
+			var dump bytes.Buffer
+			if err := dumpRequest(&dump, req); err != nil {
+				return nil, err
+			}
+
+			start := time.Now()
+			resp, err := next(req)
+			elapsed := time.Since(start)
+
+			if err != nil {
+				fmt.Fprintf(&dump, "<-- %s %s error after %s: %v\n\n", req.Method, redact.URL(req.URL), elapsed, err)
+			} else if err = dumpResponse(&dump, req, resp, elapsed); err != nil {
+				resp.Body.Close()
+				return nil, err
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			w.Write(dump.Bytes())
+			return resp, err
+		}
+	}
+}
+
+func dumpRequest(dump *bytes.Buffer, req *http.Request) error {
+	fmt.Fprintf(dump, "--> %s %s\n", req.Method, redact.URL(req.URL))
+	dumpHeaders(dump, req.Header)
+
+	if err := ensureRewindableBody(req); err != nil {
+		return err
+	}
+	if req.GetBody == nil {
+		dump.WriteString("\n")
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	dumpBody(dump, redact.RequestBody(req, content))
+	return nil
+}
+
+func dumpResponse(dump *bytes.Buffer, req *http.Request, resp *http.Response, elapsed time.Duration) error {
+	fmt.Fprintf(dump, "<-- %s %s %s (%s)\n", resp.Status, req.Method, redact.URL(req.URL), elapsed)
+	dumpHeaders(dump, resp.Header)
+
+	content, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(content))
+	dumpBody(dump, redact.ResponseBody(req, resp, content))
+	return nil
+}
+
+func dumpHeaders(dump *bytes.Buffer, headers http.Header) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range headers[name] {
+			if name == "Authorization" || name == "Proxy-Authorization" {
+				value = redact.Redacted
+			}
+			fmt.Fprintf(dump, "%s: %s\n", name, value)
+		}
+	}
+}
+
+func dumpBody(dump *bytes.Buffer, body []byte) {
+	dump.WriteString("\n")
+	if len(body) == 0 {
+		return
+	}
+	if len(body) > wireDumpMaxBody {
+		fmt.Fprintf(dump, "%s\n[%d bytes truncated]\n\n", body[:wireDumpMaxBody], len(body)-wireDumpMaxBody)
+		return
+	}
+	dump.Write(body)
+	dump.WriteString("\n\n")
+}
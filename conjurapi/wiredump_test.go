@@ -0,0 +1,146 @@
+package conjurapi
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cyberark/conjur-api-go/conjurapi/authn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWireDump(t *testing.T) {
+	server := newTestServer(t)
+	require.NoError(t, server.LoadPolicy("root", `
+- !user alice
+- !user bob
+- !variable db/password
+- !host jwt-app
+- !layer apps
+- !host-factory
+  id: factory
+  layers: [ !layer apps ]
+- !permit
+  role: !user alice
+  privileges: [ read, execute, update ]
+  resource: !variable db/password
+`))
+	require.NoError(t, server.AddSecret("variable:db/password", "plain-secret"))
+	server.AddJWT("github", "header.payload.signature", "host:jwt-app")
+	aliceAPIKey := server.APIKey("user:alice")
+	bobAPIKey := server.APIKey("user:bob")
+
+	admin := newTestServerClient(t, server)
+	hfTokens, err := admin.CreateToken("1h", "conjur:host_factory:factory", nil, 1)
+	require.NoError(t, err)
+	hfToken := hfTokens[0].Token
+
+	newClient := func(t *testing.T, dump io.Writer) *Client {
+		client, err := NewClientFromKey(
+			Config{Account: "conjur", ApplianceURL: server.URL, CredentialStorage: CredentialStorageNone},
+			authn.LoginPair{Login: "alice", APIKey: aliceAPIKey},
+		)
+		require.NoError(t, err)
+		client.Use(WireDump(dump))
+		return client
+	}
+
+	t.Run("Dumps requests and responses with timing", func(t *testing.T) {
+		var dump bytes.Buffer
+		client := newClient(t, &dump)
+
+		_, err := client.Resource("conjur:variable:missing")
+		require.Error(t, err)
+
+		out := dump.String()
+		assert.Contains(t, out, "--> POST "+server.URL+"/authn/conjur/alice/authenticate\n")
+		assert.Contains(t, out, "--> GET "+server.URL+"/resources/conjur/variable/missing\n")
+		assert.Regexp(t, `<-- 404 Not Found GET \S+/resources/conjur/variable/missing \(\S+\)\n`, out)
+		assert.Contains(t, out, `"message":"Variable 'missing' not found in account 'conjur'"`)
+	})
+
+	t.Run("Leaves responses readable", func(t *testing.T) {
+		var dump bytes.Buffer
+		client := newClient(t, &dump)
+
+		value, err := client.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		assert.Equal(t, "plain-secret", string(value))
+	})
+
+	t.Run("Redacts credentials and secrets", func(t *testing.T) {
+		var dump bytes.Buffer
+		client := newClient(t, &dump)
+
+		_, err := client.RetrieveSecret("db/password")
+		require.NoError(t, err)
+		require.NoError(t, client.AddSecret("db/password", "batch-secret"))
+		secrets, err := client.RetrieveBatchSecrets([]string{"db/password"})
+		require.NoError(t, err)
+		assert.Equal(t, "batch-secret", string(secrets["conjur:variable:db/password"]))
+		require.NoError(t, client.AddSecret("db/password", "new-secret"))
+		loginAPIKey, err := client.Login("bob", bobAPIKey)
+		require.NoError(t, err)
+		host, err := client.CreateHost("app", hfToken)
+		require.NoError(t, err)
+
+		jwtClient, err := NewClientFromJwt(Config{
+			Account:           "conjur",
+			ApplianceURL:      server.URL,
+			AuthnType:         "jwt",
+			ServiceID:         "github",
+			JWTContent:        "header.payload.signature",
+			CredentialStorage: CredentialStorageNone,
+		})
+		require.NoError(t, err)
+		jwtClient.Use(WireDump(&dump))
+		_, err = jwtClient.JWTAuthenticate("header.payload.signature", "")
+		require.NoError(t, err)
+
+		admin := newTestServerClient(t, server)
+		admin.Use(WireDump(&dump))
+		require.NoError(t, admin.DeleteToken(hfToken))
+
+		out := dump.String()
+		for _, sensitive := range []string{
+			aliceAPIKey,
+			"plain-secret",
+			"batch-secret",
+			"new-secret",
+			"header.payload.signature",
+			bobAPIKey,
+			string(loginAPIKey),
+			host.ApiKey,
+			hfToken,
+			"Basic ",
+			"Token token=",
+			string(client.currentToken().Raw()),
+		} {
+			assert.NotContains(t, out, sensitive)
+		}
+
+		assert.Contains(t, out, "Authorization: [REDACTED]\n")
+		assert.Contains(t, out, `{"conjur:variable:db/password":"[REDACTED]"}`)
+		assert.Contains(t, out, "jwt=%5BREDACTED%5D")
+		assert.Contains(t, out, "--> DELETE "+server.URL+"/host_factory_tokens/%5BREDACTED%5D\n")
+		assert.Contains(t, out, `"api_key":"[REDACTED]"`)
+	})
+
+	t.Run("Dumps transport errors", func(t *testing.T) {
+		var dump bytes.Buffer
+		client, err := NewClientFromToken(Config{Account: "conjur", ApplianceURL: server.URL}, sample_token)
+		require.NoError(t, err)
+		client.Use(WireDump(&dump), func(RequestHandler) RequestHandler {
+			return func(*http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			}
+		})
+
+		_, err = client.RetrieveSecret("db/password")
+		require.Error(t, err)
+		assert.Regexp(t, `<-- GET \S+/secrets/conjur/variable/db%2Fpassword error after \S+: connection refused`, dump.String())
+	})
+}